@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"regexp"
@@ -9,10 +10,132 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"wiki-search/pkg/config"
 	"wiki-search/pkg/model"
+	"wiki-search/pkg/settings"
+	"wiki-search/pkg/store"
+	"wiki-search/pkg/wiki"
 )
 
 func main() {
+	// The environment and then the config file provide the defaults of the flags, so flags
+	// override both. Without a configuration directory only the environment applies.
+	path, _ := config.Path()
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+
+	offline := flag.Bool("offline", false, "browse saved snapshots without network access")
+	wikiName := flag.String("wiki", cfg.Wiki, "wiki selected at startup")
+	resultsPerPage := flag.Int("results-per-page", cfg.ResultsPerPage, "how many search results to request at a time")
+	minQueryLength := flag.Int("min-query-length", cfg.MinQueryLength, "characters a query needs before related titles and previews are fetched for it")
+	quiet := flag.Bool("quiet", cfg.Quiet, "keep status messages short")
+	verbose := flag.Bool("verbose", cfg.Verbose, "print a summary of the requests made on exit")
+	justify := flag.Bool("justify", cfg.Justify, "justify article text to both margins")
+	linkAction := flag.String("link-action", cfg.LinkAction, "what opening a link does: open, copy, open-and-quit or print")
+	footerText := flag.String("footer", cfg.Footer, "template of the article footer")
+	searchTimeout := flag.Duration("search-timeout", cfg.SearchTimeout, "how long a search may take")
+	articleTimeout := flag.Duration("article-timeout", cfg.ArticleTimeout, "how long fetching an article may take")
+	cacheTTL := flag.Duration("cache-ttl", cfg.CacheTTL, "how long a cached article is reused before fetching it again (0 keeps it forever)")
+	maxConcurrentRequests := flag.Int("max-concurrent-requests", cfg.MaxConcurrentRequests, "how many requests may be in flight at once")
+	persistCache := flag.Bool("persist-cache", cfg.PersistCache, "keep the article cache on disk between sessions")
+	snippets := flag.Bool("snippets", cfg.Snippets, "show a short description and extract with search results")
+	contentMode := flag.String("content-mode", cfg.ContentMode, "how article text is made: readable, extract or html")
+	listIndent := flag.Int("list-indent", cfg.ListIndent, "spaces each nested list level is indented by")
+	maxArticleLength := flag.Int("max-article-length", cfg.MaxArticleLength, "article size in bytes above which articles are paged by section")
+	numberedHeadings := flag.Bool("numbered-headings", cfg.NumberedHeadings, "prefix section headings with their numbers")
+	keepCitations := flag.Bool("keep-citations", cfg.KeepCitations, "keep citation markers such as [1] in article text")
+	normalizeTypography := flag.Bool("normalize-typography", cfg.NormalizeTypography, "show curly quotes, dashes and special spaces as ASCII")
+	matchPerLine := flag.Bool("match-per-line", cfg.MatchPerLine, "make n/p skip the other matches on the current line")
+	identifierLinks := flag.Bool("identifier-links", cfg.IdentifierLinks, "make DOIs and ISBNs in articles links to doi.org and a book search")
+	readingCursor := flag.Bool("reading-cursor", cfg.ReadingCursor, "mark a reading line in articles, moved with J/K")
+	scrollStep := flag.Int("scroll-step", cfg.ScrollStep, "lines j/k scroll an article by")
+	pageFraction := flag.Float64("page-fraction", cfg.PageFraction, "fraction of the page ctrl+u/ctrl+d scroll an article by")
+	readingWidth := flag.Int("reading-width", cfg.ReadingWidth, "width article text is wrapped to, if the window is wider (0 uses the whole window)")
+	codeStyle := flag.String("code-style", cfg.CodeStyle, "chroma style to highlight code blocks with, e.g. monokai (empty leaves them plain)")
+	idleTimeout := flag.Duration("idle-timeout", cfg.IdleTimeout, "quit after this long without input (0 never quits)")
+	insecureTLS := flag.Bool("insecure-tls", cfg.InsecureTLS, "skip verifying the wikis' TLS certificates (unsafe)")
+	useLang := flag.String("uselang", cfg.UseLang, "language text generated by the wikis is localized to, e.g. de (empty uses each wiki's language)")
+	fallbackLang := flag.String("fallback-lang", cfg.FallbackLang, "language of the wiki edition searched when a search finds nothing, e.g. en (empty doesn't fall back)")
+	exportSession := flag.String("export-session", cfg.ExportSession, "Markdown file the articles read are exported to on exit")
+	flag.Parse()
+
+	cfg.Wiki = *wikiName
+	cfg.ResultsPerPage = *resultsPerPage
+	cfg.MinQueryLength = *minQueryLength
+	cfg.Quiet = *quiet
+	cfg.Verbose = *verbose
+	cfg.Justify = *justify
+	cfg.LinkAction = *linkAction
+	cfg.Footer = *footerText
+	cfg.SearchTimeout = *searchTimeout
+	cfg.ArticleTimeout = *articleTimeout
+	cfg.CacheTTL = *cacheTTL
+	cfg.MaxConcurrentRequests = *maxConcurrentRequests
+	cfg.PersistCache = *persistCache
+	cfg.Snippets = *snippets
+	cfg.ContentMode = *contentMode
+	cfg.ListIndent = *listIndent
+	cfg.MaxArticleLength = *maxArticleLength
+	cfg.NumberedHeadings = *numberedHeadings
+	cfg.KeepCitations = *keepCitations
+	cfg.NormalizeTypography = *normalizeTypography
+	cfg.MatchPerLine = *matchPerLine
+	cfg.ReadingCursor = *readingCursor
+	cfg.IdentifierLinks = *identifierLinks
+	cfg.ScrollStep = *scrollStep
+	cfg.PageFraction = *pageFraction
+	cfg.ReadingWidth = *readingWidth
+	cfg.CodeStyle = *codeStyle
+	cfg.IdleTimeout = *idleTimeout
+	cfg.InsecureTLS = *insecureTLS
+	cfg.UseLang = *useLang
+	cfg.FallbackLang = *fallbackLang
+	cfg.ExportSession = *exportSession
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	wiki.SearchTimeout = cfg.SearchTimeout
+	wiki.ArticleTimeout = cfg.ArticleTimeout
+	wiki.CacheTTL = cfg.CacheTTL
+	wiki.MaxConcurrentRequests = cfg.MaxConcurrentRequests
+	wiki.SetContentMode(cfg.ContentMode)
+	wiki.ListIndent = cfg.ListIndent
+	wiki.UseLang = cfg.UseLang
+	if cfg.InsecureTLS {
+		wiki.SkipTLSVerify()
+		fmt.Fprintln(os.Stderr, "WARNING: TLS certificate verification is off (-insecure-tls). Anyone on your network can read and alter your traffic to the wikis.")
+	}
+	for name, headers := range cfg.Headers {
+		// The headers were checked by Validate.
+		_ = wiki.SetHeaders(name, headers)
+	}
+	for name, n := range cfg.MaxRedirects {
+		// The limits were checked by Validate.
+		_ = wiki.SetMaxRedirects(name, n)
+	}
+	if cfg.FallbackLang != "" {
+		wiki.SetFallbackLanguage(cfg.FallbackLang)
+	}
+
+	if cfg.PersistCache {
+		// A cache that can't be read just starts out empty.
+		if entries, err := store.ArticleCache(); err == nil {
+			wiki.RestoreCache(entries)
+		}
+	}
+
+	// The link action was checked by Validate.
+	action, _ := settings.ParseLinkAction(cfg.LinkAction)
+	footer, err := model.ParseFooter(cfg.Footer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v; using the default footer\n", err)
+	}
+
 	urlRegex := regexp.MustCompile(`https?://[^\s/$.?#].[^\s]*`)
 
 	// Initial model setup
@@ -23,10 +146,87 @@ func main() {
 	vp := viewport.New(0, 0)
 	vp.YPosition = 2
 
-	p := tea.NewProgram(model.New(ti, vp, urlRegex))
+	// Recent searches are a convenience; start without them if they can't be read.
+	recent, _ := store.RecentSearches()
+
+	opts := []model.Option{
+		model.WithResultFilters(wiki.DropEmptyTitles, wiki.DedupeResults),
+		model.WithRecentSearches(recent),
+		model.WithDefaultWiki(cfg.Wiki),
+		model.WithResultsPerPage(cfg.ResultsPerPage),
+		model.WithMinQueryLength(cfg.MinQueryLength),
+		model.WithMaxArticleLength(cfg.MaxArticleLength),
+		model.WithLinkAction(action),
+		model.WithFooter(footer),
+		model.WithScrollStep(cfg.ScrollStep, cfg.PageFraction),
+		model.WithReadingWidth(cfg.ReadingWidth),
+		model.WithExportPath(cfg.ExportSession),
+		model.WithIdleTimeout(cfg.IdleTimeout),
+	}
+	if *offline {
+		opts = append(opts, model.WithOfflineMode())
+	}
+	if cfg.Quiet {
+		opts = append(opts, model.WithVerbosity(model.VerbosityQuiet))
+	}
+	if cfg.Justify {
+		opts = append(opts, model.WithJustify())
+	}
+	if cfg.Snippets {
+		opts = append(opts, model.WithResultSnippets())
+	}
+	if cfg.Debug {
+		opts = append(opts, model.WithDebug())
+	}
+	if cfg.NumberedHeadings {
+		opts = append(opts, model.WithNumberedHeadings())
+	}
+	if cfg.KeepCitations {
+		opts = append(opts, model.WithCitations())
+	}
+	if cfg.NormalizeTypography {
+		opts = append(opts, model.WithNormalizedTypography())
+	}
+	if cfg.MatchPerLine {
+		opts = append(opts, model.WithMatchPerLine())
+	}
+	if cfg.ReadingCursor {
+		opts = append(opts, model.WithReadingCursor())
+	}
+	if cfg.IdentifierLinks {
+		opts = append(opts, model.WithIdentifierLinks())
+	}
+	if cfg.CodeStyle != "" {
+		opts = append(opts, model.WithCodeStyle(cfg.CodeStyle))
+	}
+
+	p := tea.NewProgram(model.New(ti, vp, urlRegex, opts...))
 
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running program: %v\n", err)
+	finalModel, runErr := p.Run()
+	// Write what is kept in memory however the program ended, e.g. on a signal.
+	m, _ := finalModel.(model.Model)
+	if err := m.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save the reading position: %v\n", err)
+	}
+	if cfg.ExportSession != "" && m.VisitedArticles() > 0 {
+		if err := m.ExportSession(cfg.ExportSession); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not export the session: %v\n", err)
+		}
+	}
+	if cfg.PersistCache {
+		wiki.Prune()
+		if err := store.SaveArticleCache(wiki.CacheEntries()); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save the article cache: %v\n", err)
+		}
+	}
+	if cfg.Verbose {
+		fmt.Fprint(os.Stderr, wiki.CurrentMetrics())
+	}
+	if runErr != nil {
+		fmt.Printf("Error running program: %v\n", runErr)
 		os.Exit(1)
 	}
+	if m.PrintedURL() != "" {
+		fmt.Println(m.PrintedURL())
+	}
 }
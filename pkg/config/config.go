@@ -0,0 +1,240 @@
+// Package config loads the user's settings from the wiki-search config file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"wiki-search/pkg/settings"
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// Config holds the settings of the config file. Every setting has a command-line flag of
+// the same name, with dashes instead of underscores, which overrides it.
+type Config struct {
+	// Wiki is the wiki selected when the application starts, e.g. "wikipedia" or "arch".
+	Wiki string `toml:"wiki"`
+	// ResultsPerPage is how many search results are requested at a time.
+	ResultsPerPage int `toml:"results_per_page"`
+	// MinQueryLength is the number of characters a query needs before related titles and
+	// result summaries are fetched for it automatically.
+	MinQueryLength int `toml:"min_query_length"`
+	// SearchTimeout and ArticleTimeout bound how long searches and article fetches may take,
+	// e.g. "5s".
+	SearchTimeout  time.Duration `toml:"search_timeout"`
+	ArticleTimeout time.Duration `toml:"article_timeout"`
+	// CacheTTL is how long a fetched article is reused; 0 keeps it until the program exits.
+	CacheTTL time.Duration `toml:"cache_ttl"`
+	// MaxConcurrentRequests bounds how many requests are in flight at once.
+	MaxConcurrentRequests int `toml:"max_concurrent_requests"`
+	// PersistCache keeps the article cache on disk between sessions.
+	PersistCache bool `toml:"persist_cache"`
+	// Verbose prints a summary of the requests made, with their latency, on exit.
+	Verbose bool `toml:"verbose"`
+	// Quiet keeps status messages short.
+	Quiet bool `toml:"quiet"`
+	// Justify justifies article text to both margins.
+	Justify bool `toml:"justify"`
+	// Snippets shows a short description and extract with search results.
+	Snippets bool `toml:"snippets"`
+	// LinkAction is what opening a link does: open, copy, open-and-quit or print.
+	LinkAction string `toml:"link_action"`
+	// Footer is the template of the article footer.
+	Footer string `toml:"footer"`
+	// ContentMode is how article text is made from what the wiki returns: readable, extract
+	// or html.
+	ContentMode string `toml:"content_mode"`
+	// ListIndent is the number of spaces each nested list level is indented by.
+	ListIndent int `toml:"list_indent"`
+	// MaxArticleLength is the article size, in bytes, above which articles are paged by section.
+	MaxArticleLength int `toml:"max_article_length"`
+	// NumberedHeadings prefixes section headings with their numbers, e.g. "1.2 Early years".
+	NumberedHeadings bool `toml:"numbered_headings"`
+	// KeepCitations keeps inline citation markers such as "[1]" in article text.
+	KeepCitations bool `toml:"keep_citations"`
+	// NormalizeTypography shows curly quotes, dashes and other typographic characters as
+	// ASCII, so plain hyphens and quotes find them.
+	NormalizeTypography bool `toml:"normalize_typography"`
+	// MatchPerLine makes n/p skip the other matches on the current line.
+	MatchPerLine bool `toml:"match_per_line"`
+	// ReadingCursor marks a reading line in articles, moved with J/K.
+	ReadingCursor bool `toml:"reading_cursor"`
+	// IdentifierLinks makes DOIs and ISBNs in articles links to doi.org and a book search.
+	IdentifierLinks bool `toml:"identifier_links"`
+	// ScrollStep is how many lines j/k scroll an article, and PageFraction the fraction of
+	// the page ctrl+u/ctrl+d scroll it by.
+	ScrollStep   int     `toml:"scroll_step"`
+	PageFraction float64 `toml:"page_fraction"`
+	// ReadingWidth is the width article text is wrapped to, if the window is wider; 0 uses the
+	// whole window.
+	ReadingWidth int `toml:"reading_width"`
+	// CodeStyle is the chroma style code blocks are highlighted with, e.g. "monokai"; empty
+	// leaves them plain.
+	CodeStyle string `toml:"code_style"`
+	// IdleTimeout quits the application after this long without input; 0 never quits.
+	IdleTimeout time.Duration `toml:"idle_timeout"`
+	// InsecureTLS skips verifying the wikis' certificates, for networks whose proxy intercepts
+	// HTTPS. It is off by default and warned about when on.
+	InsecureTLS bool `toml:"insecure_tls"`
+	// UseLang is the language text generated by the wikis' APIs is localized to, e.g. "de";
+	// empty uses each wiki's own language.
+	UseLang string `toml:"uselang"`
+	// FallbackLang is the language of the edition of a wiki searched when a search of the wiki
+	// finds nothing, e.g. "de"; empty doesn't fall back.
+	FallbackLang string `toml:"fallback_lang"`
+	// ExportSession is the Markdown file the articles read are exported to on exit and with
+	// 'E'; empty exports only with 'E', to the current directory.
+	ExportSession string `toml:"export_session"`
+	// Debug shows a panel of the computed spans over the article, for finding rendering bugs.
+	Debug bool `toml:"debug"`
+	// Headers holds extra HTTP headers per wiki, e.g. to authenticate to a private wiki. It
+	// has no command-line flag.
+	Headers map[string]map[string]string `toml:"headers"`
+	// MaxRedirects limits how many redirects requests to a wiki follow; 0 disables following
+	// them. It has no command-line flag.
+	MaxRedirects map[string]int `toml:"max_redirects"`
+}
+
+// DefaultConfig returns the built-in settings, used for anything the config file leaves out.
+func DefaultConfig() Config {
+	return Config{
+		Wiki:                  wiki.Sources[0].Name,
+		ResultsPerPage:        10,
+		MinQueryLength:        settings.DefaultMinQueryLength,
+		SearchTimeout:         wiki.SearchTimeout,
+		ArticleTimeout:        wiki.ArticleTimeout,
+		CacheTTL:              wiki.CacheTTL,
+		MaxConcurrentRequests: wiki.MaxConcurrentRequests,
+		LinkAction:            string(settings.LinkOpen),
+		Footer:                settings.DefaultFooter,
+		ContentMode:           wiki.ContentReadable,
+		ListIndent:            wiki.ListIndent,
+		MaxArticleLength:      settings.DefaultMaxArticleLength,
+		ScrollStep:            settings.DefaultScrollStep,
+		PageFraction:          settings.DefaultPageFraction,
+	}
+}
+
+// Path returns where the config file is looked for: config.toml in the wiki-search
+// directory under the user's configuration directory.
+func Path() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "wiki-search", "config.toml"), nil
+}
+
+// Load reads the options set by environment variables (see FromEnv) on top of DefaultConfig,
+// then the config file at path on top of those, and validates the result. A missing file, or
+// an empty path when there is no configuration directory, is not an error; it just leaves the
+// defaults and the environment in place.
+func Load(path string) (Config, error) {
+	cfg, err := FromEnv(DefaultConfig(), os.LookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+	if path == "" {
+		return cfg, cfg.Validate()
+	}
+	meta, err := toml.DecodeFile(path, &cfg)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, cfg.Validate()
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("%s: unknown setting %q", path, undecoded[0].String())
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// languageCode matches the language codes MediaWiki localizes to, e.g. "de" or "pt-br".
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]+)*$`)
+
+// Validate reports the first setting that has an unusable value. The footer template is
+// checked when it is parsed instead, so a broken one can fall back to the default footer.
+func (c Config) Validate() error {
+	if !slices.Contains(wiki.SourceNames(), c.Wiki) {
+		return fmt.Errorf("unknown wiki %q", c.Wiki)
+	}
+	if c.ResultsPerPage < 1 || c.ResultsPerPage > 500 {
+		return fmt.Errorf("results_per_page must be between 1 and 500, not %d", c.ResultsPerPage)
+	}
+	if c.MinQueryLength < 0 {
+		return errors.New("min_query_length can't be negative")
+	}
+	if c.SearchTimeout <= 0 || c.ArticleTimeout <= 0 {
+		return errors.New("search_timeout and article_timeout must be positive")
+	}
+	if c.CacheTTL < 0 {
+		return errors.New("cache_ttl can't be negative")
+	}
+	if c.IdleTimeout < 0 {
+		return errors.New("idle_timeout can't be negative")
+	}
+	if c.MaxConcurrentRequests < 1 {
+		return errors.New("max_concurrent_requests must be positive")
+	}
+	if _, err := settings.ParseLinkAction(c.LinkAction); err != nil {
+		return err
+	}
+	if !slices.Contains(wiki.ContentModes, c.ContentMode) {
+		return fmt.Errorf("content_mode must be one of %s, not %q", strings.Join(wiki.ContentModes, ", "), c.ContentMode)
+	}
+	if c.ListIndent < 0 {
+		return errors.New("list_indent can't be negative")
+	}
+	if c.MaxArticleLength < 1 {
+		return errors.New("max_article_length must be positive")
+	}
+	if c.ScrollStep < 1 {
+		return errors.New("scroll_step must be positive")
+	}
+	if c.PageFraction <= 0 || c.PageFraction > 1 {
+		return fmt.Errorf("page_fraction must be above 0 and at most 1, not %g", c.PageFraction)
+	}
+	if c.ReadingWidth != 0 && c.ReadingWidth < settings.MinReadingWidth {
+		return fmt.Errorf("reading_width must be 0 (the whole window) or at least %d, not %d", settings.MinReadingWidth, c.ReadingWidth)
+	}
+	if c.UseLang != "" && !languageCode.MatchString(c.UseLang) {
+		return fmt.Errorf("uselang must be a language code such as de or pt-br, not %q", c.UseLang)
+	}
+	if c.FallbackLang != "" && !languageCode.MatchString(c.FallbackLang) {
+		return fmt.Errorf("fallback_lang must be a language code such as en or de, not %q", c.FallbackLang)
+	}
+	if c.CodeStyle != "" && !utils.CodeStyleExists(c.CodeStyle) {
+		return fmt.Errorf("unknown code_style %q", c.CodeStyle)
+	}
+	for name, headers := range c.Headers {
+		if !slices.Contains(wiki.SourceNames(), name) {
+			return fmt.Errorf("headers for unknown wiki %q", name)
+		}
+		if err := wiki.ValidateHeaders(headers); err != nil {
+			return fmt.Errorf("headers for %s: %w", name, err)
+		}
+	}
+	for name, n := range c.MaxRedirects {
+		if !slices.Contains(wiki.SourceNames(), name) {
+			return fmt.Errorf("max_redirects for unknown wiki %q", name)
+		}
+		if n < 0 {
+			return fmt.Errorf("max_redirects for %s can't be negative", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestValidateScrollSettings(t *testing.T) {
+	tests := []struct {
+		step     int
+		fraction float64
+		valid    bool
+	}{
+		{1, 0.5, true},
+		{5, 1, true},
+		{0, 0.5, false},
+		{-2, 0.5, false},
+		{1, 0, false},
+		{1, -0.5, false},
+		{1, 1.5, false},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.ScrollStep, cfg.PageFraction = tt.step, tt.fraction
+		if err := cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("scroll_step %d, page_fraction %g: Validate() = %v, want valid %v", tt.step, tt.fraction, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateUseLang(t *testing.T) {
+	for lang, valid := range map[string]bool{"": true, "de": true, "pt-br": true, "zh-hans": true, "German": false, "de_DE": false, "en;x": false} {
+		cfg := DefaultConfig()
+		cfg.UseLang = lang
+		if err := cfg.Validate(); (err == nil) != valid {
+			t.Errorf("uselang %q: Validate() = %v, want valid %v", lang, err, valid)
+		}
+	}
+}
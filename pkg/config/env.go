@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the names of the environment variables that set options, e.g.
+// WIKISEARCH_RESULTS_PER_PAGE for results_per_page.
+const EnvPrefix = "WIKISEARCH_"
+
+// envAliases are shorter names for some of the environment variables. Like the full names
+// they carry EnvPrefix, so WIKISEARCH_LANG can't be mistaken for the system's LANG.
+var envAliases = map[string]string{
+	"LANG":    "uselang",
+	"LIMIT":   "results_per_page",
+	"TIMEOUT": "search_timeout",
+}
+
+// FromEnv sets the options given by environment variables on top of cfg. Every setting of
+// the config file except the per-wiki tables has a variable named after it, e.g. WIKISEARCH_WIKI
+// or WIKISEARCH_SEARCH_TIMEOUT, and envAliases add a few shorter names. Empty variables are
+// ignored; a value that can't be converted to the setting's type is reported by name.
+func FromEnv(cfg Config, lookup func(string) (string, bool)) (Config, error) {
+	v := reflect.ValueOf(&cfg).Elem()
+	fields := map[string]reflect.Value{}
+	var names []string
+	for i := range v.NumField() {
+		setting := v.Type().Field(i).Tag.Get("toml")
+		if setting == "" || v.Field(i).Kind() == reflect.Map {
+			continue
+		}
+		fields[setting] = v.Field(i)
+		names = append(names, strings.ToUpper(setting))
+	}
+	// Aliases go first, so the full name of a setting wins if both are set.
+	var aliases []string
+	for alias := range envAliases {
+		aliases = append(aliases, alias)
+	}
+	slices.Sort(aliases)
+	setting := func(name string) string {
+		if s, ok := envAliases[name]; ok {
+			return s
+		}
+		return strings.ToLower(name)
+	}
+	for _, name := range append(aliases, names...) {
+		value, ok := lookup(EnvPrefix + name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := setField(fields[setting(name)], strings.TrimSpace(value)); err != nil {
+			return cfg, fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// setField converts value to the type of field and stores it.
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 5s or 2m", value)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("can't be set from the environment")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Setenv(EnvPrefix+"RESULTS_PER_PAGE", "20")
+	t.Setenv(EnvPrefix+"SEARCH_TIMEOUT", "7s")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("results_per_page = 30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ResultsPerPage != 30 {
+		t.Errorf("results_per_page = %d, want 30 from the config file over the environment", cfg.ResultsPerPage)
+	}
+	if cfg.SearchTimeout != 7*time.Second {
+		t.Errorf("search_timeout = %v, want 7s from the environment over the default", cfg.SearchTimeout)
+	}
+	if want := DefaultConfig().ArticleTimeout; cfg.ArticleTimeout != want {
+		t.Errorf("article_timeout = %v, want the default %v", cfg.ArticleTimeout, want)
+	}
+}
+
+func TestFromEnvAliases(t *testing.T) {
+	env := map[string]string{
+		EnvPrefix + "LIMIT":          "15",
+		EnvPrefix + "TIMEOUT":        "3s",
+		EnvPrefix + "SEARCH_TIMEOUT": "4s",
+		EnvPrefix + "LANG":           "de",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	cfg, err := FromEnv(DefaultConfig(), lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ResultsPerPage != 15 {
+		t.Errorf("results_per_page = %d, want 15 from the alias", cfg.ResultsPerPage)
+	}
+	if cfg.SearchTimeout != 4*time.Second {
+		t.Errorf("search_timeout = %v, want 4s from the full name over the alias", cfg.SearchTimeout)
+	}
+	if cfg.UseLang != "de" {
+		t.Errorf("uselang = %q, want de from the alias", cfg.UseLang)
+	}
+	// The system's own LANG isn't read.
+	if cfg, err := FromEnv(DefaultConfig(), func(name string) (string, bool) {
+		if name == "LANG" {
+			return "fr_FR.UTF-8", true
+		}
+		return "", false
+	}); err != nil || cfg.UseLang != "" {
+		t.Errorf("uselang = %q (%v), want it left alone by LANG", cfg.UseLang, err)
+	}
+}
+
+func TestFromEnvDebug(t *testing.T) {
+	for value, want := range map[string]bool{"1": true, "true": true, "0": false, "": false} {
+		cfg, err := FromEnv(DefaultConfig(), func(name string) (string, bool) {
+			return value, name == EnvPrefix+"DEBUG"
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Debug != want {
+			t.Errorf("%sDEBUG=%q: debug = %v, want %v", EnvPrefix, value, cfg.Debug, want)
+		}
+	}
+	if _, err := FromEnv(DefaultConfig(), func(name string) (string, bool) {
+		return "yes please", name == EnvPrefix+"DEBUG"
+	}); err == nil {
+		t.Errorf("%sDEBUG=\"yes please\" accepted", EnvPrefix)
+	}
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/settings"
+	"wiki-search/pkg/utils"
+)
+
+// WithLinkAction sets what activating a link does. The default is settings.LinkOpen.
+func WithLinkAction(action settings.LinkAction) Option {
+	return func(m *Model) {
+		m.linkAction = action
+	}
+}
+
+// PrintedURL returns the link to print once the program has exited, if settings.LinkPrint was used.
+func (m Model) PrintedURL() string {
+	return m.printURL
+}
+
+// activateLink handles pageURL according to the configured link action. A browser that
+// can't be launched falls back to copying the link, or to showing it.
+func (m *Model) activateLink(pageURL string) tea.Cmd {
+	switch m.linkAction {
+	case settings.LinkPrint:
+		m.printURL = pageURL
+		return tea.Quit
+	case settings.LinkCopy:
+		if err := clipboard.WriteAll(pageURL); err != nil {
+			m.statusMsg = fmt.Sprintf("Could not copy to the clipboard (%v): %s", err, pageURL)
+		} else {
+			m.statusMsg = m.verbose("Link copied", "Copied to the clipboard: "+pageURL)
+		}
+		return nil
+	}
+
+	if err := utils.OpenInBrowser(pageURL); err != nil {
+		if clipErr := clipboard.WriteAll(pageURL); clipErr == nil {
+			m.statusMsg = fmt.Sprintf("Could not open a browser (%v). The URL was copied to the clipboard: %s", err, pageURL)
+		} else {
+			m.statusMsg = fmt.Sprintf("Could not open a browser (%v). Open this URL manually: %s", err, pageURL)
+		}
+		return nil
+	}
+	if m.linkAction == settings.LinkOpenAndQuit {
+		return tea.Quit
+	}
+	m.statusMsg = m.verbose("Opened in browser", "Opened in your browser: "+pageURL)
+	return nil
+}
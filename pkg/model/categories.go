@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/wiki"
+)
+
+// showCategories lists the categories of the current article.
+func (m *Model) showCategories() {
+	if len(m.categories) == 0 {
+		m.articleNotice = "This article has no categories."
+		return
+	}
+	m.categoryCursor = min(m.categoryCursor, len(m.categories)-1)
+	m.state = categoryListView
+}
+
+// searchCategory searches the selected category, keeping the article to return to with esc.
+func (m *Model) searchCategory() tea.Cmd {
+	m.category = m.categories[m.categoryCursor]
+	m.returnTo = &articleOrigin{title: m.selectedTitle, wikiType: m.searchType}
+	m.state = searchResultsView
+	m.textInput.SetValue("")
+	m.textInput.Blur()
+	return m.startRequest(m.verbose("Searching...", fmt.Sprintf("Listing pages in Category:%s...", m.category)), wiki.SearchTimeout,
+		wiki.PerformSearch("", m.searchType, m.searchOptions()))
+}
+
+// categoryListView renders the categories of the current article.
+func (m Model) categoryListView() string {
+	s := strings.Builder{}
+	mainColor := color.New(color.FgWhite).SprintFunc()
+	s.WriteString(color.New(color.Bold, color.FgCyan).Sprint(m.fitWidth(m.articleTitle(), 0)))
+	s.WriteString(mainColor(fmt.Sprintf("\n\n%d categories:\n\n", len(m.categories))))
+	for i, category := range m.categories {
+		cursor := "  "
+		if i == m.categoryCursor {
+			cursor = color.New(color.Bold, color.FgGreen).Sprint("> ")
+		}
+		s.WriteString(fmt.Sprintf("%s%s\n", cursor, mainColor(m.fitWidth(category, 2))))
+	}
+	s.WriteString(mainColor("\n\nEnter to list the pages in a category, Esc to go back to the article, 'q' to quit."))
+	return s.String()
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"wiki-search/pkg/utils"
+)
+
+// referencesHeadingRegex matches the heading of the section that lists an article's
+// references, possibly numbered.
+var referencesHeadingRegex = regexp.MustCompile(`(?m)^(\d+(\.\d+)* )?(References|Notes|Footnotes|Citations)\s*$`)
+
+// referenceSections are the titles the references section goes by, in order of preference.
+var referenceSections = []string{"References", "Notes", "Footnotes", "Citations"}
+
+// citationOrigin is where the reader was before following a citation.
+type citationOrigin struct {
+	sectionIndex int
+	offset       int
+}
+
+// WithCitations keeps inline citation markers such as "[1]" in article text, so 'C' can
+// follow them to their references. By default they are removed.
+func WithCitations() Option {
+	return func(m *Model) {
+		m.keepCitations = true
+	}
+}
+
+// followCitation jumps to the reference of the first numbered citation on screen, or back to
+// where the reader was if they just followed one.
+func (m *Model) followCitation() {
+	if origin := m.citationOrigin; origin != nil {
+		m.citationOrigin = nil
+		if m.sectionPaging && m.sectionIndex != origin.sectionIndex {
+			m.sectionIndex = origin.sectionIndex
+			m.refreshContent()
+		}
+		m.viewport.SetYOffset(origin.offset)
+		m.articleNotice = m.verbose("Back", "Back to where you were reading.")
+		return
+	}
+	// top is the offset of the first line on screen.
+	top := 0
+	for range m.viewport.YOffset {
+		next := strings.IndexByte(m.rendered[top:], '\n')
+		if next < 0 {
+			break
+		}
+		top += next + 1
+	}
+	spans, numbers := utils.CitationNumbers(m.rendered)
+	n := 0
+	for i, span := range spans {
+		if span[0] >= top {
+			n = numbers[i]
+			break
+		}
+	}
+	if n == 0 {
+		m.articleNotice = "No numbered citation on or below this screen."
+		return
+	}
+
+	origin := &citationOrigin{sectionIndex: m.sectionIndex, offset: m.viewport.YOffset}
+	if m.sectionPaging {
+		for _, title := range referenceSections {
+			if m.gotoFragment(title) {
+				break
+			}
+		}
+	}
+	heading := referencesHeadingRegex.FindStringIndex(m.rendered)
+	at := -1
+	if heading != nil {
+		at = utils.FindReference(m.rendered, heading[1], n)
+	}
+	if at < 0 {
+		if m.sectionPaging && m.sectionIndex != origin.sectionIndex {
+			m.sectionIndex = origin.sectionIndex
+			m.refreshContent()
+		}
+		m.viewport.SetYOffset(origin.offset)
+		m.articleNotice = fmt.Sprintf("Reference %d could not be found.", n)
+		return
+	}
+	m.citationOrigin = origin
+	m.viewport.SetYOffset(strings.Count(m.rendered[:at], "\n"))
+	m.articleNotice = fmt.Sprintf("Reference %d. Press 'C' to go back.", n)
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+// citedArticle has citation markers at the top and its references far below.
+func citedArticle() wiki.ArticleMsg {
+	content := []string{"Go was designed at Google.[1] It is statically typed.[2][citation needed]"}
+	for i := range 30 {
+		content = append(content, fmt.Sprintf("Paragraph %d about the language.", i+1))
+	}
+	content = append(content, "References", "1. Pike, Rob. Go at Google.\n2. Griesemer, Robert. The Go Programming Language.")
+	return wiki.ArticleMsg{Article: wiki.Article{
+		Title:    "Go (programming language)",
+		Content:  strings.Join(content, "\n\n"),
+		Sections: []wiki.Section{{Index: "1", Level: "2", Line: "References", Number: "1"}},
+		Mode:     wiki.ContentReadable,
+	}}
+}
+
+// openCited opens citedArticle in a model made with opts.
+func openCited(opts ...Option) Model {
+	var model tea.Model = newTestModel(opts...)
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{citedArticle()}) {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestCitationsStrippedByDefault(t *testing.T) {
+	m := openCited()
+	if !strings.Contains(m.rendered, "Google. It is statically typed.") {
+		t.Errorf("citation markers not stripped:\n%s", m.rendered)
+	}
+	if view := m.View(); strings.Contains(view, "follow a citation") {
+		t.Errorf("footer offers to follow citations that aren't shown:\n%s", view)
+	}
+	// Without markers there is nothing for 'C' to follow.
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if m := model.(Model); m.viewport.YOffset != 0 || m.citationOrigin != nil {
+		t.Errorf("'C' moved to line %d without citations kept", m.viewport.YOffset)
+	}
+}
+
+func TestFollowCitation(t *testing.T) {
+	m := openCited(WithCitations())
+	if !strings.Contains(m.rendered, "Google.[1] It is statically typed.[2][citation needed]") {
+		t.Errorf("citation markers not kept:\n%s", m.rendered)
+	}
+	if view := m.View(); !strings.Contains(view, "'C' to follow a citation") {
+		t.Errorf("footer doesn't offer to follow citations:\n%s", view)
+	}
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	m = model.(Model)
+	if m.viewport.YOffset == 0 || !strings.Contains(m.viewport.View(), "Pike, Rob") {
+		t.Errorf("'C' scrolled to line %d, want the first reference on screen:\n%s", m.viewport.YOffset, m.viewport.View())
+	}
+	if !strings.Contains(m.articleNotice, "Reference 1") {
+		t.Errorf("notice %q, want it to name reference 1", m.articleNotice)
+	}
+
+	// Pressing it again goes back to where the reader was.
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if m = model.(Model); m.viewport.YOffset != 0 || m.citationOrigin != nil {
+		t.Errorf("second 'C' left the view at line %d, want it back at the top", m.viewport.YOffset)
+	}
+
+	// Below the last citation there is nothing to follow.
+	m.viewport.SetYOffset(5)
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if m = model.(Model); m.viewport.YOffset != 5 || !strings.Contains(m.articleNotice, "No numbered citation") {
+		t.Errorf("'C' below the citations: line %d, notice %q; want to stay put and be told why", m.viewport.YOffset, m.articleNotice)
+	}
+}
+
+func TestArticleOfOnlyCitations(t *testing.T) {
+	tests := []struct {
+		content string
+		shown   string
+		notice  bool
+	}{
+		{"[1][2]\n\n[citation needed] [note 3]", "[1][2]", true},
+		{"Go is typed.[1]", "Go is typed.", false},
+		// Text that was blank to begin with has nothing to fall back to.
+		{" \n\n ", "", false},
+	}
+	for _, tt := range tests {
+		article := wiki.ArticleMsg{Article: wiki.Article{Title: "Go", Content: tt.content, Mode: wiki.ContentReadable}}
+		var model tea.Model = newTestModel()
+		for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+			model, _ = model.Update(msg)
+		}
+		m := model.(Model)
+		if !strings.Contains(m.rendered, tt.shown) || (tt.shown == "" && strings.TrimSpace(m.rendered) != "") {
+			t.Errorf("%q shown as %q, want %q", tt.content, m.rendered, tt.shown)
+		}
+		if got := strings.Contains(m.articleNotice, "citation markers are removed"); got != tt.notice {
+			t.Errorf("%q: notice %q, want one about the fallback: %v", tt.content, m.articleNotice, tt.notice)
+		}
+		if tt.notice && !strings.Contains(m.View(), "citation markers are removed") {
+			t.Errorf("%q: fallback notice not on screen:\n%s", tt.content, m.View())
+		}
+	}
+}
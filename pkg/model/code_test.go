@@ -0,0 +1,75 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/wiki"
+)
+
+// codeArticle has a bash code block, with a line wider than the window, between prose.
+var codeArticle = wiki.ArticleMsg{Article: wiki.Article{
+	Title: "Systemd/Timers",
+	Content: "Timers are systemd unit files whose name ends in .timer and that control service files.\n\n" +
+		"```bash\n# systemctl list-timers --all --output=json | jq '.[] | select(.unit | test(\"backup\"))'\nfor unit in *.timer; do\n\tsystemctl status \"$unit\"\ndone\n```\n\n" +
+		"The timers are then listed.",
+	Mode: wiki.ContentReadable,
+}}
+
+// openCode opens codeArticle in a window 50 columns wide.
+func openCode(opts ...Option) Model {
+	var model tea.Model = newTestModel(opts...)
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 50, Height: 24}}, toResults, keys("enter"), []tea.Msg{codeArticle}) {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestCodeBlockVerbatim(t *testing.T) {
+	m := openCode()
+	code := "# systemctl list-timers --all --output=json | jq '.[] | select(.unit | test(\"backup\"))'\nfor unit in *.timer; do\n        systemctl status \"$unit\"\ndone"
+	if !strings.Contains(m.rendered, code) {
+		t.Errorf("code not kept verbatim with its tab expanded:\n%s", m.rendered)
+	}
+	if strings.Contains(m.rendered, "```") {
+		t.Errorf("code fences shown:\n%s", m.rendered)
+	}
+	// The prose around it is still wrapped to the window.
+	for _, line := range strings.Split(m.rendered, "\n") {
+		if len(line) > m.viewport.Width && !strings.HasPrefix(line, "# systemctl") {
+			t.Errorf("line %q is wider than the window", line)
+		}
+	}
+	if len(m.codeBlocks) != 1 || m.codeBlocks[0].Lang != "bash" || !strings.HasPrefix(m.rendered[m.codeBlocks[0].Start:], "# systemctl") {
+		t.Errorf("code blocks %+v, want the bash block located", m.codeBlocks)
+	}
+
+	// Searching finds text inside the code.
+	m = find(t, m, "status")
+	if got := m.rendered[m.matchSpans[0][0]:m.matchSpans[0][1]]; got != "status" || m.matchSpans[0][0] < m.codeBlocks[0].Start {
+		t.Errorf("match %q at %d, want status in the code", got, m.matchSpans[0][0])
+	}
+}
+
+func TestCodeBlockHighlighted(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	plain := openCode().renderArticle()
+	highlighted := openCode(WithCodeStyle("monokai")).renderArticle()
+	if highlighted == plain {
+		t.Error("code not highlighted with a code style set")
+	}
+	if ansi.Strip(highlighted) != ansi.Strip(plain) {
+		t.Errorf("highlighting changed the text:\n%s\nwant\n%s", ansi.Strip(highlighted), ansi.Strip(plain))
+	}
+	// The prose before the code is colored the same either way.
+	if first := strings.Split(plain, "\n")[0]; !strings.HasPrefix(highlighted, first) {
+		t.Errorf("prose highlighted as code: %q", strings.Split(highlighted, "\n")[0])
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+// contentModeNames describe the content modes in notices.
+var contentModeNames = map[string]string{
+	wiki.ContentReadable: "readable text",
+	wiki.ContentExtract:  "a plain-text extract",
+	wiki.ContentHTML:     "the full page",
+}
+
+// cycleContentMode switches to the next content mode and fetches the open article again in
+// it. The mode stays in use for the articles opened after it.
+func (m *Model) cycleContentMode() tea.Cmd {
+	if m.offline {
+		m.articleNotice = "Snapshots keep the text they were saved with."
+		return nil
+	}
+	mode := wiki.NextContentMode(wiki.ContentMode())
+	wiki.SetContentMode(mode)
+	m.refreshing = true
+	status := "Loading " + contentModeNames[mode] + "..."
+	m.articleNotice = status
+	return m.startRequest(status, wiki.ArticleTimeout, wiki.RefreshArticle(m.selectedTitle, m.searchType))
+}
+
+// contentModeNotice returns the notice for an article fetched in mode after the one shown was
+// fetched in shown, or empty if the mode didn't change.
+func contentModeNotice(shown, mode string) string {
+	if mode == "" || mode == shown {
+		return ""
+	}
+	return "Showing " + contentModeNames[mode] + "."
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+
+	"wiki-search/pkg/utils"
+)
+
+// WithReadingCursor marks a reading line in articles, which J/K move independently of
+// scrolling.
+func WithReadingCursor() Option {
+	return func(m *Model) {
+		m.readingCursor = true
+	}
+}
+
+// cursorRow returns the line of the rendered article the reading cursor is on. Scrolling
+// doesn't move the cursor, but it never leaves the screen: a cursor scrolled off is shown on
+// the nearest line still on screen.
+func (m Model) cursorRow() int {
+	last := min(m.viewport.YOffset+m.viewport.Height, m.viewport.TotalLineCount()) - 1
+	return max(m.viewport.YOffset, min(m.cursorLine, last))
+}
+
+// moveReadingCursor moves the reading cursor delta lines down, or up if negative, scrolling
+// the article when the cursor reaches the top or bottom of the screen.
+func (m *Model) moveReadingCursor(delta int) {
+	m.cursorLine = max(0, min(m.cursorRow()+delta, m.viewport.TotalLineCount()-1))
+	switch {
+	case m.cursorLine < m.viewport.YOffset:
+		m.viewport.SetYOffset(m.cursorLine)
+	case m.cursorLine >= m.viewport.YOffset+m.viewport.Height:
+		m.viewport.SetYOffset(m.cursorLine - m.viewport.Height + 1)
+	}
+}
+
+// markReadingLine highlights the reading cursor's line in the viewport's view.
+func (m Model) markReadingLine(view string) string {
+	lines := strings.Split(view, "\n")
+	i := m.cursorRow() - m.viewport.YOffset
+	if i < 0 || i >= len(lines) {
+		return view
+	}
+	lines[i] = utils.HighlightLine(lines[i], m.viewport.Width, m.highlightStyles)
+	return strings.Join(lines, "\n")
+}
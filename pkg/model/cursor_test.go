@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// openLines opens an article of 200 numbered lines in a window 20 lines high.
+func openLines(t *testing.T, opts ...Option) Model {
+	t.Helper()
+	var lines []string
+	for i := range 200 {
+		lines = append(lines, fmt.Sprintf("Line %d.", i+1))
+	}
+	article := wiki.ArticleMsg{Article: wiki.Article{Title: "Long", Content: strings.Join(lines, "\n"), Mode: wiki.ContentReadable}}
+	var model tea.Model = newTestModel(opts...)
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if m.viewport.Height != 20 {
+		t.Fatalf("viewport is %d lines high, want 20", m.viewport.Height)
+	}
+	return m
+}
+
+func TestReadingCursor(t *testing.T) {
+	m := openLines(t, WithReadingCursor())
+	steps := []struct {
+		name         string
+		keys         string
+		line, offset int
+	}{
+		{"down within the screen", strings.Repeat("J", 19), 19, 0},
+		{"down past the bottom", "J", 20, 1},
+		{"up within the screen", strings.Repeat("K", 19), 1, 1},
+		{"up past the top", "K", 0, 0},
+		{"up at the start", "K", 0, 0},
+		// Scrolling leaves the cursor, which is then shown on the top line and moves from there.
+		{"scroll past the cursor", "jjjjj", 5, 5},
+		{"down after scrolling", "J", 6, 5},
+	}
+	for _, step := range steps {
+		for _, msg := range keys(step.keys) {
+			model, _ := m.Update(msg)
+			m = model.(Model)
+		}
+		if m.cursorRow() != step.line || m.viewport.YOffset != step.offset {
+			t.Errorf("%s: cursor on line %d at offset %d, want line %d at offset %d", step.name, m.cursorRow(), m.viewport.YOffset, step.line, step.offset)
+		}
+	}
+
+	// The cursor stops at the last line.
+	total := m.viewport.TotalLineCount()
+	for _, msg := range keys(strings.Repeat("J", total)) {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+	if m.cursorRow() != total-1 || m.viewport.YOffset != total-m.viewport.Height {
+		t.Errorf("cursor on line %d at offset %d, want the last line, %d, at the bottom", m.cursorRow(), m.viewport.YOffset, total-1)
+	}
+}
+
+func TestReadingCursorOff(t *testing.T) {
+	m := openLines(t)
+	for _, msg := range keys("JJJ") {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+	if m.cursorLine != 0 || m.viewport.YOffset != 0 {
+		t.Errorf("J moved the cursor to line %d at offset %d without the reading cursor", m.cursorLine, m.viewport.YOffset)
+	}
+}
+
+func TestReadingLineMarked(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	// The escape sequence that turns the background on.
+	background, _, _ := strings.Cut(utils.DefaultHighlightStyles().ReadingLine.Sprint("x"), "x")
+	m := openLines(t, WithReadingCursor())
+	for _, msg := range keys("JJJ") {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+	var marked []string
+	for _, line := range strings.Split(m.View(), "\n") {
+		if strings.Contains(line, background) {
+			marked = append(marked, line)
+		}
+	}
+	if len(marked) != 1 || !strings.Contains(marked[0], "Line 4.") {
+		t.Errorf("marked lines %q, want only Line 4.", marked)
+	}
+	if len(marked) == 1 && ansi.StringWidth(marked[0]) != m.viewport.Width {
+		t.Errorf("marked line %d columns wide, want the background across the window", ansi.StringWidth(marked[0]))
+	}
+
+	// Without the setting no line is marked.
+	if view := openLines(t).View(); strings.Contains(view, background) {
+		t.Error("a line is marked without the reading cursor")
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+)
+
+// debugPanelWidth is the width of the debug panel, including its border.
+const debugPanelWidth = 32
+
+// WithDebug makes ctrl+g toggle a panel over the article that shows the computed search and
+// link spans and the viewport offsets, for diagnosing highlighting and jumps. It starts shown.
+func WithDebug() Option {
+	return func(m *Model) {
+		m.debugAllowed = true
+		m.debug = true
+	}
+}
+
+// debugLines describes the highlighting state, at most height lines of it.
+func (m Model) debugLines(height int) []string {
+	lineOf := func(offset int) int {
+		return strings.Count(m.rendered[:min(offset, len(m.rendered))], "\n")
+	}
+	lines := []string{
+		"DEBUG (ctrl+g to hide)",
+		fmt.Sprintf("offset %d/%d lines", m.viewport.YOffset, m.viewport.TotalLineCount()),
+		fmt.Sprintf("view %dx%d, %d bytes", m.viewport.Width, m.viewport.Height, len(m.rendered)),
+		fmt.Sprintf("match %d/%d (%s)", m.currentMatchIndex, len(m.matchSpans), m.matchMode),
+		fmt.Sprintf("search spans: %d", len(m.matchSpans)),
+	}
+	// The spans from the current match on are the interesting ones when jumping.
+	for i := m.currentMatchIndex; i < len(m.matchSpans) && len(lines) < height/2; i++ {
+		marker := " "
+		if i == m.currentMatchIndex {
+			marker = ">"
+		}
+		span := m.matchSpans[i]
+		lines = append(lines, fmt.Sprintf("%s%d [%d,%d) L%d", marker, i, span[0], span[1], lineOf(span[0])))
+	}
+	lines = append(lines, fmt.Sprintf("url spans: %d", len(m.urlMatches)))
+	for i, span := range m.urlMatches {
+		if len(lines) >= height {
+			break
+		}
+		lines = append(lines, fmt.Sprintf(" %d [%d,%d) L%d", i, span[0], span[1], lineOf(span[0])))
+	}
+	return lines[:min(len(lines), height)]
+}
+
+// overlayDebug draws the debug panel over the right side of the viewport.
+func (m Model) overlayDebug(view string) string {
+	lines := strings.Split(view, "\n")
+	left := max(0, m.viewport.Width-debugPanelWidth)
+	border := color.New(color.Faint).Sprint("│")
+	for i, text := range m.debugLines(len(lines)) {
+		line := ansi.Truncate(lines[i], left, "")
+		line += strings.Repeat(" ", max(0, left-ansi.StringWidth(line)))
+		lines[i] = line + border + color.New(color.FgMagenta).Sprint(ansi.Truncate(text, debugPanelWidth-1, "…"))
+	}
+	return strings.Join(lines, "\n")
+}
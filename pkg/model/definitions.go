@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// startDefine prompts for a word to look up, suggesting the current search match.
+func (m *Model) startDefine() tea.Cmd {
+	word := m.searchQuery
+	if m.matchesValid() {
+		span := m.matchSpans[m.currentMatchIndex]
+		word = utils.SingleLine(m.rendered[span[0]:span[1]])
+	}
+	m.state = defineInputView
+	m.textInput.Prompt = "Define: "
+	m.textInput.SetValue(word)
+	m.textInput.CursorEnd()
+	return m.textInput.Focus()
+}
+
+// define looks up the entered word on Wiktionary, returning to the article meanwhile.
+func (m *Model) define() tea.Cmd {
+	word := strings.TrimSpace(m.textInput.Value())
+	m.textInput.Blur()
+	m.state = articleView
+	if word == "" {
+		return nil
+	}
+	m.articleNotice = fmt.Sprintf("Looking up %q...", word)
+	return m.startRequest(m.articleNotice, wiki.SearchTimeout, wiki.Define(word))
+}
+
+// showDefinition opens the definition overlay, or explains why there is nothing to show.
+func (m *Model) showDefinition(msg wiki.DefinitionMsg) {
+	switch {
+	case msg.Err != nil:
+		m.articleNotice = fmt.Sprintf("Definition lookup failed: %v. Press 'r' to retry.", msg.Err)
+	case len(msg.Definitions) == 0:
+		m.articleNotice = fmt.Sprintf("No English Wiktionary entry for %q.", msg.Word)
+	default:
+		m.definition = &msg
+	}
+}
+
+// overlayDefinition draws the definition overlay over the bottom lines of the viewport.
+func (m Model) overlayDefinition(view string) string {
+	lines := strings.Split(view, "\n")
+	width := m.viewport.Width
+	header := "─ " + m.definition.Word + " "
+	box := []string{color.New(color.Bold, color.FgCyan).Sprint(header + strings.Repeat("─", max(0, width-len([]rune(header)))))}
+	for _, definition := range m.definition.Definitions {
+		text := fmt.Sprintf("• %s: %s", strings.ToLower(definition.PartOfSpeech), definition.Text)
+		for _, line := range strings.Split(strings.TrimRight(utils.WrapText(text, width-2), "\n"), "\n") {
+			box = append(box, "  "+line)
+		}
+	}
+	box = append(box, color.New(color.Faint).Sprint("  Press any key to close."))
+	if len(box) > len(lines) {
+		box = append(box[:len(lines)-1], box[len(box)-1])
+	}
+	copy(lines[len(lines)-len(box):], box)
+	return strings.Join(lines, "\n")
+}
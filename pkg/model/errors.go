@@ -0,0 +1,128 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// requestFailure describes a failed request shown in the error view.
+type requestFailure struct {
+	err error
+	// back is the state esc returns to.
+	back state
+	// pageURL is opened with 'o', so the page can still be read in a browser.
+	pageURL string
+	// search is set when a search failed, so going back refocuses the input.
+	search bool
+	// refreshing is restored on retry, so a failed refresh keeps the scroll position.
+	refreshing bool
+	// status, timeout and request reissue the request on retry.
+	status  string
+	timeout time.Duration
+	request wiki.Request
+}
+
+// fail switches to the error view for err, remembering the last request so it can be retried.
+func (m *Model) fail(err error, pageURL string, search bool, refreshing bool) {
+	back := m.state
+	if m.state == errorView {
+		back = m.failure.back
+	}
+	if refreshing {
+		back = articleView
+	}
+	m.failure = &requestFailure{
+		err:        err,
+		back:       back,
+		pageURL:    pageURL,
+		search:     search,
+		refreshing: refreshing,
+		status:     m.lastRequestStatus,
+		timeout:    m.requestTimeout,
+		request:    m.lastRequest,
+	}
+	m.statusMsg = fmt.Sprintf("Error: %v", err)
+	m.state = errorView
+}
+
+// responseErr returns the error a response to a request carries, if any.
+func responseErr(msg tea.Msg) error {
+	switch msg := msg.(type) {
+	case wiki.SearchMsg:
+		return msg.Err
+	case wiki.ArticleMsg:
+		return msg.Err
+	case wiki.DefinitionMsg:
+		return msg.Err
+	case splitArticleMsg:
+		return msg.article.Err
+	}
+	return nil
+}
+
+// leaveErrorView returns to the screen the failed request was made from.
+func (m *Model) leaveErrorView() {
+	m.state = m.failure.back
+	if m.failure.search {
+		m.textInput.Focus()
+	}
+	m.failure = nil
+}
+
+// retry leaves the error view and reissues the failed request.
+func (m *Model) retry() tea.Cmd {
+	failure := m.failure
+	m.leaveErrorView()
+	m.textInput.Blur()
+	m.refreshing = failure.refreshing
+	return m.startRequest(failure.status, failure.timeout, failure.request)
+}
+
+// errorGuidance suggests what to do about err.
+func errorGuidance(err error) string {
+	var apiErr *wiki.APIError
+	var rateErr *wiki.RateLimitError
+	var netErr net.Error
+	var tlsErr *wiki.TLSError
+	var protocolErr *wiki.TLSProtocolError
+	switch {
+	case errors.As(err, &tlsErr):
+		return "Something between you and the wiki, such as a company proxy, presented a certificate that can't be trusted. Check your network or proxy settings; -insecure-tls skips the check, at the cost of your connection's security."
+	case errors.As(err, &protocolErr):
+		return "Something answered at the wiki's address without HTTPS, such as a captive portal or a misconfigured proxy. Check that you are online and the wiki's address is right."
+	case errors.As(err, &rateErr):
+		return "The wiki is receiving too many requests from you. Wait a moment, then retry."
+	case errors.As(err, &apiErr):
+		return "The wiki rejected the request. Check the search terms or the article title."
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "The wiki took too long to answer. It may be busy, or your connection may be slow."
+	case errors.As(err, &netErr):
+		return "The wiki could not be reached. Check your network connection, or use -offline to read saved snapshots."
+	}
+	return "Retrying may help. Otherwise, try reading the page in your browser."
+}
+
+// errorViewString renders the error view.
+func (m Model) errorViewString() string {
+	s := strings.Builder{}
+	s.WriteString(color.New(color.Bold, color.FgRed).Sprint("Something went wrong"))
+	s.WriteString("\n\n")
+	s.WriteString(color.New(color.FgWhite).Sprint(utils.WrapText(m.failure.err.Error(), m.width)))
+	s.WriteString("\n")
+	s.WriteString(color.New(color.FgYellow).Sprint(utils.WrapText(errorGuidance(m.failure.err), m.width)))
+	footer := "\n'r' to retry, Esc to go back, 'q' to quit."
+	if m.failure.pageURL != "" {
+		footer = "\n'r' to retry, 'o' to open in browser, Esc to go back, 'q' to quit."
+	}
+	s.WriteString(color.New(color.FgWhite).Sprint(footer))
+	return s.String()
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	"wiki-search/pkg/wiki"
+)
+
+// DefaultExportPath is where the 'E' key writes the session when no path is configured.
+const DefaultExportPath = "wiki-search-session.md"
+
+// WithExportPath sets the file the 'E' key exports the session to.
+func WithExportPath(path string) Option {
+	return func(m *Model) {
+		m.exportPath = path
+	}
+}
+
+// rememberVisit adds the open article to the articles read this session, unless it was read
+// before.
+func (m *Model) rememberVisit() {
+	visit := wiki.VisitedArticle{Title: m.selectedTitle, WikiType: m.searchType}
+	if !slices.Contains(m.visited, visit) {
+		m.visited = append(m.visited, visit)
+	}
+}
+
+// ExportSession writes the articles read this session to a Markdown file at path, in the
+// order they were first opened.
+func (m Model) ExportSession(path string) error {
+	return wiki.ExportSession(m.visited, path)
+}
+
+// VisitedArticles returns how many articles were read this session.
+func (m Model) VisitedArticles() int {
+	return len(m.visited)
+}
+
+// exportSession handles the 'E' key.
+func (m *Model) exportSession() {
+	path := m.exportPath
+	if path == "" {
+		path = DefaultExportPath
+	}
+	if err := m.ExportSession(path); err != nil {
+		m.articleNotice = fmt.Sprintf("Could not export the session: %v", err)
+		return
+	}
+	m.articleNotice = fmt.Sprintf("Exported %d article(s) to %s.", len(m.visited), path)
+}
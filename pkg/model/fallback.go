@@ -0,0 +1,63 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+// fallbackSearchMsg carries the results of searching the fallback edition of a wiki.
+type fallbackSearchMsg struct {
+	source string
+	search wiki.SearchMsg
+}
+
+// searchFallback searches the fallback edition of the current wiki for the query that found
+// nothing on it. The category is left out, as categories have other names in other languages.
+// It returns nil if the wiki has no fallback.
+func (m *Model) searchFallback() tea.Cmd {
+	fallback := wiki.LookupSource(m.searchType).Fallback
+	if fallback == "" {
+		return nil
+	}
+	opts := m.searchOptions()
+	opts.Category = ""
+	search := wiki.PerformSearch(m.textInput.Value(), fallback, opts)
+	return m.startRequest(m.verbose("Searching "+fallback+"...", fmt.Sprintf("No results on %s; searching %s instead...", m.searchType, fallback)),
+		wiki.SearchTimeout, func(ctx context.Context) tea.Msg {
+			return fallbackSearchMsg{source: fallback, search: search(ctx).(wiki.SearchMsg)}
+		})
+}
+
+// showFallbackResults shows what the fallback search found. The fallback wiki becomes the
+// current one while its results are shown, so they open there; a new query goes back to the
+// original wiki. When the fallback found nothing either, or failed, the original wiki's empty
+// results stay.
+func (m Model) showFallbackResults(msg fallbackSearchMsg) (tea.Model, tea.Cmd) {
+	from := m.searchType
+	if msg.search.Err != nil || len(m.resultFilter(msg.search.Results)) == 0 {
+		m.loading = false
+		m.statusMsg = fmt.Sprintf("No results on %s or on %s.", from, msg.source)
+		if msg.search.Err != nil {
+			m.statusMsg = fmt.Sprintf("No results on %s, and searching %s failed: %v", from, msg.source, msg.search.Err)
+		}
+		return m, nil
+	}
+	m.searchType = msg.source
+	m.fallbackFrom = from
+	updated, cmd := m.Update(msg.search)
+	next := updated.(Model)
+	next.statusMsg = fmt.Sprintf("Nothing on %s. ", from) + next.statusMsg
+	return next, cmd
+}
+
+// leaveFallback makes the wiki whose search fell back the current one again.
+func (m *Model) leaveFallback() {
+	if m.fallbackFrom != "" {
+		m.searchType = m.fallbackFrom
+		m.fallbackFrom = ""
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestEmptySearchFallsBack(t *testing.T) {
+	sources := slices.Clone(wiki.Sources)
+	t.Cleanup(func() { wiki.Sources = sources })
+	wiki.SetFallbackLanguage("de")
+
+	empty := func(t *testing.T) Model {
+		t.Helper()
+		m := search(t, newTestModel(), "golang")
+		updated, cmd := m.Update(wiki.SearchMsg{})
+		m = updated.(Model)
+		if cmd == nil || !m.loading || !strings.Contains(m.statusMsg, "wikipedia-de") {
+			t.Fatalf("no fallback search after finding nothing: loading %v, status %q", m.loading, m.statusMsg)
+		}
+		return m
+	}
+
+	t.Run("found", func(t *testing.T) {
+		updated, _ := empty(t).Update(fallbackSearchMsg{source: "wikipedia-de", search: testResults})
+		m := updated.(Model)
+		if m.searchType != "wikipedia-de" || m.fallbackFrom != "wikipedia" || len(m.results) != len(testResults.Results) {
+			t.Errorf("after the fallback found results: wiki %q, from %q, %d results", m.searchType, m.fallbackFrom, len(m.results))
+		}
+		if !strings.HasPrefix(m.statusMsg, "Nothing on wikipedia.") {
+			t.Errorf("status = %q", m.statusMsg)
+		}
+		if view := m.View(); !strings.Contains(view, "fallback, nothing on wikipedia") {
+			t.Errorf("results not headed with the fallback:\n%s", view)
+		}
+		m.leaveFallback()
+		if m.searchType != "wikipedia" {
+			t.Errorf("wiki after leaving the fallback = %q", m.searchType)
+		}
+	})
+
+	t.Run("nothing either", func(t *testing.T) {
+		updated, _ := empty(t).Update(fallbackSearchMsg{source: "wikipedia-de", search: wiki.SearchMsg{}})
+		m := updated.(Model)
+		if m.loading || m.searchType != "wikipedia" || m.statusMsg != "No results on wikipedia or on wikipedia-de." {
+			t.Errorf("after the fallback found nothing: loading %v, wiki %q, status %q", m.loading, m.searchType, m.statusMsg)
+		}
+		if view := m.View(); !strings.Contains(view, `No articles on wikipedia match "golang".`) {
+			t.Errorf("no-results view not shown:\n%s", view)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+)
+
+// matchMode selects how the in-article search query is matched.
+type matchMode int
+
+const (
+	// matchExact finds every occurrence of the query, including inside longer words.
+	matchExact matchMode = iota
+	// matchWholeWord finds the query only where it forms whole words.
+	matchWholeWord
+	// matchFuzzy also finds near misses, closest first.
+	matchFuzzy
+	matchModes
+)
+
+func (mode matchMode) String() string {
+	switch mode {
+	case matchWholeWord:
+		return "whole words"
+	case matchFuzzy:
+		return "fuzzy"
+	}
+	return "exact"
+}
+
+// articleSearchPrompt returns the prompt for in-article search, marking whole-word mode with
+// a "w" and fuzzy mode with a tilde.
+func (m Model) articleSearchPrompt() string {
+	switch m.matchMode {
+	case matchWholeWord:
+		return "w/"
+	case matchFuzzy:
+		return "~/"
+	}
+	return "/"
+}
+
+// findMatches locates the in-article search query in the rendered text. With normalized
+// typography a pasted curly quote or dash is normalized too, as the text it is looked for in is.
+func (m *Model) findMatches() {
+	query := m.searchQuery
+	if m.normalizeTypography {
+		query = utils.NormalizeTypography(query)
+	}
+	switch m.matchMode {
+	case matchWholeWord:
+		m.matchSpans = utils.FindWordSpans(m.rendered, query)
+	case matchFuzzy:
+		m.matchSpans = utils.FindApproxMatches(m.rendered, query)
+	default:
+		m.matchSpans = utils.FindMatchSpans(m.rendered, query)
+	}
+}
+
+// clearMatches forgets the in-article search matches. They index into the rendered text, so
+// they have to go whenever another text replaces it.
+func (m *Model) clearMatches() {
+	m.matchSpans = nil
+	m.currentMatchIndex = 0
+}
+
+// matchesValid reports whether the current match and every match span lie within the rendered
+// text, which they might not if it changed after the search.
+func (m Model) matchesValid() bool {
+	if m.currentMatchIndex < 0 || m.currentMatchIndex >= len(m.matchSpans) {
+		return false
+	}
+	for _, span := range m.matchSpans {
+		if span[0] < 0 || span[0] > span[1] || span[1] > len(m.rendered) {
+			return false
+		}
+	}
+	return true
+}
+
+// showMatch scrolls the current match to the middle of the viewport. A match that wraps
+// onto the next line is placed by the line it starts on.
+func (m *Model) showMatch() {
+	if !m.matchesValid() {
+		return
+	}
+	line := utils.CalculateLineFromIndex(m.rendered, m.matchSpans[m.currentMatchIndex][0])
+	m.viewport.SetYOffset(max(0, line-m.viewport.Height/2))
+}
+
+// WithMatchPerLine makes n/p skip the other matches on the current match's line, so every
+// jump moves to another line.
+func WithMatchPerLine() Option {
+	return func(m *Model) {
+		m.matchPerLine = true
+	}
+}
+
+// sameLine reports whether matches i and j start on the same line of the rendered text.
+func (m Model) sameLine(i, j int) bool {
+	a, b := m.matchSpans[i][0], m.matchSpans[j][0]
+	return !strings.Contains(m.rendered[min(a, b):max(a, b)], "\n")
+}
+
+// matchesOnLine counts the matches that start on the current match's line.
+func (m Model) matchesOnLine() int {
+	n := 0
+	for i := range m.matchSpans {
+		if m.sameLine(i, m.currentMatchIndex) {
+			n++
+		}
+	}
+	return n
+}
+
+// nextMatch moves delta matches forward or backward, wrapping around, and shows the match.
+// With per-line jumping it skips matches on the current line and lands on the first match of
+// the line it moves to.
+func (m *Model) nextMatch(delta int) {
+	if !m.matchesValid() {
+		m.clearMatches()
+		m.articleNotice = "The article changed since the search; search again with '/'."
+		return
+	}
+	n := len(m.matchSpans)
+	next := (m.currentMatchIndex + delta + n) % n
+	if m.matchPerLine {
+		for i := 0; i < n-1 && m.sameLine(next, m.currentMatchIndex); i++ {
+			next = (next + delta + n) % n
+		}
+		for next > 0 && m.sameLine(next-1, next) {
+			next--
+		}
+	}
+	m.currentMatchIndex = next
+	m.showMatch()
+}
+
+// markCurrentMatch puts a marker in the last column of the line holding the current match, so
+// it can be told apart even among matches that are all on screen.
+func (m Model) markCurrentMatch(view string) string {
+	if !m.matchesValid() {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	i := utils.CalculateLineFromIndex(m.rendered, m.matchSpans[m.currentMatchIndex][0]) - m.viewport.YOffset
+	if i < 0 || i >= len(lines) || m.viewport.Width < 2 {
+		return view
+	}
+	line := ansi.Truncate(lines[i], m.viewport.Width-1, "")
+	line += strings.Repeat(" ", max(0, m.viewport.Width-1-ansi.StringWidth(line)))
+	lines[i] = line + color.New(color.Bold, color.FgYellow).Sprint("◀")
+	return strings.Join(lines, "\n")
+}
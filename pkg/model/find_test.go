@@ -0,0 +1,173 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// find searches the open article for query.
+func find(t *testing.T, m Model, query string) Model {
+	t.Helper()
+	var model tea.Model = m
+	for _, msg := range keys("/", query, "enter") {
+		model, _ = model.Update(msg)
+	}
+	m = model.(Model)
+	if len(m.matchSpans) == 0 {
+		t.Fatalf("no matches for %q", query)
+	}
+	return m
+}
+
+func TestNextMatchAfterTextChanged(t *testing.T) {
+	m := find(t, open(t), "google")
+	// The text shrinks under the matches without them being cleared.
+	m.rendered = m.rendered[:10]
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updated.(Model)
+	if len(m.matchSpans) != 0 {
+		t.Errorf("stale matches kept: %v", m.matchSpans)
+	}
+	if !strings.Contains(m.articleNotice, "search again") {
+		t.Errorf("notice = %q, want it to ask for a new search", m.articleNotice)
+	}
+	m.View()
+}
+
+func TestNextMatchAfterArticleReplaced(t *testing.T) {
+	m := find(t, open(t), "google")
+	m.refreshing = true
+	short := wiki.ArticleMsg{Article: wiki.Article{Title: testArticle.Title, Content: "Go is a language.", Mode: wiki.ContentReadable}}
+	var model tea.Model = m
+	for _, msg := range then([]tea.Msg{short}, keys("n", "p")) {
+		model, _ = model.Update(msg)
+	}
+	m = model.(Model)
+	if len(m.matchSpans) != 0 {
+		t.Errorf("matches of the old text kept: %v", m.matchSpans)
+	}
+	if view := m.View(); strings.Contains(view, "1/") {
+		t.Errorf("view still counts the old matches:\n%s", view)
+	}
+}
+
+func TestFindInSingleLineArticle(t *testing.T) {
+	var sentences []string
+	for i := range 220 {
+		sentences = append(sentences, fmt.Sprintf("Sentence %d says something about the subject.", i+1))
+	}
+	sentences[200] = "The needle is in this sentence."
+	content := strings.Join(sentences, " ")
+	article := wiki.ArticleMsg{Article: wiki.Article{Title: "Flat", Content: utils.BreakLongLines(content), Mode: wiki.ContentReadable}}
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	m := find(t, model.(Model), "needle")
+	if m.viewport.YOffset == 0 {
+		t.Error("didn't jump to the match near the end")
+	}
+	if view := m.View(); !strings.Contains(view, "needle") {
+		t.Errorf("match not on screen:\n%s", view)
+	}
+}
+
+// openSameLine opens an article with several matches for "go" on some of its lines.
+func openSameLine(opts ...Option) Model {
+	article := wiki.ArticleMsg{Article: wiki.Article{Title: "Go", Content: "Alpha go go go.\n\nBeta go.\n\nGamma go go.", Mode: wiki.ContentReadable}}
+	var model tea.Model = newTestModel(opts...)
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestSameLineMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		steps string
+		want  []int
+	}{
+		{"n visits each match", nil, "nnnnnn", []int{1, 2, 3, 4, 5, 0}},
+		{"p visits each match", nil, "pp", []int{5, 4}},
+		{"n skips to the next line", []Option{WithMatchPerLine()}, "nnn", []int{3, 4, 0}},
+		{"p goes to the first match of the line before", []Option{WithMatchPerLine()}, "ppp", []int{4, 3, 0}},
+	}
+	for _, tt := range tests {
+		var model tea.Model = find(t, openSameLine(tt.opts...), "go")
+		var got []int
+		for _, key := range keys(tt.steps) {
+			model, _ = model.Update(key)
+			got = append(got, model.(Model).currentMatchIndex)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: current matches %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCurrentMatchMarked(t *testing.T) {
+	m := find(t, openSameLine(), "go")
+	view := m.View()
+	if !strings.Contains(view, "Match 1/6 (3 on this line)") {
+		t.Errorf("footer doesn't count the matches on the current line:\n%s", view)
+	}
+	// marked returns the line carrying the current match marker.
+	marked := func(view string) string {
+		var lines []string
+		for _, line := range strings.Split(view, "\n") {
+			if strings.Contains(line, "◀") {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) != 1 {
+			t.Fatalf("%d lines marked, want 1:\n%s", len(lines), view)
+		}
+		return lines[0]
+	}
+	if line := marked(view); !strings.Contains(line, "Alpha") {
+		t.Errorf("marked %q, want the line of the first match", line)
+	}
+
+	var model tea.Model = m
+	for _, msg := range keys("nnn") {
+		model, _ = model.Update(msg)
+	}
+	view = model.View()
+	if line := marked(view); !strings.Contains(line, "Beta") {
+		t.Errorf("marked %q after moving to the fourth match, want its line", line)
+	}
+	if !strings.Contains(view, "Match 4/6 |") {
+		t.Errorf("footer counts matches on a line with only one:\n%s", view)
+	}
+}
+
+func TestFocusModeToggle(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+	dimmed := utils.DefaultHighlightStyles().Dim.Sprint("Gamma ")
+
+	m := find(t, openSameLine(), "go")
+	if strings.Contains(m.renderArticle(), dimmed) {
+		t.Fatal("text dimmed before focus mode is on")
+	}
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	m = model.(Model)
+	if !m.focusMode || !strings.Contains(m.renderArticle(), dimmed) {
+		t.Errorf("'f' didn't dim the lines without the current match: %q", m.renderArticle())
+	}
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if m = model.(Model); m.focusMode || strings.Contains(m.renderArticle(), dimmed) {
+		t.Error("a second 'f' didn't turn focus mode off")
+	}
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestSyncFocus(t *testing.T) {
+	tests := []struct {
+		name          string
+		state         state
+		results       int
+		loading       bool
+		search        bool
+		category      bool
+		searchFocused bool
+	}{
+		{name: "no results", state: searchResultsView, search: true},
+		{name: "searching", state: searchResultsView, loading: true},
+		{name: "results", state: searchResultsView, results: 2},
+		// Navigating results leaves typing to the user, who focuses the input with '/' or Esc.
+		{name: "results while typing", state: searchResultsView, results: 2, searchFocused: true, search: true},
+		{name: "article", state: articleView, searchFocused: true},
+		{name: "search in article", state: searchArticleView, search: true},
+		{name: "define", state: defineInputView, search: true},
+		{name: "compare", state: compareInputView, search: true},
+		{name: "category input", state: categoryInputView, searchFocused: true, category: true},
+		{name: "category list", state: categoryListView, searchFocused: true},
+		{name: "headings", state: headingView, searchFocused: true},
+		{name: "refine", state: refineView, searchFocused: true},
+		{name: "wiki menu", state: wikiSelectionView, searchFocused: true},
+		{name: "snapshots", state: snapshotListView, searchFocused: true},
+		{name: "error", state: errorView, searchFocused: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel()
+			m.state = tt.state
+			m.loading = tt.loading
+			m.results = make([]wiki.SearchResult, tt.results)
+			if tt.searchFocused {
+				m.textInput.Focus()
+			} else {
+				m.textInput.Blur()
+			}
+			m.categoryInput.Focus()
+			m.syncFocus()
+			if m.textInput.Focused() != tt.search || m.categoryInput.Focused() != tt.category {
+				t.Errorf("search input focused %v, category input %v; want %v, %v", m.textInput.Focused(), m.categoryInput.Focused(), tt.search, tt.category)
+			}
+		})
+	}
+}
+
+func TestFocusAfterSearch(t *testing.T) {
+	var model tea.Model = search(t, newTestModel(), "golang")
+	model, _ = model.Update(wiki.SearchMsg{Err: errors.New("connection refused")})
+	if m := model.(Model); m.state != errorView || m.textInput.Focused() {
+		t.Errorf("after a failed search: state %v, search input focused %v; want the error shown", m.state, m.textInput.Focused())
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m := model.(Model); m.state != searchResultsView || !m.textInput.Focused() {
+		t.Errorf("leaving the error: state %v, search input focused %v; want the search input focused", m.state, m.textInput.Focused())
+	}
+
+	model = search(t, newTestModel(), "golang")
+	model, _ = model.Update(wiki.SearchMsg{})
+	if m := model.(Model); !m.textInput.Focused() {
+		t.Error("search input not focused after a search that found nothing")
+	}
+
+	model = newTestModel()
+	for _, msg := range toArticle {
+		model, _ = model.Update(msg)
+	}
+	if m := model.(Model); m.textInput.Focused() || m.categoryInput.Focused() {
+		t.Error("an input is focused while reading an article")
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"wiki-search/pkg/settings"
+)
+
+// FooterData holds the values available to footer templates.
+type FooterData struct {
+	// Title is the article title and Wiki the name of its wiki, e.g. "wikipedia".
+	Title string
+	Wiki  string
+	// ScrollPercent is how far the article has been scrolled, from 0 to 100.
+	ScrollPercent int
+	// MatchPos is the position of the current search match, e.g. "3/12", or empty.
+	MatchPos string
+	// MatchesOnLine is the number of matches on the current match's line, 0 without a search.
+	MatchesOnLine int
+	// Section describes the section shown when paging by section, e.g. "Section 3/12: History".
+	Section string
+	// Keys lists the available key bindings.
+	Keys string
+}
+
+var defaultFooter = template.Must(template.New("footer").Parse(settings.DefaultFooter))
+
+// ParseFooter parses a footer template and checks that it renders, so mistakes such as
+// unknown variables are reported at startup rather than in the middle of reading.
+func ParseFooter(text string) (*template.Template, error) {
+	tmpl, err := template.New("footer").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid footer template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, FooterData{}); err != nil {
+		return nil, fmt.Errorf("invalid footer template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// WithFooter sets the template of the article footer, as returned by ParseFooter.
+func WithFooter(tmpl *template.Template) Option {
+	return func(m *Model) {
+		if tmpl != nil {
+			m.footer = tmpl
+		}
+	}
+}
+
+// footerData collects the values for the article footer.
+func (m Model) footerData() FooterData {
+	keys := "Press 'esc' to go back, Up/Down to scroll, '/' to search, ':' for a new wiki search, 'n/p' to jump between matches, 'D' to define a word, 'F' to follow a link, Tab to select a link, 's' to save offline, 'R' to refresh, 'y' to copy permalink, 'z' for zen mode, 'q' to quit."
+	if len(m.categories) > 0 {
+		keys = fmt.Sprintf("'c' for %d categories, ", len(m.categories)) + keys
+	}
+	if m.keepCitations {
+		keys = "'C' to follow a citation, " + keys
+	}
+	if len(m.sectionPages) > 1 {
+		keys = "'[/]' to change section, 'A' for one section or all, " + keys
+	}
+	data := FooterData{
+		Title:         m.articleTitle(),
+		Wiki:          m.searchType,
+		ScrollPercent: int(m.viewport.ScrollPercent() * 100),
+		Section:       m.sectionStatus(),
+		Keys:          keys,
+	}
+	if m.matchesValid() {
+		data.MatchPos = fmt.Sprintf("%d/%d", m.currentMatchIndex+1, len(m.matchSpans))
+		data.MatchesOnLine = m.matchesOnLine()
+	}
+	return data
+}
+
+// footerView renders the article footer, falling back to the default template if the
+// configured one fails.
+func (m Model) footerView() string {
+	data := m.footerData()
+	var footer strings.Builder
+	if err := m.footer.Execute(&footer, data); err != nil {
+		footer.Reset()
+		defaultFooter.Execute(&footer, data)
+	}
+	return footer.String()
+}
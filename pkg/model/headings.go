@@ -0,0 +1,117 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// startHeadingPicker opens the heading picker, which filters the article's section headings
+// as the user types and jumps to the chosen one.
+func (m *Model) startHeadingPicker() tea.Cmd {
+	if len(m.sections) == 0 {
+		m.articleNotice = "This article has no sections."
+		return nil
+	}
+	m.headingInput = textinput.New()
+	m.headingInput.Prompt = "§ "
+	m.headingInput.Placeholder = "type part of a heading"
+	m.headingInput.CharLimit = 100
+	m.headingInput.Width = m.textInput.Width
+	m.headingCursor = 0
+	m.filterHeadings()
+	m.state = headingView
+	return m.headingInput.Focus()
+}
+
+// headingTitles returns the titles of the article's sections, numbered if headings are.
+func (m Model) headingTitles() []string {
+	numbered := wiki.NumberSections(m.sections)
+	titles := make([]string, len(m.sections))
+	for i, section := range numbered {
+		titles[i] = m.headingText(section.Title())
+		if m.numberedHeadings && section.Number != "" {
+			titles[i] = section.Number + " " + titles[i]
+		}
+	}
+	return titles
+}
+
+// filterHeadings lists the sections matching the typed filter, best match first.
+func (m *Model) filterHeadings() {
+	m.headingMatches = utils.FuzzyFilter(m.headingTitles(), m.headingText(m.headingInput.Value()))
+	m.headingCursor = max(0, min(m.headingCursor, len(m.headingMatches)-1))
+}
+
+// updateHeadingPicker handles a key in the heading picker.
+func (m *Model) updateHeadingPicker(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.state = articleView
+		return nil
+	case "up", "ctrl+p", "shift+tab":
+		if m.headingCursor > 0 {
+			m.headingCursor--
+		}
+		return nil
+	case "down", "ctrl+n", "tab":
+		if m.headingCursor < len(m.headingMatches)-1 {
+			m.headingCursor++
+		}
+		return nil
+	case "enter":
+		if len(m.headingMatches) == 0 {
+			return nil
+		}
+		i := m.headingMatches[m.headingCursor]
+		m.state = articleView
+		m.clearMatches()
+		if !m.gotoHeading(i) {
+			m.articleNotice = fmt.Sprintf("The heading %q could not be found in the text.", m.headingText(m.sections[i].Title()))
+		}
+		return nil
+	}
+	if msg.Paste {
+		msg.Runes = []rune(utils.SingleLine(string(msg.Runes)))
+	}
+	var cmd tea.Cmd
+	m.headingInput, cmd = m.headingInput.Update(msg)
+	m.filterHeadings()
+	return cmd
+}
+
+// headingPickerView renders the heading picker: the filter and the headings matching it.
+func (m Model) headingPickerView() string {
+	s := strings.Builder{}
+	mainColor := color.New(color.FgWhite).SprintFunc()
+	s.WriteString(color.New(color.Bold, color.FgCyan).Sprint(m.fitWidth(m.articleTitle(), 0)))
+	s.WriteString("\n\n")
+	s.WriteString(m.headingInput.View())
+	s.WriteString("\n\n")
+	if len(m.headingMatches) == 0 {
+		s.WriteString(mainColor("No heading matches."))
+	}
+	// Keep the cursor on screen when there are more matches than lines.
+	visible := max(1, m.height-8)
+	first := max(0, m.headingCursor-visible+1)
+	titles := m.headingTitles()
+	for n, i := range m.headingMatches[first:min(len(m.headingMatches), first+visible)] {
+		cursor := "  "
+		if first+n == m.headingCursor {
+			cursor = color.New(color.Bold, color.FgGreen).Sprint("> ")
+		}
+		// Subsections are indented under their sections.
+		indent := strings.Repeat("  ", max(0, m.sections[i].Depth()-2))
+		s.WriteString(fmt.Sprintf("%s%s%s\n", cursor, indent, mainColor(m.fitWidth(titles[i], 2+len(indent)))))
+	}
+	s.WriteString(mainColor(fmt.Sprintf("\n%d of %d headings. Enter to jump to the selected heading, Up/Down to select, Esc to cancel.", len(m.headingMatches), len(m.sections))))
+	return s.String()
+}
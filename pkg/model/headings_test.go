@@ -0,0 +1,37 @@
+package model
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestHeadingsWithNormalizedTypography(t *testing.T) {
+	article := wiki.ArticleMsg{Article: wiki.Article{
+		Title:    "Go",
+		Content:  "Go is a language.\n\nHistory – early years\n\nDesigned in 2007.\n\n“Go” in popular culture\n\nA mascot.",
+		Sections: []wiki.Section{{Index: "1", Level: "2", Line: "History – early years", Number: "1"}, {Index: "2", Level: "2", Line: "“Go” in popular culture", Number: "2"}},
+		Mode:     wiki.ContentReadable,
+	}}
+	var model tea.Model = newTestModel(WithNormalizedTypography())
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if got, want := m.headingTitles(), []string{"History - early years", `"Go" in popular culture`}; !slices.Equal(got, want) {
+		t.Errorf("heading titles = %q, want %q as the text shows them", got, want)
+	}
+	for _, filter := range []string{`"go"`, "“go”"} {
+		m.headingInput.SetValue(filter)
+		m.filterHeadings()
+		if len(m.headingMatches) == 0 || m.headingMatches[0] != 1 {
+			t.Errorf("filter %q matches %v, want the second heading first", filter, m.headingMatches)
+		}
+	}
+	if !m.gotoHeading(1) {
+		t.Error("couldn't jump to the normalized heading")
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+)
+
+// WithoutPersistence keeps reading positions and recent searches in memory instead of
+// reading and writing them on disk, so a Model behaves the same wherever it runs.
+func WithoutPersistence() Option {
+	return func(m *Model) {
+		m.ephemeral = true
+	}
+}
+
+// Render drives m without a terminal and returns what it would display. The model is sized
+// to width×height, then each of msgs is passed to Update in order, ignoring the commands
+// they return, so canned results and articles can be fed in as wiki.SearchMsg and
+// wiki.ArticleMsg. Colors are disabled while rendering, which makes the output stable
+// enough to compare against golden files.
+func Render(m Model, width, height int, msgs ...tea.Msg) string {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	var model tea.Model = m
+	model, _ = model.Update(tea.WindowSizeMsg{Width: width, Height: height})
+	for _, msg := range msgs {
+		model, _ = model.Update(msg)
+	}
+	return model.View()
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// newTestModel returns a model that keeps nothing on disk, as the tests build it.
+func newTestModel(opts ...Option) Model {
+	opts = append([]Option{WithoutPersistence()}, opts...)
+	return New(textinput.New(), viewport.New(80, 20), regexp.MustCompile(`https?://\S+`), opts...)
+}
+
+// keys turns s into key presses: special keys such as "enter", "esc" and the arrows are
+// pressed as such, anything else is typed one rune at a time.
+func keys(s ...string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, k := range s {
+		switch k {
+		case "enter":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+		case "esc":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEsc})
+		case "up":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyUp})
+		case "down":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyDown})
+		default:
+			for _, r := range k {
+				msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			}
+		}
+	}
+	return msgs
+}
+
+// then joins message lists, for building up a session step by step.
+func then(lists ...[]tea.Msg) []tea.Msg {
+	var msgs []tea.Msg
+	for _, list := range lists {
+		msgs = append(msgs, list...)
+	}
+	return msgs
+}
+
+// checkGolden compares got with testdata/name.golden, or rewrites the file with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run go test with -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("view differs from %s; run go test with -update if the change is intended\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+var (
+	testResults = wiki.SearchMsg{
+		Results: []wiki.SearchResult{
+			{Title: "Go (programming language)"},
+			{Title: "Go (game)"},
+			{Title: "Go, Went, Gone"},
+		},
+		TotalHits: 3,
+	}
+	testArticle = wiki.ArticleMsg{Article: wiki.Article{
+		Title:        "Go (programming language)",
+		DisplayTitle: "Go (programming language)",
+		Content: "Go is a statically typed, compiled high-level programming language designed at Google. " +
+			"It is syntactically similar to C, but also has garbage collection and structural typing.\n\n" +
+			"History\n\nGo was designed at Google in 2007 to improve programming productivity. " +
+			"The designers wanted to address criticisms of other languages in use at Google.\n\n" +
+			"More at https://go.dev/doc/ and on the language's own pages.",
+		Sections: []wiki.Section{{Index: "1", Level: "2", Line: "History", Number: "1"}},
+		Mode:     wiki.ContentReadable,
+		Links: []wiki.Link{
+			{Text: "Google", Title: "Google"},
+			{Text: "garbage collection", Title: "Garbage collection (computer science)"},
+			{Text: "C", Title: "C (programming language)"},
+		},
+	}}
+)
+
+// Steps of a session, each starting where the previous one ended.
+var (
+	toResults = then(keys("enter", "go", "enter"), []tea.Msg{testResults})
+	toArticle = then(toResults, keys("enter"), []tea.Msg{testArticle})
+	toFind    = then(toArticle, keys("/", "google", "enter"))
+)
+
+func TestViewGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		msgs []tea.Msg
+	}{
+		{"wiki_selection", nil},
+		{"results", toResults},
+		{"article", toArticle},
+		{"find_input", then(toArticle, keys("/", "google"))},
+		{"find_matches", toFind},
+		{"find_next", then(toFind, keys("n"))},
+		{"link_picker", then(toArticle, keys("F"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, Render(newTestModel(), 80, 24, tt.msgs...))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// withRecent returns a model on the search input with recent searches, newest first.
+func withRecent(recent ...string) Model {
+	m := newTestModel()
+	m.recentSearches = recent
+	var model tea.Model = m
+	for _, msg := range keys("enter") {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestBrowseHistory(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []tea.Msg
+		input string
+	}{
+		{"up recalls the newest", keys("ru", "up"), "golang"},
+		{"up again recalls older", keys("ru", "up", "up"), "pacman"},
+		{"up stops at the oldest", keys("ru", "up", "up", "up", "up", "up"), "rust"},
+		{"down goes back to newer", keys("ru", "up", "up", "down"), "golang"},
+		{"down past the newest restores the draft", keys("ru", "up", "up", "down", "down"), "ru"},
+		{"down without browsing keeps the input", keys("ru", "down"), "ru"},
+		{"skips the search already in the input", keys("golang", "up"), "pacman"},
+		{"editing a recalled search browses afresh", keys("ru", "up", "up", "s", "up", "down"), "pacmans"},
+	}
+	for _, tt := range tests {
+		var model tea.Model = withRecent("golang", "pacman", "rust")
+		for _, msg := range tt.steps {
+			model, _ = model.Update(msg)
+		}
+		if got := model.(Model).textInput.Value(); got != tt.input {
+			t.Errorf("%s: input %q, want %q", tt.name, got, tt.input)
+		}
+	}
+}
+
+func TestHistoryKeysPrecedence(t *testing.T) {
+	// An empty input lists the recent searches, and Up/Down pick from the list.
+	var model tea.Model = withRecent("golang", "pacman")
+	for _, msg := range keys("down", "down") {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if m.recentCursor != 1 || m.textInput.Value() != "" {
+		t.Errorf("empty input: recent cursor %d, input %q; want the list cursor moved and the input left empty", m.recentCursor, m.textInput.Value())
+	}
+
+	// Without recent searches there is nothing to browse, and the input is kept.
+	model = withRecent()
+	for _, msg := range keys("go", "up") {
+		model, _ = model.Update(msg)
+	}
+	if got := model.(Model).textInput.Value(); got != "go" {
+		t.Errorf("no recent searches: input %q, want %q", got, "go")
+	}
+
+	// Once the results are shown the input is left, and Up/Down move through them.
+	model = withRecent("golang", "pacman")
+	for _, msg := range then(keys("go", "enter"), []tea.Msg{testResults}, keys("down", "down", "up")) {
+		model, _ = model.Update(msg)
+	}
+	m = model.(Model)
+	if m.cursor != 1 || m.textInput.Value() != "go" {
+		t.Errorf("results shown: cursor %d, input %q; want the cursor on the second result and the query kept", m.cursor, m.textInput.Value())
+	}
+	if m.historyPos != 0 {
+		t.Errorf("results shown: browsing recent search %d, want none", m.historyPos)
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// WithIdleTimeout quits the application once no key has been pressed and the mouse hasn't
+// been used for d, e.g. on a kiosk. Zero, the default, never quits.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(m *Model) {
+		m.idleTimeout = d
+	}
+}
+
+// idleMsg asks whether the session has been idle for the idle timeout.
+type idleMsg struct{}
+
+// checkIdle schedules the next idle check after d.
+func checkIdle(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return idleMsg{}
+	})
+}
+
+// noteActivity resets the idle timer on user input, in whatever state the application is.
+func (m *Model) noteActivity(msg tea.Msg) {
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		m.lastActivity = time.Now()
+	}
+}
+
+// handleIdle quits if the session has been idle for the idle timeout. Otherwise it checks
+// again when the timeout would next run out, so only one check is ever pending.
+func (m Model) handleIdle() tea.Cmd {
+	remaining := m.idleTimeout - time.Since(m.lastActivity)
+	if remaining <= 0 {
+		return tea.Quit
+	}
+	return checkIdle(remaining)
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// idleFor makes m look idle since d ago.
+func idleFor(m tea.Model, d time.Duration) Model {
+	idle := m.(Model)
+	idle.lastActivity = time.Now().Add(-d)
+	return idle
+}
+
+func TestIdleTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	var model tea.Model = newTestModel(WithIdleTimeout(timeout))
+
+	// Before the timeout the check is scheduled again, for when it would run out.
+	_, cmd := model.Update(idleMsg{})
+	if cmd == nil {
+		t.Fatal("no further idle check scheduled")
+	}
+	if msg := cmd(); msg != (idleMsg{}) {
+		t.Fatalf("idle check before the timeout = %T, want another check", msg)
+	}
+
+	// Past the timeout the application quits.
+	_, cmd = idleFor(model, 2*timeout).Update(idleMsg{})
+	if cmd == nil || cmd() != tea.Quit() {
+		t.Fatal("didn't quit after the idle timeout")
+	}
+}
+
+func TestIdleTimerResets(t *testing.T) {
+	inputs := []struct {
+		name string
+		msgs []tea.Msg
+	}{
+		{"wiki selection", nil},
+		{"search input", keys("enter")},
+		{"results", toResults},
+		{"article", toArticle},
+		{"in-article search", toFind},
+		{"refine form", then(toResults, []tea.Msg{tea.KeyMsg{Type: tea.KeyCtrlR}})},
+	}
+	activity := []tea.Msg{
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.MouseMsg{Action: tea.MouseActionPress, Button: tea.MouseButtonWheelDown},
+	}
+	for _, in := range inputs {
+		var model tea.Model = newTestModel(WithIdleTimeout(time.Hour))
+		for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, in.msgs) {
+			model, _ = model.Update(msg)
+		}
+		for _, msg := range activity {
+			idle := idleFor(model, 2*time.Hour)
+			updated, _ := idle.Update(msg)
+			if since := time.Since(updated.(Model).lastActivity); since > time.Minute {
+				t.Errorf("%s: %T didn't reset the idle timer", in.name, msg)
+			}
+		}
+		// Messages that aren't input, such as responses, leave it running.
+		idle := idleFor(model, 2*time.Hour)
+		updated, _ := idle.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+		if since := time.Since(updated.(Model).lastActivity); since < time.Hour {
+			t.Errorf("%s: a resize reset the idle timer", in.name)
+		}
+	}
+}
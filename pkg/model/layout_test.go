@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestTooSmallTerminal(t *testing.T) {
+	sizes := []tea.WindowSizeMsg{{Width: 10, Height: 24}, {Width: 80, Height: 3}, {Width: 1, Height: 1}}
+	for _, size := range sizes {
+		var model tea.Model = newTestModel()
+		for _, msg := range then(toArticle, []tea.Msg{size}) {
+			model, _ = model.Update(msg)
+		}
+		m := model.(Model)
+		if m.viewport.Width < 1 || m.viewport.Height < 1 {
+			t.Errorf("%dx%d: viewport is %dx%d, want at least 1x1", size.Width, size.Height, m.viewport.Width, m.viewport.Height)
+		}
+		view := m.View()
+		// However narrow the terminal, the notice is wrapped to it.
+		if got := strings.Join(strings.Fields(view), ""); got != "Terminaltoosmall(needatleast20×5)" {
+			t.Errorf("%dx%d: view %q, want the too-small notice", size.Width, size.Height, view)
+		}
+		for _, line := range strings.Split(view, "\n") {
+			if n := len([]rune(line)); n > size.Width {
+				t.Errorf("%dx%d: line %q is %d wide", size.Width, size.Height, line, n)
+			}
+		}
+
+		// Growing the terminal again brings the article back.
+		model, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+		if view := model.View(); !strings.Contains(view, "Go is a statically typed") {
+			t.Errorf("%dx%d, then 80x24: article not shown again:\n%s", size.Width, size.Height, view)
+		}
+	}
+}
+
+// widest returns the width of the longest line of s.
+func widest(s string) int {
+	width := 0
+	for _, line := range strings.Split(s, "\n") {
+		width = max(width, len([]rune(line)))
+	}
+	return width
+}
+
+func TestResizeDuringFetch(t *testing.T) {
+	var paragraphs []string
+	for i := range 30 {
+		paragraphs = append(paragraphs, fmt.Sprintf("Paragraph %d is a long run of words that needs wrapping whatever the width of the terminal is.", i+1))
+	}
+	article := wiki.ArticleMsg{Article: wiki.Article{Title: "Wide", Content: strings.Join(paragraphs, "\n\n"), Mode: wiki.ContentReadable}}
+
+	// The window shrinks while the article is being fetched.
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{tea.WindowSizeMsg{Width: 50, Height: 24}, article}) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if got := widest(m.rendered); got > m.viewport.Width || got < m.viewport.Width-10 {
+		t.Errorf("article arriving after a resize to 50 columns is wrapped to %d, want %d", got, m.viewport.Width)
+	}
+
+	// Resizing once it is shown re-wraps it, keeping the reading position.
+	m.viewport.SetYOffset(m.viewport.TotalLineCount() / 2)
+	before := m.viewport.ScrollPercent()
+	model, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 24})
+	m = model.(Model)
+	if got := widest(m.rendered); got > m.viewport.Width || got < m.viewport.Width-10 {
+		t.Errorf("after resizing to 100 columns the article is wrapped to %d, want %d", got, m.viewport.Width)
+	}
+	if after := m.viewport.ScrollPercent(); math.Abs(after-before) > 0.05 {
+		t.Errorf("scrolled to %.2f of the article after the resize, want %.2f", after, before)
+	}
+	if view := m.View(); !strings.Contains(view, "Paragraph") {
+		t.Errorf("article not shown after the resize:\n%s", view)
+	}
+}
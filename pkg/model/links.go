@@ -0,0 +1,306 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// hintKeys are the characters link hints are made of, home row first.
+const hintKeys = "asdfghjklqwertyuiopzxcvbnm"
+
+// linkPickerHelp is shown in the footer while the link picker is open.
+const linkPickerHelp = "Type a link's label to follow it, Esc to cancel."
+
+// linkHint labels a link shown in the article for the link picker.
+type linkHint struct {
+	label string
+	url   string
+	// start is the offset of the link in the rendered text.
+	start int
+}
+
+// WithIdentifierLinks makes DOIs and ISBNs in articles links to doi.org and to a book search.
+func WithIdentifierLinks() Option {
+	return func(m *Model) {
+		m.identifierLinks = true
+	}
+}
+
+// findLinks returns the spans of the links in rendered text, whose addresses are at urls, and
+// those of the DOIs and ISBNs among them. Identifiers are only links with WithIdentifierLinks.
+func (m Model) findLinks(rendered string, urls [][]int) (links, identifiers [][]int) {
+	if !m.identifierLinks {
+		return urls, nil
+	}
+	identifiers = utils.FindIdentifiers(rendered, urls)
+	return utils.MergeSpans(urls, identifiers), identifiers
+}
+
+// linkText returns the link at span of the rendered text, joining the pieces of one that
+// wrapping broke across lines.
+func (m Model) linkText(span []int) string {
+	lines := strings.Split(m.rendered[span[0]:span[1]], "\n")
+	for i := 1; i < len(lines); i++ {
+		// Continuation lines may be indented, or set behind a quotation's border.
+		lines[i] = strings.TrimLeft(lines[i], " "+quoteBorder)
+	}
+	return strings.Join(lines, "")
+}
+
+// linkTarget returns the address a link in the text leads to: the link itself, or the
+// resolver of a DOI or ISBN.
+func linkTarget(link string) string {
+	if target, ok := utils.IdentifierURL(link); ok {
+		return target
+	}
+	return link
+}
+
+// hintLabels returns n distinct labels: single letters while they suffice, two letters otherwise.
+func hintLabels(n int) []string {
+	labels := make([]string, 0, n)
+	if n <= len(hintKeys) {
+		for i := 0; i < n; i++ {
+			labels = append(labels, hintKeys[i:i+1])
+		}
+		return labels
+	}
+	for _, first := range hintKeys {
+		for _, second := range hintKeys {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(first)+string(second))
+		}
+	}
+	return labels
+}
+
+// startLinkPicker labels the links on screen so one can be followed by typing its label:
+// the addresses in the text, and the text of the links to other articles.
+func (m *Model) startLinkPicker() {
+	first, end := m.screenBytes()
+	var visible []linkHint
+	for _, match := range m.urlMatches {
+		if match[0] >= first && match[0] < end {
+			visible = append(visible, linkHint{url: linkTarget(m.linkText(match)), start: match[0]})
+		}
+	}
+	visible = append(visible, m.articleLinkHints(first, end)...)
+	if len(visible) == 0 {
+		m.articleNotice = "No links on screen."
+		return
+	}
+	slices.SortFunc(visible, func(a, b linkHint) int { return a.start - b.start })
+	// Two-letter labels cover hundreds of links; a screen holds far fewer.
+	visible = visible[:min(len(visible), len(hintKeys)*len(hintKeys))]
+	m.linkHints = nil
+	m.hintInput = ""
+	m.articleNotice = linkPickerHelp
+	for i, label := range hintLabels(len(visible)) {
+		hint := visible[i]
+		hint.label = label
+		m.linkHints = append(m.linkHints, hint)
+	}
+}
+
+// screenBytes returns the range of the rendered text that is on screen, as byte offsets.
+func (m Model) screenBytes() (first, end int) {
+	top, bottom := m.viewport.YOffset, m.viewport.YOffset+m.viewport.Height
+	first, end = len(m.rendered), len(m.rendered)
+	line := 0
+	for i := range len(m.rendered) + 1 {
+		if line == top && first == len(m.rendered) {
+			first = i
+		}
+		if line == bottom {
+			return first, i
+		}
+		if i < len(m.rendered) && m.rendered[i] == '\n' {
+			line++
+		}
+	}
+	return first, end
+}
+
+// articleLinkHints returns hints for the links to other articles whose text is in the rendered
+// text between first and end: one at the first place each link's text is found as whole
+// words, away from the addresses. Labels are written over the first two bytes of the text,
+// so text that doesn't start with two visible ASCII characters can't be labelled.
+func (m Model) articleLinkHints(first, end int) []linkHint {
+	var hints []linkHint
+	taken := slices.Clone(m.urlMatches)
+	for _, link := range m.articleLinks {
+		for _, span := range utils.FindWordSpans(m.rendered[first:end], link.Text) {
+			start, stop := first+span[0], first+span[1]
+			if stop-start < 2 || !labelable(m.rendered[start:start+2]) || overlaps(taken, start, stop) {
+				continue
+			}
+			taken = append(taken, []int{start, stop})
+			hints = append(hints, linkHint{url: wiki.ArticleURL(link.Title, m.searchType), start: start})
+			break
+		}
+	}
+	return hints
+}
+
+// labelable reports whether a label may be written over s: printable ASCII without spaces.
+func labelable(s string) bool {
+	for i := range len(s) {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether the span from start to stop overlaps any of spans.
+func overlaps(spans [][]int, start, stop int) bool {
+	for _, span := range spans {
+		if start < span[1] && span[0] < stop {
+			return true
+		}
+	}
+	return false
+}
+
+// pickLink narrows the link hints down by a typed key, following the link once its label is
+// complete. Esc, or a key no label continues with, closes the picker.
+func (m *Model) pickLink(msg tea.KeyMsg) tea.Cmd {
+	if msg.Type != tea.KeyRunes {
+		m.linkHints = nil
+		if msg.Type != tea.KeyEsc {
+			m.articleNotice = "Link picker closed."
+		}
+		return nil
+	}
+	m.hintInput += string(msg.Runes)
+	for _, hint := range m.linkHints {
+		if hint.label == m.hintInput {
+			m.linkHints = nil
+			return m.followLink(hint.url)
+		}
+		if strings.HasPrefix(hint.label, m.hintInput) {
+			m.articleNotice = linkPickerHelp
+			return nil
+		}
+	}
+	m.articleNotice = fmt.Sprintf("No link labelled %q.", m.hintInput)
+	m.linkHints = nil
+	return nil
+}
+
+// followLink opens a link to an article of the current wiki in the application, and handles
+// any other link according to the link action.
+func (m *Model) followLink(link string) tea.Cmd {
+	if title, ok := wiki.TitleFromURL(link, wiki.ArticleBase(m.searchType)); ok && !m.offline {
+		m.savePosition()
+		m.articleNotice = "Fetching " + title + "..."
+		return m.openArticle(title)
+	}
+	cmd := m.activateLink(link)
+	m.articleNotice = m.statusMsg
+	return cmd
+}
+
+// openTalkPage opens the Talk page of the article, or the article a Talk page discusses.
+// Offline it is handled like a link, as only the wiki has the page.
+func (m *Model) openTalkPage() tea.Cmd {
+	title, ok := wiki.TalkTitle(m.pageTitle())
+	if !ok {
+		m.articleNotice = "This page has no Talk page."
+		return nil
+	}
+	if m.offline {
+		talkURL, _ := wiki.TalkURL(m.pageTitle(), m.searchType)
+		cmd := m.activateLink(talkURL)
+		m.articleNotice = m.statusMsg
+		return cmd
+	}
+	m.savePosition()
+	m.articleNotice = "Fetching " + title + "..."
+	return m.openArticle(title)
+}
+
+// openMap shows the article's coordinates on OpenStreetMap, handled like a link.
+func (m *Model) openMap() tea.Cmd {
+	if m.coordinates == nil {
+		m.articleNotice = "This article has no coordinates."
+		return nil
+	}
+	cmd := m.activateLink(wiki.MapURL(*m.coordinates))
+	m.articleNotice = m.statusMsg
+	return cmd
+}
+
+// labelLinks overwrites the start of each labelled link in the rendered text with its label
+// and returns the labels' spans, for highlighting. Addresses start with "http", "10." or
+// "ISBN", and the text of article links is only labelled where it starts with ASCII, so the
+// labels replace ASCII bytes and the offsets of everything else stay the same.
+func (m Model) labelLinks(rendered string) (string, [][]int) {
+	b := []byte(rendered)
+	spans := make([][]int, 0, len(m.linkHints))
+	for _, hint := range m.linkHints {
+		if !strings.HasPrefix(hint.label, m.hintInput) {
+			continue
+		}
+		copy(b[hint.start:], hint.label)
+		spans = append(spans, []int{hint.start, hint.start + len(hint.label)})
+	}
+	return string(b), spans
+}
+
+// renderLinkHints highlights the article with the link picker's labels in place of search matches.
+func (m Model) renderLinkHints() string {
+	rendered, labels := m.labelLinks(m.rendered)
+	spans := utils.NoSpans()
+	spans.Matches, spans.URLs, spans.Identifiers = labels, m.urlMatches, m.identifierMatches
+	return utils.HighlightText(rendered, spans, m.highlightStyles)
+}
+
+// selectLink selects the next (delta 1) or previous (delta -1) link in the article, wrapping
+// around, and scrolls it into the middle of the screen if it is off screen.
+func (m *Model) selectLink(delta int) {
+	n := len(m.urlMatches)
+	if n == 0 {
+		m.articleNotice = "There are no links in this article."
+		return
+	}
+	if m.selectedLink == 0 && delta < 0 {
+		m.selectedLink = n
+	} else {
+		m.selectedLink = (m.selectedLink-1+delta+n)%n + 1
+	}
+	link := m.urlMatches[m.selectedLink-1]
+	line := utils.CalculateLineFromIndex(m.rendered, link[0])
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/2))
+	}
+	m.articleNotice = fmt.Sprintf("Link %d/%d: %s (Enter to open)", m.selectedLink, n, m.linkText(link))
+}
+
+// toggleURLs switches between showing the links in the article as they are and as numbered
+// markers such as "[link 3]". Markers are still links: Tab selects them, showing the address,
+// and F labels them, showing the addresses while it is open.
+func (m *Model) toggleURLs() {
+	m.hideURLs = !m.hideURLs
+	if m.hideURLs {
+		m.articleNotice = m.verbose("Links as [link] markers", "Links are shown as [link] markers; Tab selects one and shows its address. Press 'u' to show them in full.")
+	} else {
+		m.articleNotice = m.verbose("Links in full", "Links are shown in full.")
+	}
+}
+
+// openSelectedLink handles the selected link according to the link action.
+func (m *Model) openSelectedLink() tea.Cmd {
+	link := m.urlMatches[m.selectedLink-1]
+	cmd := m.activateLink(linkTarget(m.linkText(link)))
+	m.articleNotice = m.statusMsg
+	return cmd
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/settings"
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// open drives a fresh model to the test article.
+func open(t *testing.T) Model {
+	t.Helper()
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toArticle) {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestLinkPickerOpensArticleLinks(t *testing.T) {
+	m := open(t)
+	m.startLinkPicker()
+	var labels []string
+	targets := map[string]string{}
+	for _, hint := range m.linkHints {
+		labels = append(labels, hint.label)
+		targets[hint.label] = hint.url
+	}
+	// "Google" and "garbage collection" are labelled where they first appear, before the
+	// address; "C" is too short to hold a label.
+	want := []string{
+		"https://en.wikipedia.org/wiki/Google",
+		"https://en.wikipedia.org/wiki/Garbage_collection_(computer_science)",
+		"https://go.dev/doc/",
+	}
+	if len(m.linkHints) != len(want) {
+		t.Fatalf("labels %v lead to %v, want %v", labels, targets, want)
+	}
+	for i, hint := range m.linkHints {
+		if hint.url != want[i] {
+			t.Errorf("link %d (%s) leads to %s, want %s", i, hint.label, hint.url, want[i])
+		}
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(m.linkHints[1].label)})
+	m = updated.(Model)
+	if cmd == nil || !m.loading || m.selectedTitle != "Garbage collection (computer science)" {
+		t.Errorf("following the link: loading %v, title %q; want the linked article fetched", m.loading, m.selectedTitle)
+	}
+}
+
+func TestWrappedLinkOpensInFull(t *testing.T) {
+	const link = "https://example.org/wiki/a/long/path/to/the/page"
+	article := testArticle
+	article.Content = "Read more at " + link + " today."
+	var model tea.Model = newTestModel(WithReadingWidth(20), WithLinkAction(settings.LinkPrint))
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if !strings.Contains(m.rendered, "\n") || strings.Contains(m.rendered, link) {
+		t.Fatalf("rendered %q; want the link broken across lines", m.rendered)
+	}
+
+	m.selectLink(1)
+	if !strings.HasSuffix(m.articleNotice, link+" (Enter to open)") {
+		t.Errorf("selecting the link: notice %q, want the whole link", m.articleNotice)
+	}
+	m.openSelectedLink()
+	if m.printURL != link {
+		t.Errorf("opened %q, want %q", m.printURL, link)
+	}
+	m.startLinkPicker()
+	if len(m.linkHints) != 1 || m.linkHints[0].url != link {
+		t.Errorf("link picker hints %+v, want one for %q", m.linkHints, link)
+	}
+}
+
+func TestWrappedLinkInQuotationOpensInFull(t *testing.T) {
+	const link = "https://example.org/wiki/a/long/path/to/the/page"
+	m := newTestModel(WithReadingWidth(20))
+	m.viewport.Width = 80
+	m.articleContent = utils.CodeFence + utils.QuoteLang + "\nAs said at " + link + "\n" + utils.CodeFence + "\n"
+	m.refreshContent()
+	if len(m.urlMatches) != 1 {
+		t.Fatalf("links %v in %q, want one", m.urlMatches, m.rendered)
+	}
+	if got := m.linkText(m.urlMatches[0]); got != link {
+		t.Errorf("link %q, want %q", got, link)
+	}
+}
+
+func TestOpenMap(t *testing.T) {
+	article := testArticle
+	article.Title, article.DisplayTitle = "Berlin", "Berlin"
+	article.Coordinates = &wiki.Coordinates{Lat: 52.52, Lon: 13.405}
+	var model tea.Model = newTestModel(WithLinkAction(settings.LinkPrint))
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	if view := model.View(); !strings.Contains(view, "📍 52.5200, 13.4050") {
+		t.Errorf("coordinates not shown under the title:\n%s", view)
+	}
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if want := wiki.MapURL(*article.Coordinates); model.(Model).printURL != want || cmd == nil {
+		t.Errorf("M opened %q, want %q", model.(Model).printURL, want)
+	}
+
+	// Articles that aren't about places have neither.
+	m := open(t)
+	if strings.Contains(m.View(), "📍") {
+		t.Errorf("coordinates shown for an article without them:\n%s", m.View())
+	}
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if m := model.(Model); m.printURL != "" || m.articleNotice != "This article has no coordinates." {
+		t.Errorf("M without coordinates: opened %q, notice %q", m.printURL, m.articleNotice)
+	}
+}
+
+func TestIdentifierLinks(t *testing.T) {
+	article := testArticle
+	article.Content = "Described in doi:10.1000/xyz123. The book is ISBN 978-0-306-40615-7, see https://example.org/ too."
+	openWith := func(opts ...Option) Model {
+		var model tea.Model = newTestModel(append(opts, WithLinkAction(settings.LinkPrint))...)
+		for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+			model, _ = model.Update(msg)
+		}
+		return model.(Model)
+	}
+
+	m := openWith(WithIdentifierLinks())
+	if len(m.urlMatches) != 3 || len(m.identifierMatches) != 2 {
+		t.Fatalf("%d links of which %d identifiers, want 3 and 2", len(m.urlMatches), len(m.identifierMatches))
+	}
+	want := []string{"https://doi.org/10.1000/xyz123", "https://openlibrary.org/search?isbn=9780306406157", "https://example.org/"}
+	for i, target := range want {
+		m.selectLink(1)
+		m.openSelectedLink()
+		if m.printURL != target {
+			t.Errorf("link %d opened %q, want %q", i+1, m.printURL, target)
+		}
+	}
+
+	// Without the option they are plain text.
+	m = openWith()
+	if len(m.urlMatches) != 1 || m.identifierMatches != nil {
+		t.Errorf("%d links, identifiers %v, want only the address", len(m.urlMatches), m.identifierMatches)
+	}
+}
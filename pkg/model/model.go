@@ -1,17 +1,22 @@
 package model
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
 	"regexp"
-	"runtime"
 	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/fatih/color"
 
+	"wiki-search/pkg/settings"
+	"wiki-search/pkg/store"
 	"wiki-search/pkg/utils"
 	"wiki-search/pkg/wiki"
 )
@@ -24,6 +29,25 @@ const (
 	searchResultsView
 	articleView
 	searchArticleView
+	snapshotListView
+	categoryInputView
+	errorView
+	categoryListView
+	defineInputView
+	refineView
+	headingView
+	compareInputView
+	splitView
+)
+
+// Verbosity controls how chatty status messages are.
+type Verbosity int
+
+const (
+	// VerbosityNormal explains what happened and what to do next.
+	VerbosityNormal Verbosity = iota
+	// VerbosityQuiet keeps status messages to a minimum, leaving key hints to the footer.
+	VerbosityQuiet
 )
 
 // Model holds the state of our application.
@@ -40,81 +64,927 @@ type Model struct {
 	wikiCursor        int
 	viewport          viewport.Model
 	searchQuery       string
-	matchIndexes      []int
+	matchSpans        [][]int
 	currentMatchIndex int
 	urlRegex          *regexp.Regexp
 	urlMatches        [][]int
+	sortMode          string
+	resultFilter      wiki.ResultFilter
+	loading           bool
+	requestID         int
+	requestStart      time.Time
+	elapsed           time.Duration
+	sections          []wiki.Section
+	sectionPages      []sectionPage
+	sectionIndex      int
+	sectionPaging     bool
+	maxArticleLength  int
+	recentSearches    []string
+	recentCursor      int
+	// historyPos is the recent search shown in the input while browsing them with Up/Down,
+	// counting from 1 for the newest; 0 when not browsing. historyDraft is the text the
+	// input held before browsing started.
+	historyPos     int
+	historyDraft   string
+	matchMode      matchMode
+	resultsPerPage int
+	// minQueryLength is the number of characters a query needs for automatic requests.
+	minQueryLength  int
+	totalHits       int
+	nextOffset      int
+	snapshots       []store.Snapshot
+	snapshotCursor  int
+	offline         bool
+	articleNotice   string
+	highlightStyles utils.HighlightStyles
+	width           int
+	height          int
+	zen             bool
+	searchWhat      string
+	categoryInput   textinput.Model
+	category        string
+	refreshing      bool
+	verbosity       Verbosity
+	revisionID      int
+	// fragment is the section a redirect pointed at, kept for links to the article.
+	fragment string
+	// resolvedTitle is the title the article was found under after following redirects, and
+	// displayTitle the title as the wiki displays it, if known.
+	resolvedTitle string
+	displayTitle  string
+	// description is the article's short description, if it has one.
+	description string
+	// coordinates locate the article's subject, if it is a place.
+	coordinates *wiki.Coordinates
+	// articleMode is the content mode the article was fetched in.
+	articleMode string
+	// fallbackFrom is the wiki whose search found nothing while the results of its fallback
+	// edition are shown, or empty.
+	fallbackFrom string
+	// idleTimeout quits the application after this long without input, unless it is zero;
+	// lastActivity is when the last key was pressed.
+	idleTimeout  time.Duration
+	lastActivity time.Time
+	// headingInput filters the headings listed by the heading picker; headingMatches are the
+	// indexes of the sections matching it, best first.
+	headingInput   textinput.Model
+	headingMatches []int
+	headingCursor  int
+	// focusMode dims the article but for the line of the current search match.
+	focusMode bool
+	// visited lists the articles read this session, for exporting it; exportPath is the file
+	// the 'E' key writes to.
+	visited    []wiki.VisitedArticle
+	exportPath string
+	// categories are the current article's categories, listed with 'c'.
+	categories     []string
+	categoryCursor int
+	// articleLinks are the links to other articles in the current article's text, which F
+	// labels along with the addresses in it.
+	articleLinks []wiki.Link
+	// returnTo is the article a global search was started from, which esc on the results
+	// view returns to until another article is opened.
+	returnTo *articleOrigin
+	justify  bool
+	snippets bool
+	// linkAction is what activating a link does; printURL holds a link to print on exit.
+	linkAction settings.LinkAction
+	printURL   string
+	// ephemeral keeps reading positions and recent searches off the disk.
+	ephemeral bool
+	footer    *template.Template
+	failure   *requestFailure
+	// lastRequest and lastRequestStatus remember the latest request, for retrying it. A
+	// request that succeeds is forgotten.
+	lastRequest       wiki.Request
+	lastRequestStatus string
+	requestTimeout    time.Duration
+	// requestCtx is the context of the request in flight, and cancelInFlight cancels it.
+	requestCtx     context.Context
+	cancelInFlight context.CancelFunc
+	// rendered is the formatted and wrapped article text; search and link offsets index into it.
+	rendered string
+	// definition is the Wiktionary definition shown over the article, if any.
+	definition       *wiki.DefinitionMsg
+	numberedHeadings bool
+	// normalizeTypography shows typographic characters as ASCII.
+	normalizeTypography bool
+	// hideURLs shows the links in articles as numbered markers instead of their addresses.
+	hideURLs bool
+	// linkHints label the links on screen while the link picker is open; hintInput is what has
+	// been typed of a label so far.
+	linkHints []linkHint
+	hintInput string
+	// partial is set while only the introduction of the article is shown.
+	partial bool
+	// debugAllowed lets ctrl+g toggle the debug panel; debug shows it.
+	debugAllowed bool
+	debug        bool
+	// keepCitations keeps citation markers in the text; citationOrigin is where 'C' returns to
+	// after following one.
+	keepCitations  bool
+	citationOrigin *citationOrigin
+	matchPerLine   bool
+	// selectedLink is the link selected with Tab, counting from 1; 0 when none is.
+	selectedLink int
+	// showOperators lists the search operators in place of the results.
+	showOperators bool
+	// emptySearch is the query of a search that found nothing, and suggestion the wiki's
+	// spelling correction of it, for the no-results screen.
+	emptySearch string
+	suggestion  string
+	// codeBlocks locates the code blocks and quotations in rendered. codeStyle is the chroma
+	// style code is highlighted with, or empty to leave it plain; quotations are drawn in the
+	// Quote highlight style either way.
+	codeBlocks []utils.CodeBlock
+	codeStyle  string
+	// scrollStep is how many lines j/k scroll an article, and pageFraction how much of the
+	// page ctrl+u/ctrl+d scroll it.
+	scrollStep   int
+	pageFraction float64
+	// readingWidth is the width text is wrapped to when narrower than the window; 0 uses the
+	// whole window.
+	readingWidth int
+	// namespace is the namespace searched, 0 for articles. refineInputs are the fields of the
+	// refine search form and refineFocus the one being edited.
+	namespace    int
+	refineInputs []textinput.Model
+	refineFocus  int
+	// panes are the articles compared in the split view and paneFocus the one scrolled;
+	// splitStacked puts them one above the other, for narrow windows.
+	panes        [2]splitPane
+	paneFocus    int
+	splitStacked bool
+	// readingCursor marks the line cursorLine of the rendered article, which J/K move.
+	readingCursor bool
+	cursorLine    int
+	// identifierLinks makes DOIs and ISBNs links too; identifierMatches are their spans, which
+	// are also among urlMatches.
+	identifierLinks   bool
+	identifierMatches [][]int
+	// summary is the first sentence of the introduction of summaryFor, the selected search
+	// result, once fetched.
+	summaryFor articleOrigin
+	summary    string
+}
+
+// articleOrigin identifies an article kept loaded behind a global search.
+type articleOrigin struct {
+	title    string
+	wikiType string
+}
+
+// tickMsg drives the elapsed-time indicator of the request with the given ID.
+type tickMsg struct {
+	requestID int
+}
+
+// responseMsg carries the result of the request with the given ID, so responses to
+// cancelled requests can be told apart and dropped.
+type responseMsg struct {
+	requestID int
+	msg       tea.Msg
+}
+
+// tickElapsed schedules the next elapsed-time update.
+func tickElapsed(requestID int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return tickMsg{requestID: requestID}
+	})
+}
+
+// Option configures a Model at construction time.
+type Option func(*Model)
+
+// WithResultFilters registers filters that are applied, in order, to every set of search results.
+func WithResultFilters(filters ...wiki.ResultFilter) Option {
+	return func(m *Model) {
+		m.resultFilter = wiki.ChainFilters(filters...)
+	}
+}
+
+// WithRecentSearches seeds the recent searches dropdown, newest first.
+func WithRecentSearches(terms []string) Option {
+	return func(m *Model) {
+		m.recentSearches = terms
+	}
+}
+
+// WithResultsPerPage sets how many search results are requested at a time.
+func WithResultsPerPage(n int) Option {
+	return func(m *Model) {
+		m.resultsPerPage = n
+	}
+}
+
+// WithHighlightStyles sets the colors used for search matches, links and article text.
+func WithHighlightStyles(styles utils.HighlightStyles) Option {
+	return func(m *Model) {
+		m.highlightStyles = styles
+	}
+}
+
+// WithVerbosity sets how chatty status messages are.
+func WithVerbosity(v Verbosity) Option {
+	return func(m *Model) {
+		m.verbosity = v
+	}
+}
+
+// WithDefaultWiki preselects the named wiki in the wiki selection menu.
+func WithDefaultWiki(name string) Option {
+	return func(m *Model) {
+		for i, option := range m.wikiOptions {
+			if option == name {
+				m.wikiCursor = i
+			}
+		}
+	}
+}
+
+// WithJustify pads wrapped article lines so both margins are straight.
+func WithJustify() Option {
+	return func(m *Model) {
+		m.justify = true
+	}
+}
+
+// WithResultSnippets shows a short description and extract with each search result.
+func WithResultSnippets() Option {
+	return func(m *Model) {
+		m.snippets = true
+	}
 }
 
 // New initializes a new model.
-func New(ti textinput.Model, vp viewport.Model, urlRegex *regexp.Regexp) Model {
-	return Model{
-		textInput:   ti,
-		results:     []wiki.SearchResult{},
-		state:       wikiSelectionView,
-		wikiOptions: []string{"wikipedia", "arch"},
-		viewport:    vp,
-		urlRegex:    urlRegex,
+func New(ti textinput.Model, vp viewport.Model, urlRegex *regexp.Regexp, opts ...Option) Model {
+	categoryInput := textinput.New()
+	categoryInput.Prompt = "Category: "
+	categoryInput.Placeholder = "e.g. Networking (leave empty to search everywhere)"
+	categoryInput.CharLimit = 100
+	categoryInput.Width = ti.Width
+
+	m := Model{
+		textInput:        ti,
+		categoryInput:    categoryInput,
+		results:          []wiki.SearchResult{},
+		state:            wikiSelectionView,
+		wikiOptions:      wiki.SourceNames(),
+		viewport:         vp,
+		urlRegex:         urlRegex,
+		sortMode:         wiki.SortRelevance,
+		searchWhat:       wiki.SearchText,
+		resultFilter:     wiki.NoFilter,
+		maxArticleLength: settings.DefaultMaxArticleLength,
+		minQueryLength:   settings.DefaultMinQueryLength,
+		recentCursor:     -1,
+		highlightStyles:  utils.DefaultHighlightStyles(),
+		scrollStep:       settings.DefaultScrollStep,
+		pageFraction:     settings.DefaultPageFraction,
+		linkAction:       settings.LinkOpen,
+		footer:           defaultFooter,
+		lastActivity:     time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
+}
+
+// searchOptions collects the user's current search settings.
+func (m Model) searchOptions() wiki.SearchOptions {
+	return wiki.SearchOptions{Sort: m.sortMode, What: m.searchWhat, Category: m.category, Namespace: m.namespace, Limit: m.resultsPerPage, Snippets: m.snippets}
+}
+
+// verbose picks the status text for the configured verbosity.
+func (m Model) verbose(quiet, normal string) string {
+	if m.verbosity == VerbosityQuiet {
+		return quiet
+	}
+	return normal
+}
+
+// startRequest marks a request as in flight and starts the elapsed-time indicator, which
+// turns yellow as the request nears its timeout. A zero timeout is for local requests.
+func (m *Model) startRequest(status string, timeout time.Duration, request wiki.Request) tea.Cmd {
+	m.statusMsg = status
+	m.requestTimeout = timeout
+	m.lastRequest = request
+	m.lastRequestStatus = status
+	m.loading = true
+	m.requestID++
+	m.requestStart = time.Now()
+	m.elapsed = 0
+	// A request replaced by this one would only be ignored, so it is abandoned.
+	m.abandonRequest()
+	m.requestCtx, m.cancelInFlight = context.WithCancel(context.Background())
+	return tea.Batch(m.alongside(request), tickElapsed(m.requestID))
+}
+
+// alongside makes request with the context of the request in flight, delivering its response
+// as part of that request's, so cancelling it abandons both.
+func (m Model) alongside(request wiki.Request) tea.Cmd {
+	ctx, requestID := m.requestCtx, m.requestID
+	return func() tea.Msg {
+		return responseMsg{requestID: requestID, msg: request(ctx)}
+	}
+}
+
+// abandonRequest cancels the context of the request in flight, if any, which stops its
+// connections to the wiki.
+func (m *Model) abandonRequest() {
+	if m.cancelInFlight != nil {
+		m.cancelInFlight()
+		m.cancelInFlight = nil
+	}
+}
+
+// openArticle fetches an article of the current wiki. Its introduction is fetched alongside
+// and shown as soon as it arrives, unless the full article is quicker.
+func (m *Model) openArticle(title string) tea.Cmd {
+	m.selectedTitle = title
+	wantLead := m.wantLead(title)
+	fetch := m.startRequest("Fetching article...", wiki.ArticleTimeout, wiki.FetchArticle(title, m.searchType))
+	if !wantLead {
+		return fetch
+	}
+	return tea.Batch(fetch, m.alongside(wiki.FetchArticleLead(title, m.searchType)))
+}
+
+// smallArticle is the size, in bytes of wikitext, up to which an article is fetched without
+// its introduction first: the full article takes hardly longer to arrive.
+const smallArticle = 20000
+
+// wantLead reports whether to fetch the introduction of an article alongside it. Cached
+// articles open at once, and those the search found to be small arrive about as quickly as
+// their introduction would; for others, such as followed links, the size isn't known.
+func (m Model) wantLead(title string) bool {
+	if wiki.IsCached(title, m.searchType) {
+		return false
+	}
+	for _, result := range m.results {
+		if result.Title == title && result.Size > 0 {
+			return result.Size > smallArticle
+		}
+	}
+	return true
+}
+
+// cancelRequest abandons the request in flight: its connections are closed and anything it
+// still returns is ignored.
+func (m *Model) cancelRequest() {
+	m.loading = false
+	m.refreshing = false
+	m.abandonRequest()
+	m.requestID++
+	m.statusMsg = "Request cancelled."
+	if m.state == articleView {
+		m.articleNotice = m.statusMsg
+		if m.partial {
+			m.articleNotice = "Request cancelled; only the introduction was loaded. Press 'R' to load the full article."
+		}
+	}
+}
+
+// statusView renders the status message, including the elapsed time of an in-flight request.
+func (m Model) statusView() string {
+	if !m.loading || m.elapsed < time.Second {
+		return color.New(color.FgWhite).Sprint(m.statusMsg)
+	}
+	status := fmt.Sprintf("%s %ds (esc to cancel)", m.statusMsg, int(m.elapsed.Seconds()))
+	if m.requestTimeout > 0 && m.elapsed >= m.requestTimeout*3/4 {
+		return color.New(color.FgYellow).Sprint(status)
+	}
+	return color.New(color.FgWhite).Sprint(status)
+}
+
+// savePosition remembers how far the current article was read.
+func (m *Model) savePosition() {
+	if err := m.writePosition(); err != nil {
+		m.statusMsg = fmt.Sprintf("Could not save reading position: %v", err)
+	}
+}
+
+// writePosition stores how far the current article was read, if there is one to remember.
+func (m Model) writePosition() error {
+	if m.selectedTitle == "" || m.articleContent == "" || m.sectionPaging || m.partial || m.ephemeral {
+		return nil
+	}
+	return store.SavePosition(m.searchType, m.selectedTitle, m.viewport.ScrollPercent())
+}
+
+// Flush writes the state that is only kept in memory, the reading position of the open
+// article, to disk. Call it once the program has ended, however it ended, so quitting with a
+// link action or a signal doesn't lose the position.
+func (m Model) Flush() error {
+	return m.writePosition()
+}
+
+// restorePosition scrolls to where the current article was last left, if known.
+func (m *Model) restorePosition() {
+	m.viewport.SetYOffset(0)
+	if m.ephemeral {
+		return
+	}
+	percent, ok := store.LoadPosition(m.searchType, m.selectedTitle)
+	if !ok {
+		return
+	}
+	m.scrollToPercent(percent)
+}
+
+// scrollToPercent scrolls the viewport to a share of the way down the content, as returned
+// by ScrollPercent.
+func (m *Model) scrollToPercent(percent float64) {
+	maxOffset := max(0, m.viewport.TotalLineCount()-m.viewport.Height)
+	m.viewport.SetYOffset(int(percent * float64(maxOffset)))
+}
+
+// showRecent reports whether the recent searches dropdown is visible.
+func (m Model) showRecent() bool {
+	return m.state == searchResultsView && m.textInput.Focused() && m.textInput.Value() == "" && len(m.recentSearches) > 0
+}
+
+// rememberSearch records term as the most recent search and persists the list.
+func (m *Model) rememberSearch(term string) {
+	m.recentSearches = store.PushRecent(m.recentSearches, term)
+	m.recentCursor = -1
+	m.historyPos = 0
+	if m.ephemeral {
+		return
+	}
+	if err := store.SaveRecentSearches(m.recentSearches); err != nil {
+		m.statusMsg = fmt.Sprintf("Could not save recent searches: %v", err)
+	}
+}
+
+// switchWiki moves on to the next wiki, re-running the current query against it.
+func (m *Model) switchWiki() tea.Cmd {
+	m.wikiCursor = (m.wikiCursor + 1) % len(m.wikiOptions)
+	m.fallbackFrom = ""
+	m.searchType = m.wikiOptions[m.wikiCursor]
+	m.results = []wiki.SearchResult{}
+	m.cursor = 0
+	m.totalHits = 0
+	m.nextOffset = 0
+	m.emptySearch = ""
+	if m.textInput.Value() == "" {
+		m.statusMsg = m.verbose(m.searchType, fmt.Sprintf("Switched to %s.", m.searchType))
+		m.textInput.Focus()
+		return nil
+	}
+	return m.startRequest(m.verbose("Searching...", fmt.Sprintf("Searching %s...", m.searchType)), wiki.SearchTimeout,
+		wiki.PerformSearch(m.textInput.Value(), m.searchType, m.searchOptions()))
+}
+
+// browsingHistory reports whether Up/Down step through recent searches in the search input.
+// That is the case while the input is focused and holds a query; an empty input lists the
+// recent searches instead, and an unfocused one leaves Up/Down to the results.
+func (m Model) browsingHistory() bool {
+	return m.state == searchResultsView && m.textInput.Focused() && m.textInput.Value() != "" && len(m.recentSearches) > 0
+}
+
+// browseHistory puts an older (delta 1) or newer (delta -1) recent search in the input, like a
+// shell. Going past the newest restores what was typed before browsing. Editing a recalled
+// search starts browsing afresh from it.
+func (m *Model) browseHistory(delta int) {
+	value := m.textInput.Value()
+	if m.historyPos > 0 && (m.historyPos > len(m.recentSearches) || m.recentSearches[m.historyPos-1] != value) {
+		m.historyPos = 0
+	}
+	if m.historyPos == 0 {
+		m.historyDraft = value
+	}
+	pos := m.historyPos + delta
+	// Skip entries that wouldn't change the input, such as the search just run.
+	for pos > 0 && pos <= len(m.recentSearches) && m.recentSearches[pos-1] == value {
+		pos += delta
+	}
+	switch {
+	case pos > len(m.recentSearches):
+		return
+	case pos <= 0:
+		m.historyPos = 0
+		m.textInput.SetValue(m.historyDraft)
+	default:
+		m.historyPos = pos
+		m.textInput.SetValue(m.recentSearches[pos-1])
+	}
+	m.textInput.CursorEnd()
+}
+
+// paste inserts text into the focused input as a single line. Line breaks would otherwise
+// end up in the query, and the input's CharLimit still caps the length.
+func (m *Model) paste(text string) tea.Cmd {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(utils.SingleLine(text)), Paste: true}
+	var cmd tea.Cmd
+	if m.textInput.Focused() {
+		m.textInput, cmd = m.textInput.Update(msg)
+	} else if m.categoryInput.Focused() {
+		m.categoryInput, cmd = m.categoryInput.Update(msg)
+	}
+	return cmd
+}
+
+// articleTitle returns the title to show for the current article.
+func (m Model) articleTitle() string {
+	if m.displayTitle != "" {
+		return m.displayTitle
 	}
+	return m.pageTitle()
+}
+
+// pageTitle returns the title of the page being read: where a redirect led, if known, rather
+// than the title that was opened.
+func (m Model) pageTitle() string {
+	if m.resolvedTitle != "" {
+		return m.resolvedTitle
+	}
+	return m.selectedTitle
+}
+
+// subtitle returns the line shown under the article title: the short description and the
+// coordinates, as far as the article has them.
+func (m Model) subtitle() string {
+	var parts []string
+	if m.description != "" {
+		parts = append(parts, m.description)
+	}
+	if m.coordinates != nil {
+		parts = append(parts, "📍 "+m.coordinates.String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+// Below this terminal size the UI can't be laid out sensibly.
+const (
+	minWidth  = settings.MinReadingWidth
+	minHeight = 5
+)
+
+// layout sizes the viewport for the window, leaving room for the title and footer unless in zen mode.
+func (m *Model) layout() {
+	m.viewport.Width = max(1, m.width)
+	if m.zen {
+		m.viewport.Height = max(1, m.height)
+	} else {
+		m.viewport.Height = max(1, m.height-4)
+	}
+}
+
+// tooSmall reports whether the terminal is known to be below the usable size.
+func (m Model) tooSmall() bool {
+	return m.width > 0 && (m.width < minWidth || m.height < minHeight)
+}
+
+// resultsStatus summarizes the current search results.
+func (m Model) resultsStatus() string {
+	found := m.foundResults()
+	if m.verbosity == VerbosityQuiet {
+		if m.totalHits > found {
+			return fmt.Sprintf("%d of %d results", found, m.totalHits)
+		}
+		return fmt.Sprintf("%d results", found)
+	}
+	if found == 0 && m.totalHits == 0 {
+		return fmt.Sprintf("No results on %s (%s).", m.searchType, m.searchSettings())
+	}
+	count := fmt.Sprintf("Found %d results", found)
+	if m.totalHits > found {
+		count = fmt.Sprintf("Showing %d of %d results", found, m.totalHits)
+	}
+	return fmt.Sprintf("%s on %s for '%s' (%s). Press Enter to select one.", count, m.searchType, utils.TruncateRunes(m.textInput.Value(), maxQueryEcho), m.searchSettings())
+}
+
+// sparseResults is the number of results below which related titles are suggested.
+const sparseResults = 5
+
+// foundResults counts the results found by the search itself, leaving out related titles.
+func (m Model) foundResults() int {
+	n := 0
+	for _, result := range m.results {
+		if !result.Related {
+			n++
+		}
+	}
+	return n
+}
+
+// maxQueryEcho bounds how much of the query is repeated in status messages.
+const maxQueryEcho = 40
+
+// maxQuickSelect is the number of results that can be opened with the digit keys.
+const maxQuickSelect = 9
+
+// searchSettings describes the active sort order and search mode for status messages.
+func (m Model) searchSettings() string {
+	settings := fmt.Sprintf("sorted by %s, %s", sortLabel(m.sortMode), whatLabel(m.searchWhat))
+	if m.category != "" {
+		settings += ", in Category:" + m.category
+	}
+	if m.namespace != 0 {
+		settings += ", in namespace " + wiki.NamespaceName(m.namespace)
+	}
+	return settings
+}
+
+// whatLabel returns a human-readable name for a search mode.
+func whatLabel(what string) string {
+	switch what {
+	case wiki.SearchTitle:
+		return "titles only"
+	case wiki.SearchNearMatch:
+		return "near match"
+	}
+	return "full text"
+}
+
+// fitWidth truncates s to the terminal width minus reserved columns. Before the
+// terminal size is known, s is returned unchanged.
+func (m Model) fitWidth(s string, reserved int) string {
+	if m.width <= 0 {
+		return s
+	}
+	return utils.TruncateRunes(s, max(1, m.width-reserved))
+}
+
+// sortLabel returns a human-readable name for a sort mode.
+func sortLabel(mode string) string {
+	if mode == wiki.SortLastEdit {
+		return "recently edited"
+	}
+	return "relevance"
 }
 
 // Init initializes the application state.
 func (m Model) Init() tea.Cmd {
+	if m.idleTimeout > 0 {
+		return tea.Batch(textinput.Blink, checkIdle(m.idleTimeout))
+	}
 	return textinput.Blink
 }
 
 // Update handles all user input and model updates.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(idleMsg); ok {
+		return m, m.handleIdle()
+	}
+	m.noteActivity(msg)
+	updated, cmd := m.update(msg)
+	next := updated.(Model)
+	focusCmd := next.syncFocus()
+	summaryCmd := next.scheduleSummary()
+	return next, tea.Batch(cmd, focusCmd, summaryCmd)
+}
+
+// syncFocus focuses the input that belongs to the current state and blurs the other, so the
+// user can always type where input is expected. On the results screen the search input is
+// focused whenever there are no results to navigate, e.g. after a failed or empty search.
+func (m *Model) syncFocus() tea.Cmd {
+	focus := func(input *textinput.Model) tea.Cmd {
+		if input.Focused() {
+			return nil
+		}
+		return input.Focus()
+	}
+	switch m.state {
+	case searchResultsView:
+		m.categoryInput.Blur()
+		if len(m.results) == 0 && !m.loading {
+			return focus(&m.textInput)
+		}
+	case searchArticleView, defineInputView, compareInputView:
+		m.categoryInput.Blur()
+		return focus(&m.textInput)
+	case categoryInputView:
+		m.textInput.Blur()
+		return focus(&m.categoryInput)
+	case refineView, headingView:
+		m.textInput.Blur()
+		m.categoryInput.Blur()
+	default:
+		m.textInput.Blur()
+		m.categoryInput.Blur()
+	}
+	return nil
+}
+
+// update applies msg to the model; Update wraps it to keep the input focus in sync.
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var vpCmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.viewport.Width = msg.Width
-		m.viewport.Height = msg.Height - 4
-		wrappedContent := utils.WrapText(m.articleContent, m.viewport.Width)
-		m.viewport.SetContent(wrappedContent)
+		// Re-wrapping to the new width changes the line count, so keep the same share of the
+		// text above the screen. An article still being fetched is wrapped to the current
+		// width when it arrives.
+		scrolled := m.viewport.YOffset > 0
+		percent := m.viewport.ScrollPercent()
+		m.width = msg.Width
+		m.height = msg.Height
+		m.layout()
+		m.refreshContent()
+		if scrolled {
+			m.scrollToPercent(percent)
+		}
+		if m.state == splitView && !m.layoutSplit() {
+			m.closeSplit()
+			m.articleNotice = "The window became too small to compare articles."
+		}
 
 	case tea.KeyMsg:
+		m.articleNotice = ""
+		if m.definition != nil {
+			// Any key closes the definition overlay; esc does nothing else.
+			m.definition = nil
+			if msg.String() == "esc" {
+				return m, nil
+			}
+		}
+		if m.linkHints != nil {
+			return m, m.pickLink(msg)
+		}
+		if m.state == refineView {
+			return m, m.updateRefine(msg)
+		}
+		if m.state == headingView {
+			return m, m.updateHeadingPicker(msg)
+		}
+		if m.state == splitView {
+			return m, m.updateSplit(msg)
+		}
+		if m.showOperators {
+			// Any key closes the operator help.
+			m.showOperators = false
+			return m, nil
+		}
+		if msg.Paste {
+			return m, m.paste(string(msg.Runes))
+		}
+		// Typed characters belong to the focused input, not to the single-letter key bindings.
+		if msg.Type == tea.KeyRunes && (m.textInput.Focused() || m.categoryInput.Focused()) {
+			break
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
+			// The reading position is written by Flush once the program has ended.
 			return m, tea.Quit
 
 		case "esc":
+			if m.loading {
+				m.cancelRequest()
+				return m, nil
+			}
 			switch m.state {
 			case articleView, searchArticleView:
+				m.savePosition()
 				m.state = searchResultsView
+				if m.offline {
+					m.state = snapshotListView
+				}
 				m.articleContent = ""
+				m.clearMatches()
+				m.partial = false
+				m.sections = nil
+				m.sectionPages = nil
+				m.sectionPaging = false
 				m.textInput.Focus()
 				return m, nil
 			case searchResultsView:
+				if m.returnTo != nil {
+					m.selectedTitle = m.returnTo.title
+					m.searchType = m.returnTo.wikiType
+					m.returnTo = nil
+					m.state = articleView
+					m.textInput.Blur()
+					m.statusMsg = m.verbose(m.selectedTitle, fmt.Sprintf("Displaying article: %s", m.selectedTitle))
+					m.rememberVisit()
+					return m, nil
+				}
 				m.state = wikiSelectionView
 				m.textInput.Blur()
 				return m, nil
+			case snapshotListView:
+				m.state = wikiSelectionView
+				m.offline = false
+				return m, nil
+			case categoryInputView:
+				m.state = searchResultsView
+				m.categoryInput.Blur()
+				return m, nil
+			case errorView:
+				m.leaveErrorView()
+				return m, nil
+			case categoryListView, defineInputView, compareInputView:
+				m.state = articleView
+				return m, nil
 			}
 			return m, tea.Quit
 
 		case "/":
+			if m.state == articleView && m.partial {
+				m.articleNotice = "Search is available once the full article has loaded."
+				return m, nil
+			}
 			if m.state == articleView {
 				m.state = searchArticleView
 				m.textInput.Focus()
-				m.textInput.Prompt = "/"
+				m.textInput.Prompt = m.articleSearchPrompt()
 				m.textInput.CharLimit = 100
+				m.textInput.SetValue("")
+				return m, nil
+			}
+
+		case "D":
+			if m.state == articleView {
+				return m, m.startDefine()
+			}
+
+		case "V":
+			if m.state == articleView {
+				return m, m.startCompare()
+			}
+
+		case "ctrl+o":
+			if m.state == searchResultsView {
+				m.showOperators = true
+				return m, nil
+			}
+
+		case "ctrl+g":
+			if m.debugAllowed {
+				m.debug = !m.debug
+				return m, nil
+			}
+
+		case "C":
+			if m.state == articleView && m.keepCitations {
+				m.followCitation()
+				return m, nil
+			}
+
+		case "F":
+			if m.state == articleView {
+				m.startLinkPicker()
+				return m, nil
+			}
+
+		case ":":
+			if m.state == articleView {
+				m.savePosition()
+				m.returnTo = &articleOrigin{title: m.selectedTitle, wikiType: m.searchType}
+				m.state = searchResultsView
+				m.textInput.SetValue("")
+				m.textInput.Prompt = "> "
+				m.textInput.Focus()
+				m.recentCursor = -1
+				return m, nil
+			}
+
+		case "tab":
+			if m.state == searchResultsView && m.emptySearch != "" && m.suggestion != "" {
+				return m, m.searchSuggestion()
+			}
+			if m.state == searchArticleView {
+				m.matchMode = (m.matchMode + 1) % matchModes
+				m.textInput.Prompt = m.articleSearchPrompt()
+				return m, nil
+			}
+			if m.state == articleView {
+				m.selectLink(1)
+				return m, nil
+			}
+
+		case "shift+tab":
+			if m.state == articleView {
+				m.selectLink(-1)
 				return m, nil
 			}
 
 		case "n":
-			if m.state == articleView && len(m.matchIndexes) > 0 {
-				m.currentMatchIndex = (m.currentMatchIndex + 1) % len(m.matchIndexes)
-				m.viewport.SetYOffset(utils.CalculateLineFromIndex(m.articleContent, m.matchIndexes[m.currentMatchIndex]))
+			if m.state == articleView && len(m.matchSpans) > 0 {
+				m.nextMatch(1)
 			}
 		case "p":
-			if m.state == articleView && len(m.matchIndexes) > 0 {
-				m.currentMatchIndex = (m.currentMatchIndex - 1 + len(m.matchIndexes)) % len(m.matchIndexes)
-				m.viewport.SetYOffset(utils.CalculateLineFromIndex(m.articleContent, m.matchIndexes[m.currentMatchIndex]))
+			if m.state == articleView && len(m.matchSpans) > 0 {
+				m.nextMatch(-1)
 			}
 		case "up", "k":
+			if m.showRecent() && msg.String() == "up" {
+				if m.recentCursor > -1 {
+					m.recentCursor--
+				}
+				return m, nil
+			}
+			if m.browsingHistory() && msg.String() == "up" {
+				m.browseHistory(1)
+				return m, nil
+			}
 			switch m.state {
+			case articleView:
+				m.scrollLines(-1)
+				return m, nil
 			case searchResultsView:
 				if m.cursor > 0 {
 					m.cursor--
@@ -123,10 +993,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.wikiCursor > 0 {
 					m.wikiCursor--
 				}
+			case snapshotListView:
+				if m.snapshotCursor > 0 {
+					m.snapshotCursor--
+				}
+			case categoryListView:
+				if m.categoryCursor > 0 {
+					m.categoryCursor--
+				}
 			}
 
 		case "down", "j":
+			if m.showRecent() && msg.String() == "down" {
+				if m.recentCursor < len(m.recentSearches)-1 {
+					m.recentCursor++
+				}
+				return m, nil
+			}
+			if m.browsingHistory() && msg.String() == "down" {
+				m.browseHistory(-1)
+				return m, nil
+			}
 			switch m.state {
+			case articleView:
+				m.scrollLines(1)
+				return m, nil
 			case searchResultsView:
 				if m.cursor < len(m.results)-1 {
 					m.cursor++
@@ -135,148 +1026,755 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.wikiCursor < len(m.wikiOptions)-1 {
 					m.wikiCursor++
 				}
+			case snapshotListView:
+				if m.snapshotCursor < len(m.snapshots)-1 {
+					m.snapshotCursor++
+				}
+			case categoryListView:
+				if m.categoryCursor < len(m.categories)-1 {
+					m.categoryCursor++
+				}
 			}
 
-		case "ctrl+u", "ctrl+d":
+		case "home", "end":
+			if m.state == searchResultsView && !m.textInput.Focused() && len(m.results) > 0 {
+				m.cursor = 0
+				if msg.String() == "end" {
+					m.cursor = len(m.results) - 1
+				}
+				return m, nil
+			}
+
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if m.state == searchResultsView && !m.textInput.Focused() {
+				i := int(msg.String()[0] - '1')
+				if i >= len(m.results) {
+					return m, nil
+				}
+				m.cursor = i
+				return m, m.openArticle(m.results[i].Title)
+			}
+
+		case "s":
+			switch m.state {
+			case wikiSelectionView:
+				m.showSnapshots()
+				return m, nil
+			case articleView:
+				if m.partial {
+					m.articleNotice = "Only the introduction has loaded; wait for the full article to save it."
+					return m, nil
+				}
+				m.saveSnapshot()
+				return m, nil
+			}
+			if m.state == searchResultsView && !m.textInput.Focused() {
+				if m.sortMode == wiki.SortLastEdit {
+					m.sortMode = wiki.SortRelevance
+				} else {
+					m.sortMode = wiki.SortLastEdit
+				}
+				if m.textInput.Value() == "" {
+					m.statusMsg = m.verbose(sortLabel(m.sortMode), fmt.Sprintf("Sorting by %s.", sortLabel(m.sortMode)))
+					return m, nil
+				}
+				return m, m.startRequest(m.verbose("Searching...", fmt.Sprintf("Searching (%s)...", m.searchSettings())), wiki.SearchTimeout,
+					wiki.PerformSearch(m.textInput.Value(), m.searchType, m.searchOptions()))
+			}
+
+		case "t":
+			if m.state == searchResultsView && !m.textInput.Focused() {
+				switch m.searchWhat {
+				case wiki.SearchText:
+					m.searchWhat = wiki.SearchTitle
+				case wiki.SearchTitle:
+					m.searchWhat = wiki.SearchNearMatch
+				default:
+					m.searchWhat = wiki.SearchText
+				}
+				if m.textInput.Value() == "" {
+					m.statusMsg = m.verbose(whatLabel(m.searchWhat), fmt.Sprintf("Searching %s.", whatLabel(m.searchWhat)))
+					return m, nil
+				}
+				return m, m.startRequest(m.verbose("Searching...", fmt.Sprintf("Searching (%s)...", m.searchSettings())), wiki.SearchTimeout,
+					wiki.PerformSearch(m.textInput.Value(), m.searchType, m.searchOptions()))
+			}
+
+		case "w":
+			if m.state == searchResultsView && !m.textInput.Focused() {
+				return m, m.switchWiki()
+			}
+
+		case "ctrl+n":
+			if m.state == searchResultsView && m.emptySearch != "" {
+				return m, m.switchWiki()
+			}
+
+		case "ctrl+r":
+			if m.state == searchResultsView {
+				return m, m.startRefine()
+			}
+
+		case "ctrl+b":
+			if m.state == searchResultsView && m.emptySearch != "" && (m.searchWhat != wiki.SearchText || m.category != "" || m.namespace != 0) {
+				return m, m.broadenSearch()
+			}
+
+		case "c":
+			if m.state == searchResultsView && !m.textInput.Focused() {
+				m.state = categoryInputView
+				m.categoryInput.SetValue(m.category)
+				m.categoryInput.CursorEnd()
+				return m, m.categoryInput.Focus()
+			}
 			if m.state == articleView {
-				m.viewport, vpCmd = m.viewport.Update(msg)
-				return m, vpCmd
+				m.showCategories()
+				return m, nil
 			}
 
-		case "o":
-			if m.state == searchResultsView && len(m.results) > 0 {
-				selectedTitle := m.results[m.cursor].Title
-				var pageURL string
-				if m.searchType == "arch" {
-					pageURL = "https://wiki.archlinux.org/index.php/" + strings.ReplaceAll(selectedTitle, " ", "_")
+		case "m":
+			if m.state == searchResultsView && !m.textInput.Focused() && m.nextOffset > 0 {
+				opts := m.searchOptions()
+				opts.Offset = m.nextOffset
+				return m, m.startRequest(m.verbose("Loading...", "Loading more results..."), wiki.SearchTimeout, wiki.PerformSearch(m.textInput.Value(), m.searchType, opts))
+			}
+
+		case "ctrl+l":
+			if m.state == searchResultsView {
+				// A search still in flight would fill the cleared list when it returns.
+				m.cancelRequest()
+				m.textInput.SetValue("")
+				m.results = []wiki.SearchResult{}
+				m.cursor = 0
+				m.totalHits = 0
+				m.nextOffset = 0
+				m.emptySearch = ""
+				m.statusMsg = ""
+				m.textInput.Focus()
+				return m, nil
+			}
+
+		case "ctrl+v":
+			if m.textInput.Focused() || m.categoryInput.Focused() {
+				text, err := clipboard.ReadAll()
+				if err != nil {
+					m.statusMsg = fmt.Sprintf("Could not read the clipboard: %v", err)
+					return m, nil
+				}
+				return m, m.paste(text)
+			}
+
+		case "R":
+			if m.state == articleView {
+				if m.offline {
+					m.articleNotice = "Snapshots can't be refreshed in offline mode."
+					return m, nil
+				}
+				m.refreshing = true
+				m.articleNotice = "Refreshing..."
+				return m, m.startRequest("Refreshing...", wiki.ArticleTimeout, wiki.RefreshArticle(m.selectedTitle, m.searchType))
+			}
+
+		case "v":
+			if m.state == articleView {
+				return m, m.cycleContentMode()
+			}
+
+		case "u":
+			if m.state == articleView {
+				m.toggleURLs()
+				return m, nil
+			}
+
+		case "h":
+			if m.state == articleView {
+				return m, m.startHeadingPicker()
+			}
+
+		case "T":
+			if m.state == articleView {
+				return m, m.openTalkPage()
+			}
+
+		case "E":
+			if m.state == articleView {
+				m.exportSession()
+				return m, nil
+			}
+
+		case "M":
+			if m.state == articleView {
+				return m, m.openMap()
+			}
+
+		case "y":
+			if m.state == articleView {
+				permalink := wiki.PermalinkURL(m.selectedTitle, m.searchType, m.revisionID)
+				if m.fragment != "" {
+					permalink += "#" + strings.ReplaceAll(m.fragment, " ", "_")
+				}
+				if err := clipboard.WriteAll(permalink); err != nil {
+					m.articleNotice = fmt.Sprintf("Could not copy to the clipboard (%v): %s", err, permalink)
+				} else if m.revisionID > 0 {
+					m.articleNotice = m.verbose("Permalink copied", fmt.Sprintf("Copied permalink to revision %d: %s", m.revisionID, permalink))
+				} else {
+					m.articleNotice = m.verbose("Link copied", "Revision unknown, copied the article link instead: "+permalink)
+				}
+				return m, nil
+			}
+
+		case "f":
+			if m.state == articleView {
+				m.focusMode = !m.focusMode
+				switch {
+				case !m.focusMode:
+					m.articleNotice = m.verbose("Focus mode off", "Focus mode off.")
+				case len(m.matchSpans) == 0:
+					m.articleNotice = "Focus mode on; search with '/' to dim all but the current match's line."
+				default:
+					m.articleNotice = m.verbose("Focus mode on", "Focus mode on: everything but the current match's line is dimmed.")
+				}
+				return m, nil
+			}
+
+		case "z":
+			if m.state == articleView {
+				offset := m.viewport.YOffset
+				m.zen = !m.zen
+				m.layout()
+				m.viewport.SetYOffset(offset)
+				return m, nil
+			}
+
+		case "]", "[":
+			if m.state == articleView && len(m.sectionPages) > 1 {
+				if msg.String() == "]" {
+					m.gotoSection(1)
+				} else {
+					m.gotoSection(-1)
+				}
+				return m, nil
+			}
+
+		case "A":
+			if m.state == articleView && len(m.sectionPages) > 1 {
+				m.toggleSectionPaging()
+				return m, nil
+			}
+
+		case "+", "=", "-":
+			if m.state == articleView {
+				if msg.String() == "-" {
+					m.adjustReadingWidth(-1)
 				} else {
-					pageURL = "https://en.wikipedia.org/wiki/" + strings.ReplaceAll(selectedTitle, " ", "_")
+					m.adjustReadingWidth(1)
 				}
+				return m, nil
+			}
 
-				var openCmd *exec.Cmd
-				switch runtime.GOOS {
-				case "linux":
-					openCmd = exec.Command("xdg-open", pageURL)
-				case "darwin":
-					openCmd = exec.Command("open", pageURL)
-				case "windows":
-					openCmd = exec.Command("cmd", "/c", "start", pageURL)
+		case "J", "K":
+			if m.state == articleView && m.readingCursor {
+				if msg.String() == "J" {
+					m.moveReadingCursor(1)
+				} else {
+					m.moveReadingCursor(-1)
 				}
-				if openCmd != nil {
-					openCmd.Start()
+				return m, nil
+			}
+
+		case "ctrl+u", "ctrl+d":
+			if m.state == articleView {
+				if msg.String() == "ctrl+u" {
+					m.scrollPage(-1)
+				} else {
+					m.scrollPage(1)
+				}
+				return m, nil
+			}
+
+		case "r":
+			if m.state == errorView {
+				if m.failure.request == nil {
+					m.leaveErrorView()
+					return m, nil
+				}
+				return m, m.retry()
+			}
+			if m.state == articleView && m.lastRequest != nil && !m.loading {
+				// A request that failed without leaving the article, such as a definition
+				// lookup, or one whose error view was left with esc.
+				m.articleNotice = m.lastRequestStatus
+				return m, m.startRequest(m.lastRequestStatus, m.requestTimeout, m.lastRequest)
+			}
+
+		case "o":
+			if (m.state == searchResultsView && len(m.results) > 0) || (m.state == errorView && m.failure.pageURL != "") {
+				var pageURL string
+				if m.state == errorView {
+					pageURL = m.failure.pageURL
+				} else {
+					pageURL = wiki.ArticleURL(m.results[m.cursor].Title, m.searchType)
 				}
-				return m, tea.Quit
+				return m, m.activateLink(pageURL)
 			}
 
 		case "enter":
+			if m.state == articleView && m.selectedLink > 0 {
+				return m, m.openSelectedLink()
+			}
 			if m.state == wikiSelectionView {
+				m.fallbackFrom = ""
 				m.searchType = m.wikiOptions[m.wikiCursor]
 				m.state = searchResultsView
 				m.textInput.Focus()
 				return m, nil
+			} else if m.state == categoryInputView {
+				m.category = wiki.CleanCategory(m.categoryInput.Value())
+				m.categoryInput.Blur()
+				m.state = searchResultsView
+				scope := m.verbose("All categories", "Search scope cleared.")
+				if m.category != "" {
+					scope = m.verbose("Category:"+m.category, fmt.Sprintf("Searching only in Category:%s.", m.category))
+				}
+				if m.textInput.Value() == "" {
+					m.statusMsg = scope
+					m.textInput.Focus()
+					return m, nil
+				}
+				return m, m.startRequest(m.verbose("Searching...", fmt.Sprintf("Searching (%s)...", m.searchSettings())), wiki.SearchTimeout,
+					wiki.PerformSearch(m.textInput.Value(), m.searchType, m.searchOptions()))
+			} else if m.state == categoryListView {
+				return m, m.searchCategory()
+			} else if m.state == defineInputView {
+				return m, m.define()
+			} else if m.state == compareInputView {
+				return m, m.compare()
+			} else if m.state == snapshotListView {
+				if len(m.snapshots) == 0 {
+					return m, nil
+				}
+				snapshot := m.snapshots[m.snapshotCursor]
+				m.offline = true
+				m.selectedTitle = snapshot.Title
+				m.searchType = snapshot.WikiType
+				return m, m.startRequest("Opening snapshot...", 0, openSnapshot(snapshot.WikiType, snapshot.Title))
 			} else if m.state == searchArticleView {
 				m.searchQuery = m.textInput.Value()
-				m.matchIndexes = utils.FindMatches(m.articleContent, m.searchQuery)
+				m.findMatches()
 				m.currentMatchIndex = 0
 				m.textInput.Blur()
 				m.state = articleView
-				if len(m.matchIndexes) > 0 {
-					m.viewport.SetYOffset(utils.CalculateLineFromIndex(m.articleContent, m.matchIndexes[0]))
+				if len(m.matchSpans) > 0 {
+					m.showMatch()
+				} else if m.searchQuery != "" {
+					m.articleNotice = fmt.Sprintf("No matches for %q.", m.searchQuery)
 				}
 				return m, nil
 			} else if m.textInput.Focused() {
+				if m.showRecent() && m.recentCursor >= 0 {
+					m.textInput.SetValue(m.recentSearches[m.recentCursor])
+				}
 				if m.textInput.Value() != "" {
-					m.statusMsg = "Searching..."
 					m.textInput.Blur()
-					return m, wiki.PerformSearch(m.textInput.Value(), m.searchType)
+					m.leaveFallback()
+					m.rememberSearch(m.textInput.Value())
+					return m, m.startRequest("Searching...", wiki.SearchTimeout, wiki.PerformSearch(m.textInput.Value(), m.searchType, m.searchOptions()))
 				}
 			} else if m.state == searchResultsView && len(m.results) > 0 {
-				m.selectedTitle = m.results[m.cursor].Title
-				m.statusMsg = "Fetching article..."
-				return m, wiki.FetchArticle(m.selectedTitle, m.searchType)
+				return m, m.openArticle(m.results[m.cursor].Title)
 			}
 		}
 
+	case responseMsg:
+		if !m.loading || msg.requestID != m.requestID {
+			return m, nil
+		}
+		// update, not Update, which syncs focus and schedules summaries once this returns.
+		updated, cmd := m.update(msg.msg)
+		next := updated.(Model)
+		if !next.loading && responseErr(msg.msg) == nil {
+			// Only a failed request is kept for 'r' to retry.
+			next.lastRequest = nil
+		}
+		return next, cmd
+
+	case tickMsg:
+		if m.loading && msg.requestID == m.requestID {
+			m.elapsed = time.Since(m.requestStart)
+			return m, tickElapsed(msg.requestID)
+		}
+		return m, nil
+
+	case wiki.DefinitionMsg:
+		m.loading = false
+		m.showDefinition(msg)
+
+	case splitArticleMsg:
+		m.loading = false
+		m.openSplit(msg)
+
+	case summaryDueMsg:
+		return m, m.fetchSummary(msg)
+
+	case wiki.SummaryMsg:
+		m.showSummary(msg)
+		return m, nil
+
 	case wiki.SearchMsg:
+		m.loading = false
 		if msg.Err != nil {
-			m.statusMsg = fmt.Sprintf("Error: %v", msg.Err)
-			m.textInput.Focus()
+			m.fail(msg.Err, wiki.SearchURL(m.textInput.Value(), m.searchType), true, false)
 		} else {
-			m.results = msg.Results
-			m.statusMsg = fmt.Sprintf("Found %d results for '%s'. Press Enter to select one.", len(m.results), m.textInput.Value())
-			m.cursor = 0
+			if msg.Offset > 0 {
+				m.results = wiki.AppendPage(m.results, m.resultFilter(msg.Results))
+			} else {
+				m.results = m.resultFilter(msg.Results)
+				m.cursor = 0
+			}
+			m.totalHits = msg.TotalHits
+			m.nextOffset = msg.NextOffset
+			m.statusMsg = m.resultsStatus()
+			m.emptySearch = ""
+			m.suggestion = msg.Suggestion
+			if len(m.results) == 0 {
+				m.emptySearch = m.textInput.Value()
+				if msg.Offset == 0 {
+					if cmd := m.searchFallback(); cmd != nil {
+						return m, cmd
+					}
+				}
+			}
+			if term := m.textInput.Value(); msg.Offset == 0 && !m.shortQuery() && len(m.results) < sparseResults {
+				return m, wiki.PrefixSearch(term, m.searchType)
+			}
+		}
+
+	case fallbackSearchMsg:
+		return m.showFallbackResults(msg)
+
+	case wiki.PrefixSearchMsg:
+		// Suggestions are a bonus: drop them on failure or once the search has moved on.
+		if msg.Err == nil && m.state == searchResultsView && !m.loading && msg.Term == m.textInput.Value() {
+			m.results = wiki.AddRelated(m.results, msg.Titles)
+			if len(m.results) > 0 {
+				// The query is unchanged, so the input can make way for picking a title.
+				m.textInput.Blur()
+				m.emptySearch = ""
+			}
+		}
+		return m, nil
+
+	case wiki.ArticleLeadMsg:
+		// Without an introduction to show, keep waiting for the full article.
+		if msg.Err != nil || strings.TrimSpace(msg.Content) == "" {
+			return m, nil
 		}
+		m.state = articleView
+		m.partial = true
+		m.articleContent = msg.Content
+		m.sections = nil
+		m.sectionPages = nil
+		m.sectionPaging = false
+		m.resolvedTitle = ""
+		m.displayTitle = ""
+		m.description = ""
+		m.coordinates = nil
+		m.categories = nil
+		m.articleLinks = nil
+		m.clearMatches()
+		m.refreshContent()
+		m.viewport.SetYOffset(0)
 
 	case wiki.ArticleMsg:
+		m.loading = false
+		refreshing := m.refreshing
+		m.refreshing = false
+		partial := m.partial
+		m.partial = false
 		if msg.Err != nil {
-			m.statusMsg = fmt.Sprintf("Error: %v", msg.Err)
+			pageURL := wiki.ArticleURL(m.selectedTitle, m.searchType)
+			if m.offline {
+				pageURL = ""
+			}
+			m.fail(msg.Err, pageURL, false, refreshing)
 		} else {
+			article := msg.Article
+			offset := m.viewport.YOffset
 			m.state = articleView
-			m.articleContent = msg.Content
-			m.urlMatches = m.urlRegex.FindAllStringIndex(m.articleContent, -1)
-			m.statusMsg = fmt.Sprintf("Displaying article: %s", m.selectedTitle)
+			m.returnTo = nil
+			m.articleContent = article.Content
+			m.sections = article.Sections
+			m.revisionID = article.RevID
+			m.fragment = article.Fragment
+			m.resolvedTitle = article.Title
+			m.displayTitle = article.DisplayTitle
+			m.description = article.Description
+			m.coordinates = article.Coordinates
+			shownMode := m.articleMode
+			m.articleMode = article.Mode
+			m.categories = article.Categories
+			m.articleLinks = article.Links
+			m.categoryCursor = 0
+			m.citationOrigin = nil
+			m.sectionPages = buildSectionPages(m.articleContent, m.sections)
+			m.sectionIndex = 0
+			m.sectionPaging = len(m.sectionPages) > 1 && len(m.articleContent) > m.maxArticleLength
+			m.clearMatches()
+			m.cursorLine = 0
+			m.statusMsg = m.verbose(m.selectedTitle, fmt.Sprintf("Displaying article: %s", m.selectedTitle))
+			m.rememberVisit()
 
-			wrappedContent := utils.WrapText(m.articleContent, m.viewport.Width)
-			m.viewport.SetContent(wrappedContent)
+			m.refreshContent()
+			if refreshing {
+				m.viewport.SetYOffset(offset)
+				m.articleNotice = "Article refreshed."
+				if notice := contentModeNotice(shownMode, article.Mode); notice != "" {
+					m.articleNotice = notice
+				}
+			} else if partial && offset > 0 {
+				// Keep the place in the introduction the user has scrolled to.
+				m.viewport.SetYOffset(offset)
+			} else if m.fragment != "" {
+				if m.gotoFragment(m.fragment) {
+					m.articleNotice = m.verbose("§ "+m.fragment, "Redirected to section: "+m.fragment)
+				} else {
+					m.viewport.SetYOffset(0)
+					m.articleNotice = fmt.Sprintf("Redirected to section %q, which could not be found; showing the top of the article.", m.fragment)
+				}
+			} else if m.sectionPaging {
+				m.viewport.SetYOffset(0)
+			} else {
+				m.restorePosition()
+			}
 		}
 	}
 
 	m.viewport, vpCmd = m.viewport.Update(msg)
 	m.textInput, cmd = m.textInput.Update(msg)
+	var categoryCmd tea.Cmd
+	m.categoryInput, categoryCmd = m.categoryInput.Update(msg)
+	var refineCmd tea.Cmd
+	if m.state == refineView {
+		// Keeps the cursor of the field being edited blinking.
+		m.refineInputs[m.refineFocus], refineCmd = m.refineInputs[m.refineFocus].Update(msg)
+	}
+	var headingCmd tea.Cmd
+	if m.state == headingView {
+		m.headingInput, headingCmd = m.headingInput.Update(msg)
+	}
 
-	return m, tea.Batch(cmd, vpCmd)
+	return m, tea.Batch(cmd, vpCmd, categoryCmd, refineCmd, headingCmd)
+}
+
+// renderArticle highlights search matches and links in the rendered article text.
+func (m Model) renderArticle() string {
+	if m.linkHints != nil {
+		return m.renderLinkHints()
+	}
+	styles := m.highlightStyles
+	styles.Focus = m.focusMode
+	styles.HideURLs = m.hideURLs
+	if m.codeStyle != "" {
+		styles.Code = func(code, lang string) string {
+			return utils.HighlightCode(code, lang, m.codeStyle)
+		}
+	}
+	spans := utils.HighlightSpans{
+		Matches:      m.matchSpans,
+		CurrentMatch: m.currentMatchIndex,
+		URLs:         m.urlMatches,
+		CurrentURL:   m.selectedLink - 1,
+		Identifiers:  m.identifierMatches,
+		CodeBlocks:   m.codeBlocks,
+	}
+	// Matches left over from a text that has since been replaced are not drawn.
+	if !m.matchesValid() {
+		spans.Matches = nil
+	}
+	return utils.HighlightText(m.rendered, spans, styles)
 }
 
 // View renders the UI to the terminal.
 func (m Model) View() string {
+	if m.tooSmall() {
+		return utils.WrapText(fmt.Sprintf("Terminal too small (need at least %d×%d)", minWidth, minHeight), m.width)
+	}
 	s := strings.Builder{}
 	mainColor := color.New(color.FgWhite).SprintFunc()
 
 	switch m.state {
+	case errorView:
+		return m.errorViewString()
+
+	case categoryListView:
+		return m.categoryListView()
+
 	case wikiSelectionView:
-		s.WriteString(mainColor("Select a Wiki to Search:\n\n"))
-		for i, wiki := range m.wikiOptions {
+		s.WriteString(mainColor("Select a Wiki to Search:\n"))
+		group := ""
+		for i, name := range m.wikiOptions {
+			source := wiki.LookupSource(name)
+			if source.Group != group {
+				group = source.Group
+				s.WriteString("\n" + color.New(color.Faint).Sprint(group) + "\n")
+			}
 			cursor := " "
 			if i == m.wikiCursor {
 				cursor = color.New(color.Bold, color.FgGreen).Sprint(">")
 			}
-			s.WriteString(fmt.Sprintf("%s %s\n", cursor, mainColor(wiki)))
+			s.WriteString(fmt.Sprintf("%s %s\n", cursor, mainColor(source.Label)))
 		}
-		s.WriteString(mainColor("\n\nPress Enter to select, 'q' to quit."))
+		s.WriteString(mainColor("\n\nPress Enter to select, 's' for offline snapshots, 'q' to quit."))
+
+	case snapshotListView:
+		s.WriteString(mainColor("Offline Snapshots:\n\n"))
+		if len(m.snapshots) == 0 {
+			s.WriteString(mainColor("No snapshots yet. Press 's' while reading an article to save one.\n"))
+		}
+		for i, snapshot := range m.snapshots {
+			cursor := "  "
+			if i == m.snapshotCursor {
+				cursor = color.New(color.Bold, color.FgGreen).Sprint("> ")
+			}
+			saved := color.New(color.Faint).Sprintf("(%s, saved %s)", snapshot.WikiType, snapshot.SavedAt.Format("2006-01-02"))
+			s.WriteString(fmt.Sprintf("%s%s %s\n", cursor, mainColor(m.fitWidth(snapshot.Title, 30)), saved))
+		}
+		s.WriteString("\n")
+		s.WriteString(mainColor(m.statusMsg))
+		s.WriteString(mainColor("\n\nEnter to read, Esc to go back, 'q' to quit."))
+
+	case refineView:
+		s.WriteString(m.refineView())
+
+	case headingView:
+		s.WriteString(m.headingPickerView())
+
+	case splitView:
+		return m.splitViewString()
+
+	case categoryInputView:
+		s.WriteString(m.textInput.View())
+		s.WriteString("\n\n")
+		s.WriteString(m.categoryInput.View())
+		s.WriteString(mainColor("\n\nEnter to apply (empty clears the scope), Esc to cancel."))
 
 	case searchResultsView:
 		s.WriteString(m.textInput.View())
 		s.WriteString("\n\n")
-		s.WriteString(mainColor(m.statusMsg))
+		s.WriteString(m.queryWarnings())
+		s.WriteString(m.shortQueryHint())
+		if m.showRecent() {
+			s.WriteString(mainColor("Recent searches:\n"))
+			for i, term := range m.recentSearches {
+				cursor := "  "
+				if i == m.recentCursor {
+					cursor = color.New(color.Bold, color.FgGreen).Sprint("> ")
+				}
+				s.WriteString(fmt.Sprintf("%s%s\n", cursor, mainColor(m.fitWidth(term, 2))))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString(m.statusView())
 		s.WriteString("\n\n")
-		if len(m.results) > 0 {
-			s.WriteString(mainColor("Search Results:\n"))
+		if m.showOperators {
+			s.WriteString(m.operatorHelp())
+		} else if len(m.results) == 0 && m.emptySearch != "" && !m.loading {
+			s.WriteString(m.noResultsView())
+		} else if len(m.results) > 0 {
+			if m.fallbackFrom != "" {
+				s.WriteString(mainColor(m.fitWidth(fmt.Sprintf("Search Results from %s (fallback, nothing on %s):", m.searchType, m.fallbackFrom), 0) + "\n"))
+			} else {
+				s.WriteString(mainColor("Search Results:\n"))
+			}
 			for i, result := range m.results {
+				if result.Related && (i == 0 || !m.results[i-1].Related) {
+					s.WriteString(color.New(color.Faint).Sprint("\n  Related titles:\n"))
+				}
 				var cursor string
 				if i == m.cursor {
 					cursor = color.New(color.Bold, color.FgGreen).Sprint("> ")
 				} else {
 					cursor = "  "
 				}
-				s.WriteString(fmt.Sprintf("%s%s\n", cursor, mainColor(result.Title)))
+				// The first results are numbered for quick selection with the digit keys.
+				index := "  "
+				if i < maxQuickSelect {
+					index = fmt.Sprintf("%d ", i+1)
+				}
+				title := m.fitWidth(result.Title, 4)
+				s.WriteString(cursor + color.New(color.Faint).Sprint(index) + mainColor(title))
+				if reserved := 4 + utf8.RuneCountInString(title); result.Description != "" && (m.width <= 0 || m.width-reserved > 3) {
+					s.WriteString(color.New(color.Faint).Sprint(m.fitWidth(" – "+result.Description, reserved)))
+				}
+				s.WriteString("\n")
+				if i == m.cursor && result.Extract != "" {
+					s.WriteString(color.New(color.Faint).Sprint("      " + m.fitWidth(strings.Join(strings.Fields(result.Extract), " "), 6)))
+					s.WriteString("\n")
+				} else if i == m.cursor && m.summary != "" && m.summaryFor.title == result.Title {
+					s.WriteString(color.New(color.Faint).Sprint("      " + m.fitWidth(m.summary, 6)))
+					s.WriteString("\n")
+				}
+			}
+			if m.nextOffset > 0 {
+				s.WriteString(color.New(color.Faint).Sprint("  ... more results available, press 'm' to load them\n"))
 			}
 		}
-		s.WriteString(mainColor("\n\nEnter to search/select, Up/Down to navigate, 'o' to open in browser, 'q' to quit."))
+		footer := "Enter to search/select, Up/Down to navigate, 1-9 to open a result, 'ctrl+o' for search operators, 'ctrl+r' to refine, 'o' to open in browser, 's' to change sort, 't' to change search mode, 'c' to set category, 'w' to switch wiki, 'ctrl+l' to clear, 'q' to quit."
+		if m.returnTo != nil {
+			footer = "Esc to return to " + m.fitWidth(m.returnTo.title, 0) + ". " + footer
+		}
+		s.WriteString(mainColor("\n\n" + footer))
 
-	case articleView, searchArticleView:
-		s.WriteString(color.New(color.Bold, color.FgCyan).Sprint(m.selectedTitle))
-		s.WriteString("\n\n")
+	case articleView, searchArticleView, defineInputView, compareInputView:
+		if m.zen && m.state == articleView {
+			m.viewport.SetContent(m.renderArticle())
+			if m.readingCursor {
+				return m.markReadingLine(m.viewport.View())
+			}
+			return m.viewport.View()
+		}
+		s.WriteString(color.New(color.Bold, color.FgCyan).Sprint(m.fitWidth(m.articleTitle(), 0)))
+		s.WriteString("\n")
+		// The short description and coordinates take the place of the blank line under the title.
+		if under := m.subtitle(); under != "" {
+			s.WriteString(color.New(color.Italic, color.Faint).Sprint(m.fitWidth(under, 0)))
+		}
+		s.WriteString("\n")
 		if m.state == searchArticleView {
 			s.WriteString(m.textInput.View())
 			s.WriteString("\n\n")
-			s.WriteString(mainColor("Press Enter to search, Esc to cancel."))
+			s.WriteString(mainColor(fmt.Sprintf("Press Enter to search, Tab to change matching (%s), Esc to cancel.", m.matchMode)))
+		} else if m.state == defineInputView {
+			s.WriteString(m.textInput.View())
+			s.WriteString("\n\n")
+			s.WriteString(mainColor("Press Enter to look the word up on Wiktionary, Esc to cancel."))
+		} else if m.state == compareInputView {
+			s.WriteString(m.textInput.View())
+			s.WriteString("\n\n")
+			s.WriteString(mainColor("Press Enter to open the article next to this one, Esc to cancel."))
 		} else {
-			formattedContent := utils.FormatText(m.articleContent)
-			wrappedContent := utils.WrapText(formattedContent, m.viewport.Width)
-			highlightedContent := utils.HighlightText(wrappedContent, m.searchQuery, m.matchIndexes, m.currentMatchIndex, m.urlMatches)
-			m.viewport.SetContent(highlightedContent)
-			s.WriteString(m.viewport.View())
-			s.WriteString(mainColor("\n\nPress 'esc' to go back, Up/Down to scroll, '/' to search, 'n/p' to jump between matches, 'q' to quit."))
+			m.viewport.SetContent(m.renderArticle())
+			view := m.viewport.View()
+			if len(m.matchSpans) > 0 {
+				view = m.markCurrentMatch(view)
+			}
+			if m.readingCursor {
+				view = m.markReadingLine(view)
+			}
+			if m.definition != nil {
+				view = m.overlayDefinition(view)
+			}
+			if m.debug {
+				view = m.overlayDebug(view)
+			}
+			s.WriteString(view)
+			footer := m.footerView()
+			if m.articleNotice != "" {
+				footer = m.articleNotice
+			} else if m.partial && m.loading {
+				footer = "Showing the introduction while the rest of the article loads (esc to cancel)."
+			}
+			s.WriteString(mainColor("\n\n" + footer))
 		}
 	}
 	return s.String()
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/store"
+	"wiki-search/pkg/wiki"
+)
+
+// waitForCancel is a request that only ends when its context does.
+func waitForCancel(ctx context.Context) tea.Msg {
+	<-ctx.Done()
+	return nil
+}
+
+func TestCancelRequestCancelsContext(t *testing.T) {
+	m := search(t, newTestModel(), "golang")
+	m.startRequest("Searching...", time.Minute, waitForCancel)
+	ctx := m.requestCtx
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("context after esc: %v, want context.Canceled", ctx.Err())
+	}
+	if m.loading {
+		t.Error("still loading after esc")
+	}
+
+	// A request made again, e.g. with 'r', gets a context of its own.
+	m.startRequest(m.lastRequestStatus, m.requestTimeout, m.lastRequest)
+	if m.requestCtx.Err() != nil {
+		t.Errorf("context of the reissued request: %v, want none", m.requestCtx.Err())
+	}
+	// Starting another request abandons the one in flight.
+	previous := m.requestCtx
+	m.startRequest("Searching...", time.Minute, waitForCancel)
+	if previous.Err() != context.Canceled {
+		t.Errorf("context of the replaced request: %v, want context.Canceled", previous.Err())
+	}
+}
+
+func TestClearSearchDropsLateResponse(t *testing.T) {
+	m := search(t, newTestModel(), "golang")
+	m.startRequest("Searching...", time.Minute, waitForCancel)
+	ctx, id := m.requestCtx, m.requestID
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlL})
+	m = updated.(Model)
+	if ctx.Err() != context.Canceled {
+		t.Errorf("context after ctrl+l: %v, want context.Canceled", ctx.Err())
+	}
+
+	updated, _ = m.Update(responseMsg{requestID: id, msg: testResults})
+	m = updated.(Model)
+	if len(m.results) != 0 || m.statusMsg != "" {
+		t.Errorf("after a late response: results %v, status %q; want the list left cleared", m.results, m.statusMsg)
+	}
+}
+
+func TestWantLead(t *testing.T) {
+	wiki.RestoreCache([]wiki.CacheEntry{{
+		WikiType:  "wikipedia",
+		Title:     "Cached article",
+		Article:   wiki.Article{Content: "Text.", Mode: wiki.ContentReadable},
+		FetchedAt: time.Now(),
+	}})
+	m := newTestModel()
+	m.searchType = "wikipedia"
+	m.results = []wiki.SearchResult{{Title: "Stub", Size: 1200}, {Title: "Long read", Size: 180000}, {Title: "Cached article", Size: 90000}}
+	for title, want := range map[string]bool{"Stub": false, "Long read": true, "Cached article": false, "Followed link": true} {
+		if got := m.wantLead(title); got != want {
+			t.Errorf("wantLead(%q) = %v, want %v", title, got, want)
+		}
+	}
+}
+
+func TestOpenedArticleIsRemembered(t *testing.T) {
+	m := open(t)
+	if got := m.VisitedArticles(); got != 1 {
+		t.Fatalf("articles visited after opening one: %d, want 1", got)
+	}
+	var model tea.Model = m
+	for _, msg := range then(keys("esc", "enter"), []tea.Msg{testArticle}) {
+		model, _ = model.Update(msg)
+	}
+	if got := model.(Model).VisitedArticles(); got != 1 {
+		t.Errorf("articles visited after opening the same one again: %d, want 1", got)
+	}
+}
+
+func TestErrorGuidanceForTLSProtocolError(t *testing.T) {
+	guidance := errorGuidance(&wiki.TLSProtocolError{Host: "en.wikipedia.org", Err: errors.New("first record does not look like a TLS handshake")})
+	if strings.Contains(guidance, "-insecure-tls") || strings.Contains(guidance, "certificate") {
+		t.Errorf("guidance for a protocol error suggests skipping certificate checks: %q", guidance)
+	}
+}
+
+// response runs cmd, and the commands of any batch it returns, and returns the first response
+// to a request among their messages.
+func response(t *testing.T, cmd tea.Cmd) responseMsg {
+	t.Helper()
+	msgs := make(chan tea.Msg, 16)
+	var run func(tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		go func() {
+			msg := cmd()
+			if batch, ok := msg.(tea.BatchMsg); ok {
+				for _, cmd := range batch {
+					run(cmd)
+				}
+				return
+			}
+			msgs <- msg
+		}()
+	}
+	run(cmd)
+	timeout := time.After(500 * time.Millisecond)
+	for {
+		select {
+		case msg := <-msgs:
+			if response, ok := msg.(responseMsg); ok {
+				return response
+			}
+		case <-timeout:
+			t.Fatal("no response to the request")
+		}
+	}
+}
+
+func TestRetryReissuesFailedRequest(t *testing.T) {
+	calls := 0
+	flaky := func(context.Context) tea.Msg {
+		calls++
+		if calls == 1 {
+			return wiki.SearchMsg{Err: errors.New("search timed out")}
+		}
+		return testResults
+	}
+	m := search(t, newTestModel(), "golang")
+	cmd := m.startRequest("Searching...", time.Minute, flaky)
+	updated, _ := m.Update(response(t, cmd))
+	m = updated.(Model)
+	if m.state != errorView || m.failure == nil {
+		t.Fatalf("state after a failed search: %v, want the error view", m.state)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(Model)
+	if m.state != searchResultsView || !m.loading || m.failure != nil {
+		t.Fatalf("after 'r': state %v, loading %v, failure %v; want the request in flight again", m.state, m.loading, m.failure)
+	}
+	updated, _ = m.Update(response(t, cmd))
+	m = updated.(Model)
+	if calls != 2 {
+		t.Errorf("request made %d times, want 2", calls)
+	}
+	if m.state != searchResultsView || len(m.results) != len(testResults.Results) || m.failure != nil || m.lastRequest != nil {
+		t.Errorf("after the retry succeeded: state %v, %d results, failure %v", m.state, len(m.results), m.failure)
+	}
+	if strings.HasPrefix(m.statusMsg, "Error:") {
+		t.Errorf("status still shows the error: %q", m.statusMsg)
+	}
+}
+
+func TestArticleMsgFillsArticleView(t *testing.T) {
+	article := testArticle.Article
+	article.Title = "Go (programming language)"
+	article.DisplayTitle = "Go"
+	article.RevID = 1234
+	article.Categories = []string{"Programming languages"}
+	article.Description = "Programming language"
+	article.Coordinates = &wiki.Coordinates{Lat: 37.42, Lon: -122.08}
+	var model tea.Model = newTestModel()
+	for _, msg := range then(toResults, keys("enter"), []tea.Msg{wiki.ArticleMsg{Article: article}}) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if m.state != articleView {
+		t.Fatalf("state = %v, want the article view", m.state)
+	}
+	if got := m.currentArticle(); !reflect.DeepEqual(got, article) {
+		t.Errorf("article view holds %+v\nwant %+v", got, article)
+	}
+	if view := m.View(); !strings.Contains(view, "Programming language") {
+		t.Errorf("description not shown:\n%s", view)
+	}
+}
+
+func TestFlushAfterQuit(t *testing.T) {
+	var paragraphs []string
+	for i := range 40 {
+		paragraphs = append(paragraphs, fmt.Sprintf("Paragraph %d of a long article.", i+1))
+	}
+	article := wiki.ArticleMsg{Article: wiki.Article{Title: testArticle.Title, Content: strings.Join(paragraphs, "\n\n"), Mode: wiki.ContentReadable}}
+
+	for _, ephemeral := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ephemeral=%v", ephemeral), func(t *testing.T) {
+			base := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", base)
+			t.Setenv("HOME", base)
+			m := newTestModel()
+			m.ephemeral = ephemeral
+			var model tea.Model = m
+			for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+				model, _ = model.Update(msg)
+			}
+			m = model.(Model)
+			m.viewport.SetYOffset(m.viewport.TotalLineCount() / 2)
+			read := m.viewport.ScrollPercent()
+			model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+			if cmd == nil || cmd() != tea.Quit() {
+				t.Fatal("q did not quit")
+			}
+			if err := model.(Model).Flush(); err != nil {
+				t.Fatal(err)
+			}
+			percent, ok := store.LoadPosition(m.searchType, m.selectedTitle)
+			if ephemeral {
+				if ok {
+					t.Error("Flush wrote the position of a model without persistence")
+				}
+				return
+			}
+			if !ok || math.Abs(percent-read) > 0.01 {
+				t.Errorf("position after Flush = %.2f, %v; want %.2f", percent, ok, read)
+			}
+		})
+	}
+}
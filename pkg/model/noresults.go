@@ -0,0 +1,62 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// noResultsView explains that the last search found nothing and suggests what to try next:
+// the wiki's spelling suggestion, another wiki and ways to broaden the query.
+func (m Model) noResultsView() string {
+	faint := color.New(color.Faint)
+	key := color.New(color.FgCyan)
+	s := strings.Builder{}
+	s.WriteString(color.New(color.FgWhite).Sprint(m.fitWidth(fmt.Sprintf("No articles on %s match %q.",
+		m.searchType, utils.TruncateRunes(m.emptySearch, maxQueryEcho)), 0)))
+	s.WriteString("\n\n")
+	if m.suggestion != "" {
+		s.WriteString(fmt.Sprintf("  Did you mean %s? Press %s to search for it.\n",
+			color.New(color.FgGreen).Sprintf("%q", m.suggestion), key.Sprint("Tab")))
+	}
+	if len(m.wikiOptions) > 1 {
+		next := m.wikiOptions[(m.wikiCursor+1)%len(m.wikiOptions)]
+		s.WriteString(fmt.Sprintf("  Press %s to search %s instead.\n", key.Sprint("Ctrl+n"), next))
+	}
+	if m.searchWhat != wiki.SearchText || m.category != "" || m.namespace != 0 {
+		s.WriteString(fmt.Sprintf("  Press %s to search the full text of all articles.\n", key.Sprint("Ctrl+b")))
+	}
+	s.WriteString(faint.Sprint("\n  Or broaden the query: use fewer or more general words, check the spelling,\n" +
+		"  and drop quotes and operators such as intitle:.\n"))
+	return s.String()
+}
+
+// searchSuggestion searches for the wiki's spelling suggestion in place of the query.
+func (m *Model) searchSuggestion() tea.Cmd {
+	m.textInput.SetValue(m.suggestion)
+	m.textInput.CursorEnd()
+	return m.searchAgain()
+}
+
+// broadenSearch drops the title-only mode, category scope and namespace and searches again.
+func (m *Model) broadenSearch() tea.Cmd {
+	m.searchWhat = wiki.SearchText
+	m.category = ""
+	m.namespace = 0
+	return m.searchAgain()
+}
+
+// searchAgain runs the query in the input with the current settings.
+func (m *Model) searchAgain() tea.Cmd {
+	m.leaveFallback()
+	m.emptySearch = ""
+	m.suggestion = ""
+	m.rememberSearch(m.textInput.Value())
+	return m.startRequest(m.verbose("Searching...", fmt.Sprintf("Searching (%s)...", m.searchSettings())), wiki.SearchTimeout,
+		wiki.PerformSearch(m.textInput.Value(), m.searchType, m.searchOptions()))
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestNoResultsThenSearchAgain(t *testing.T) {
+	empty := wiki.SearchMsg{Suggestion: "gopher"}
+	tests := []struct {
+		name  string
+		key   tea.KeyMsg
+		query string
+	}{
+		{"broaden", tea.KeyMsg{Type: tea.KeyCtrlB}, "gophr"},
+		{"suggestion", tea.KeyMsg{Type: tea.KeyTab}, "gopher"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel()
+			m.searchWhat = wiki.SearchTitle
+			m = search(t, m, "gophr")
+			updated, _ := m.Update(empty)
+			m = updated.(Model)
+			view := m.View()
+			for _, want := range []string{`No articles on wikipedia match "gophr".`, `Did you mean "gopher"?`, "Ctrl+b"} {
+				if !strings.Contains(view, want) {
+					t.Errorf("no-results view lacks %q:\n%s", want, view)
+				}
+			}
+
+			updated, cmd := m.Update(tt.key)
+			m = updated.(Model)
+			if cmd == nil || !m.loading || m.emptySearch != "" {
+				t.Fatalf("%s didn't search again: loading = %v, emptySearch = %q", tt.name, m.loading, m.emptySearch)
+			}
+			if got := m.textInput.Value(); got != tt.query {
+				t.Errorf("searched for %q, want %q", got, tt.query)
+			}
+			if tt.name == "broaden" && m.searchWhat != wiki.SearchText {
+				t.Errorf("broadened search still has mode %q", m.searchWhat)
+			}
+
+			updated, _ = m.Update(testResults)
+			m = updated.(Model)
+			view = m.View()
+			if strings.Contains(view, "No articles on") || !strings.Contains(view, "Go (game)") {
+				t.Errorf("results not shown after searching again:\n%s", view)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/wiki"
+)
+
+// queryWarnings renders the syntax warnings for the query being typed, if there are any.
+func (m Model) queryWarnings() string {
+	warnings := wiki.CheckQuery(m.textInput.Value())
+	if len(warnings) == 0 {
+		return ""
+	}
+	return color.New(color.FgYellow).Sprint(m.fitWidth("Check the query: "+strings.Join(warnings, "; "), 0)) + "\n\n"
+}
+
+// operatorHelp lists the search operators, shown with ctrl+o on the results screen.
+func (m Model) operatorHelp() string {
+	s := strings.Builder{}
+	s.WriteString(color.New(color.FgWhite).Sprint("Search operators:\n\n"))
+	width := 0
+	for _, op := range wiki.Operators {
+		width = max(width, len([]rune(op.Example)))
+	}
+	for _, op := range wiki.Operators {
+		example := color.New(color.FgCyan).Sprint(op.Example + strings.Repeat(" ", width-len([]rune(op.Example))))
+		s.WriteString(fmt.Sprintf("  %s  %s\n", example, color.New(color.Faint).Sprint(m.fitWidth(op.Help, width+4))))
+	}
+	s.WriteString(color.New(color.Faint).Sprint("\n  Press any key to close.\n"))
+	return s.String()
+}
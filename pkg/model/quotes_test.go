@@ -0,0 +1,83 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// quoteArticle has a quotation, longer than the window is wide, between prose.
+var quoteArticle = wiki.ArticleMsg{Article: wiki.Article{
+	Title: "Linux",
+	Content: "Torvalds announced the project in 1991:\n\n" +
+		"```" + utils.QuoteLang + "\nI'm doing a (free) operating system (just a hobby, won't be big and professional like gnu) for 386(486) AT clones.\n— Linus Torvalds\n```\n\n" +
+		"The kernel was released later that year.",
+	Mode: wiki.ContentReadable,
+}}
+
+// openQuote opens quoteArticle in a window 50 columns wide.
+func openQuote(opts ...Option) Model {
+	var model tea.Model = newTestModel(opts...)
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 50, Height: 24}}, toResults, keys("enter"), []tea.Msg{quoteArticle}) {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestQuoteIndented(t *testing.T) {
+	m := openQuote()
+	if len(m.codeBlocks) != 1 || m.codeBlocks[0].Lang != utils.QuoteLang {
+		t.Fatalf("code blocks %+v, want the quotation located", m.codeBlocks)
+	}
+	quote := m.rendered[m.codeBlocks[0].Start:m.codeBlocks[0].End]
+	lines := strings.Split(quote, "\n")
+	if len(lines) < 4 {
+		t.Errorf("quotation not wrapped:\n%s", quote)
+	}
+	// Every line of it, the wrapped ones too, is behind the border and fits the window.
+	for _, line := range lines {
+		if !strings.HasPrefix(line, quoteBorder) {
+			t.Errorf("line %q not indented behind the border", line)
+		}
+		if w := len([]rune(line)); w > m.viewport.Width {
+			t.Errorf("line %q is %d columns wide, more than the window", line, w)
+		}
+	}
+	if last := lines[len(lines)-1]; last != quoteBorder+"— Linus Torvalds" {
+		t.Errorf("last line %q, want the attribution on its own", last)
+	}
+	if strings.Contains(m.rendered, "```") {
+		t.Errorf("fences shown:\n%s", m.rendered)
+	}
+	// The prose around it isn't indented.
+	if !strings.HasPrefix(m.rendered, "Torvalds announced") || !strings.Contains(m.rendered, "\nThe kernel was released") {
+		t.Errorf("prose indented:\n%s", m.rendered)
+	}
+
+	// Searching finds text inside the quotation.
+	m = find(t, m, "hobby")
+	if got := m.rendered[m.matchSpans[0][0]:m.matchSpans[0][1]]; got != "hobby" || m.matchSpans[0][0] < m.codeBlocks[0].Start {
+		t.Errorf("match %q at %d, want hobby in the quotation", got, m.matchSpans[0][0])
+	}
+}
+
+func TestQuoteStyled(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	m := openQuote()
+	rendered := m.renderArticle()
+	quote := utils.DefaultHighlightStyles().Quote
+	if want := quote.Sprint(quoteBorder + "— Linus Torvalds"); !strings.Contains(rendered, want) {
+		t.Errorf("attribution not drawn in the quote style:\n%q", rendered)
+	}
+	if strings.Contains(rendered, quote.Sprint("The kernel")) {
+		t.Errorf("prose drawn in the quote style:\n%q", rendered)
+	}
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// Fields of the refine search form, in order.
+const (
+	refineText = iota
+	refineTitle
+	refineCategory
+	refineNamespace
+	refineFields
+)
+
+// refineLabels name the fields of the refine search form.
+var refineLabels = [refineFields]string{"Text", "In title", "Category", "Namespace"}
+
+// startRefine opens the refine search form, filled in from the current search.
+func (m *Model) startRefine() tea.Cmd {
+	placeholders := [refineFields]string{
+		"words to search for",
+		"words the title must contain",
+		"e.g. Networking",
+		"e.g. Talk, Help or 12 (empty searches articles)",
+	}
+	m.refineInputs = make([]textinput.Model, refineFields)
+	for i := range m.refineInputs {
+		input := textinput.New()
+		input.Prompt = fmt.Sprintf("%-10s ", refineLabels[i]+":")
+		input.Placeholder = placeholders[i]
+		input.CharLimit = m.textInput.CharLimit
+		input.Width = m.textInput.Width
+		m.refineInputs[i] = input
+	}
+	m.refineInputs[refineText].SetValue(m.textInput.Value())
+	m.refineInputs[refineCategory].SetValue(m.category)
+	if m.namespace != 0 {
+		m.refineInputs[refineNamespace].SetValue(wiki.NamespaceName(m.namespace))
+	}
+	m.refineFocus = refineText
+	m.state = refineView
+	m.textInput.Blur()
+	return m.refineInputs[m.refineFocus].Focus()
+}
+
+// refinement collects the fields of the refine search form.
+func (m Model) refinement() wiki.Refinement {
+	return wiki.Refinement{
+		Text:      m.refineInputs[refineText].Value(),
+		Title:     m.refineInputs[refineTitle].Value(),
+		Category:  m.refineInputs[refineCategory].Value(),
+		Namespace: m.refineInputs[refineNamespace].Value(),
+	}
+}
+
+// updateRefine handles a key in the refine search form.
+func (m *Model) updateRefine(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.state = searchResultsView
+		m.refineInputs = nil
+		return nil
+	case "tab", "down":
+		return m.focusRefineField(m.refineFocus + 1)
+	case "shift+tab", "up":
+		return m.focusRefineField(m.refineFocus - 1)
+	case "enter":
+		term, category, namespace, err := m.refinement().Compose()
+		if err != nil {
+			// The form shows the error; keep it open to fix the fields.
+			return nil
+		}
+		m.state = searchResultsView
+		m.refineInputs = nil
+		m.textInput.SetValue(term)
+		m.textInput.CursorEnd()
+		m.category = category
+		m.namespace = namespace
+		m.emptySearch = ""
+		m.rememberSearch(term)
+		return m.startRequest(m.verbose("Searching...", fmt.Sprintf("Searching (%s)...", m.searchSettings())), wiki.SearchTimeout,
+			wiki.PerformSearch(term, m.searchType, m.searchOptions()))
+	}
+	if msg.Paste {
+		msg.Runes = []rune(utils.SingleLine(string(msg.Runes)))
+	}
+	var cmd tea.Cmd
+	m.refineInputs[m.refineFocus], cmd = m.refineInputs[m.refineFocus].Update(msg)
+	return cmd
+}
+
+// focusRefineField moves the focus to field i of the refine search form, wrapping around.
+func (m *Model) focusRefineField(i int) tea.Cmd {
+	m.refineInputs[m.refineFocus].Blur()
+	m.refineFocus = (i + refineFields) % refineFields
+	return m.refineInputs[m.refineFocus].Focus()
+}
+
+// refineView renders the refine search form with the query it composes, so the operator
+// syntax can be learned by watching it change.
+func (m Model) refineView() string {
+	s := strings.Builder{}
+	s.WriteString(color.New(color.FgWhite).Sprint(fmt.Sprintf("Refine the search on %s:\n\n", m.searchType)))
+	for _, input := range m.refineInputs {
+		s.WriteString(input.View() + "\n")
+	}
+	s.WriteString("\n")
+	term, category, namespace, err := m.refinement().Compose()
+	if err != nil {
+		s.WriteString(color.New(color.FgYellow).Sprint(m.fitWidth(err.Error(), 0)))
+	} else {
+		query := wiki.ComposeSearch(term, category)
+		if namespace != 0 {
+			query += fmt.Sprintf("  (namespace: %s)", wiki.NamespaceName(namespace))
+		}
+		s.WriteString("Query: " + color.New(color.FgCyan).Sprint(m.fitWidth(query, len("Query: "))))
+	}
+	s.WriteString(color.New(color.FgWhite).Sprint("\n\nTab/Shift+Tab to move between fields, Enter to search, Esc to cancel."))
+	return s.String()
+}
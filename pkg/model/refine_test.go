@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRefineForm(t *testing.T) {
+	tab := []tea.Msg{tea.KeyMsg{Type: tea.KeyTab}}
+	var model tea.Model = search(t, newTestModel(), "setup")
+	for _, msg := range then([]tea.Msg{testResults, tea.KeyMsg{Type: tea.KeyCtrlR}}, tab, keys("Wi-Fi"), tab, keys("Networking"), tab, keys("Help")) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if m.state != refineView {
+		t.Fatalf("state %v, want the refine form", m.state)
+	}
+	// The form shows the query it composes, to teach the syntax.
+	view := Render(m, 100, 24)
+	if !strings.Contains(view, `Query: incategory:"Networking" intitle:"Wi-Fi" setup  (namespace: Help)`) {
+		t.Errorf("composed query not shown:\n%s", view)
+	}
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	if cmd == nil || !m.loading || m.state != searchResultsView {
+		t.Fatal("Enter didn't run the refined search")
+	}
+	if got := m.textInput.Value(); got != `intitle:"Wi-Fi" setup` {
+		t.Errorf("query %q, want the text with the title operator", got)
+	}
+	if opts := m.searchOptions(); opts.Category != "Networking" || opts.Namespace != 12 {
+		t.Errorf("search options %+v, want the category and the Help namespace", opts)
+	}
+
+	// Reopening the form starts from the search it ran.
+	model, _ = m.Update(testResults)
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = model.(Model)
+	if got := m.refinement(); got.Text != `intitle:"Wi-Fi" setup` || got.Category != "Networking" || got.Namespace != "Help" {
+		t.Errorf("form reopened with %+v", got)
+	}
+}
+
+func TestRefineFormInvalid(t *testing.T) {
+	var model tea.Model = search(t, newTestModel(), "setup")
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	for _, msg := range then([]tea.Msg{testResults, tea.KeyMsg{Type: tea.KeyCtrlR}, tab, tab, tab}, keys("Gadgets")) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if view := Render(m, 100, 24); !strings.Contains(view, `unknown namespace "gadgets"`) {
+		t.Errorf("invalid namespace not reported:\n%s", view)
+	}
+	// Enter keeps the form open to fix the field, and Esc leaves the search as it was.
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m = model.(Model); cmd != nil || m.state != refineView {
+		t.Error("Enter ran a search with an invalid field")
+	}
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = model.(Model); m.state != searchResultsView || m.textInput.Value() != "setup" || m.namespace != 0 {
+		t.Errorf("after Esc: state %v, query %q, namespace %d; want the search unchanged", m.state, m.textInput.Value(), m.namespace)
+	}
+}
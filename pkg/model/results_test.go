@@ -0,0 +1,86 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestLoadMoreResults(t *testing.T) {
+	firstPage := wiki.SearchMsg{
+		Results:    []wiki.SearchResult{{Title: "Pacman"}, {Title: "Pacman/Tips and tricks"}},
+		TotalHits:  3,
+		NextOffset: 2,
+	}
+	lastPage := wiki.SearchMsg{Results: []wiki.SearchResult{{Title: "Pacman/Package signing"}}, TotalHits: 3, Offset: 2}
+	var model tea.Model = search(t, newTestModel(WithResultsPerPage(2)), "pacman")
+	model, _ = model.Update(firstPage)
+	m := model.(Model)
+	if !strings.HasPrefix(m.statusMsg, "Showing 2 of 3 results") {
+		t.Errorf("status %q, want it to show 2 of 3 results", m.statusMsg)
+	}
+	if view := Render(m, 80, 24); !strings.Contains(view, "more results available") {
+		t.Errorf("view does not offer more results:\n%s", view)
+	}
+
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	if m = model.(Model); cmd == nil || !m.loading {
+		t.Fatal("'m' did not load more results")
+	}
+	model, _ = m.Update(lastPage)
+	m = model.(Model)
+	if len(m.results) != 3 || m.results[2].Title != "Pacman/Package signing" {
+		t.Errorf("results %v, want the last page added", m.results)
+	}
+	if !strings.HasPrefix(m.statusMsg, "Found 3 results") {
+		t.Errorf("status %q, want all 3 results found", m.statusMsg)
+	}
+	if view := Render(m, 80, 24); strings.Contains(view, "more results available") {
+		t.Errorf("view offers more results after the last page:\n%s", view)
+	}
+}
+
+func TestRelatedTitles(t *testing.T) {
+	// found lists titles with their extracts, so no summaries are scheduled for them.
+	found := func(titles ...string) wiki.SearchMsg {
+		msg := wiki.SearchMsg{TotalHits: len(titles)}
+		for _, title := range titles {
+			msg.Results = append(msg.Results, wiki.SearchResult{Title: title, Extract: title + " is a thing."})
+		}
+		return msg
+	}
+	m := search(t, newTestModel(), "golang")
+	if _, cmd := m.Update(found("Go", "Go (game)", "Go!", "Golang", "Gopher")); cmd != nil {
+		t.Error("related titles looked up for a search with enough results")
+	}
+	model, cmd := m.Update(found("Go (programming language)", "Go (game)", "Go, Went, Gone"))
+	if cmd == nil {
+		t.Fatal("no related titles looked up for a search with few results")
+	}
+
+	// Suggestions for another query are dropped.
+	model, _ = model.Update(wiki.PrefixSearchMsg{Term: "rust", Titles: []string{"Rust"}})
+	if m = model.(Model); len(m.results) != 3 {
+		t.Errorf("results %v, want suggestions for another query dropped", m.results)
+	}
+
+	model, _ = model.Update(wiki.PrefixSearchMsg{Term: "golang", Titles: []string{"Go (game)", "Golang", "Gopher"}})
+	m = model.(Model)
+	var got []string
+	for _, result := range m.results {
+		if result.Related {
+			got = append(got, result.Title)
+		}
+	}
+	if want := []string{"Golang", "Gopher"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("related titles %v, want %v", got, want)
+	}
+	view := Render(m, 80, 24)
+	if i := strings.Index(view, "Related titles:"); i < 0 || i < strings.Index(view, "Go, Went, Gone") || i > strings.Index(view, "Golang") {
+		t.Errorf("related titles not listed apart after the results:\n%s", view)
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+// WithScrollStep sets how many lines j/k and the arrow keys scroll an article, and the
+// fraction of the page ctrl+u/ctrl+d scroll it by.
+func WithScrollStep(lines int, pageFraction float64) Option {
+	return func(m *Model) {
+		m.scrollStep = lines
+		m.pageFraction = pageFraction
+	}
+}
+
+// scrollLines scrolls the article by delta steps of scrollStep lines; negative is up.
+func (m *Model) scrollLines(delta int) {
+	m.scrollBy(delta * m.scrollStep)
+}
+
+// scrollPage scrolls the article by pageFraction of the viewport's height, and at least one
+// line, in the direction of delta.
+func (m *Model) scrollPage(delta int) {
+	m.scrollBy(delta * max(1, int(float64(m.viewport.Height)*m.pageFraction)))
+}
+
+// scrollBy scrolls the article by n lines; negative is up.
+func (m *Model) scrollBy(n int) {
+	if n < 0 {
+		m.viewport.ScrollUp(-n)
+	} else {
+		m.viewport.ScrollDown(n)
+	}
+}
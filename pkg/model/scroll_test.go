@@ -0,0 +1,52 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestScrollStep(t *testing.T) {
+	var lines []string
+	for i := range 200 {
+		lines = append(lines, fmt.Sprintf("Line %d.", i+1))
+	}
+	article := wiki.ArticleMsg{Article: wiki.Article{Title: "Long", Content: strings.Join(lines, "\n"), Mode: wiki.ContentReadable}}
+	tests := []struct {
+		step     int
+		fraction float64
+		key      tea.KeyMsg
+		want     int
+	}{
+		{1, 0.5, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}, 1},
+		{3, 0.5, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}, 3},
+		{3, 0.5, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}, -3},
+		{3, 0.5, tea.KeyMsg{Type: tea.KeyDown}, 3},
+		{3, 0.5, tea.KeyMsg{Type: tea.KeyUp}, -3},
+		{1, 0.5, tea.KeyMsg{Type: tea.KeyCtrlD}, 10},
+		{1, 0.25, tea.KeyMsg{Type: tea.KeyCtrlD}, 5},
+		{1, 0.25, tea.KeyMsg{Type: tea.KeyCtrlU}, -5},
+		{1, 1, tea.KeyMsg{Type: tea.KeyCtrlD}, 20},
+		// However small the fraction, a page key scrolls at least a line.
+		{1, 0.01, tea.KeyMsg{Type: tea.KeyCtrlD}, 1},
+	}
+	for _, tt := range tests {
+		var model tea.Model = newTestModel(WithScrollStep(tt.step, tt.fraction))
+		for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+			model, _ = model.Update(msg)
+		}
+		m := model.(Model)
+		if m.viewport.Height != 20 {
+			t.Fatalf("viewport is %d lines high, want 20", m.viewport.Height)
+		}
+		m.viewport.SetYOffset(50)
+		model, _ = m.Update(tt.key)
+		if got := model.(Model).viewport.YOffset - 50; got != tt.want {
+			t.Errorf("step %d, fraction %g: %s scrolled %d lines, want %d", tt.step, tt.fraction, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// sectionPage is a part of the article that is shown on its own when paging by section.
+type sectionPage struct {
+	title string
+	// number is the section number, shown with numbered headings; empty for the introduction.
+	number     string
+	start, end int
+}
+
+// WithMaxArticleLength sets the article size, in bytes, above which articles are shown one
+// section at a time. Smaller articles can still be paged with the section keys.
+func WithMaxArticleLength(n int) Option {
+	return func(m *Model) {
+		m.maxArticleLength = n
+	}
+}
+
+// WithCodeStyle highlights code blocks with the named chroma style, e.g. "monokai".
+func WithCodeStyle(style string) Option {
+	return func(m *Model) {
+		m.codeStyle = style
+	}
+}
+
+// WithNumberedHeadings prefixes section headings with their hierarchical numbers, e.g.
+// "1.2 Early years".
+func WithNumberedHeadings() Option {
+	return func(m *Model) {
+		m.numberedHeadings = true
+	}
+}
+
+// WithNormalizedTypography shows curly quotes, dashes and other typographic characters as
+// their ASCII equivalents, which searching with '/' then finds.
+func WithNormalizedTypography() Option {
+	return func(m *Model) {
+		m.normalizeTypography = true
+	}
+}
+
+// headingText returns a heading as the article text shows it, with its typography normalized
+// if the text's is, so it reads the same and a filter typed either way finds it.
+func (m Model) headingText(title string) string {
+	if m.normalizeTypography {
+		return utils.NormalizeTypography(title)
+	}
+	return title
+}
+
+// buildSectionPages splits an article into its lead and top-level sections.
+// It returns nil when the article has no locatable sections.
+func buildSectionPages(content string, sections []wiki.Section) []sectionPage {
+	offsets := wiki.SectionOffsets(content, sections)
+	numbered := wiki.NumberSections(sections)
+	topLevel := 0
+	for i, section := range sections {
+		if depth := section.Depth(); offsets[i] >= 0 && depth > 0 && (topLevel == 0 || depth < topLevel) {
+			topLevel = depth
+		}
+	}
+
+	pages := []sectionPage{{title: "Introduction"}}
+	for i, section := range sections {
+		if offsets[i] < 0 || section.Depth() != topLevel {
+			continue
+		}
+		pages[len(pages)-1].end = offsets[i]
+		pages = append(pages, sectionPage{title: section.Title(), number: numbered[i].Number, start: offsets[i]})
+	}
+	pages[len(pages)-1].end = len(content)
+
+	nonEmpty := pages[:0]
+	for _, page := range pages {
+		if strings.TrimSpace(content[page.start:page.end]) != "" {
+			nonEmpty = append(nonEmpty, page)
+		}
+	}
+	if len(nonEmpty) < 2 {
+		return nil
+	}
+	return nonEmpty
+}
+
+// displayContent returns the part of the article currently shown in the viewport.
+func (m Model) displayContent() string {
+	if m.sectionPaging && m.sectionIndex < len(m.sectionPages) {
+		page := m.sectionPages[m.sectionIndex]
+		return m.articleContent[page.start:page.end]
+	}
+	return m.articleContent
+}
+
+// refreshContent re-renders the displayed text, recomputing link and search matches so their
+// offsets line up with the wrapped lines, after the text or the viewport width changes.
+func (m *Model) refreshContent() {
+	var urls [][]int
+	m.rendered, m.codeBlocks, urls = m.renderBlocks(m.displayContent())
+	if !m.keepCitations && strings.TrimSpace(m.rendered) == "" && strings.TrimSpace(m.displayContent()) != "" {
+		// Cleaning left nothing, e.g. of a page of only citation markers. A blank screen would
+		// look like a failed fetch, so show the text as it came instead.
+		uncleaned := *m
+		uncleaned.keepCitations = true
+		m.rendered, m.codeBlocks, urls = uncleaned.renderBlocks(m.displayContent())
+		m.articleNotice = m.verbose("Shown uncleaned", "Nothing is left of this text once citation markers are removed, so it is shown as is.")
+	}
+	m.urlMatches, m.identifierMatches = m.findLinks(m.rendered, urls)
+	// Link hints and the selected link point into the previous rendering.
+	m.linkHints = nil
+	m.selectedLink = 0
+	if len(m.matchSpans) > 0 {
+		m.findMatches()
+		m.currentMatchIndex = min(m.currentMatchIndex, max(0, len(m.matchSpans)-1))
+	}
+	m.viewport.SetContent(m.rendered)
+}
+
+// render formats and wraps text for the viewport.
+func (m Model) render(text string) string {
+	rendered, _, _ := m.renderBlocks(text)
+	return rendered
+}
+
+// renderBlocks is render, also returning where the code blocks and quotations ended up, and
+// the spans of the addresses in the text. Code is kept verbatim instead of being formatted and
+// wrapped like the prose around it, tables are laid out in columns that fit the viewport and
+// quotations are indented behind a border.
+func (m Model) renderBlocks(text string) (string, []utils.CodeBlock, [][]int) {
+	if m.numberedHeadings {
+		text = numberHeadings(text, m.sections)
+	}
+	if m.normalizeTypography {
+		// After numbering, which finds the headings as the wiki wrote them.
+		text = utils.NormalizeTypography(text)
+	}
+	var sb strings.Builder
+	var codeBlocks []utils.CodeBlock
+	var urls [][]int
+	// add appends rendered text, whose addresses are at spans, to the output.
+	add := func(rendered string, spans [][]int) {
+		for _, span := range spans {
+			urls = append(urls, []int{sb.Len() + span[0], sb.Len() + span[1]})
+		}
+		sb.WriteString(rendered)
+	}
+	for _, block := range utils.SplitCodeBlocks(text) {
+		if block.Code && block.Lang == utils.TableLang {
+			table := utils.ParseTable(block.Text)
+			if !m.keepCitations {
+				table = table.Map(utils.StripCitations)
+			}
+			rendered := utils.RenderTable(table, m.textWidth()) + "\n"
+			add(rendered, m.urlRegex.FindAllStringIndex(rendered, -1))
+			continue
+		}
+		if block.Code && block.Lang == utils.QuoteLang {
+			start := sb.Len()
+			add(m.renderQuote(block.Text))
+			codeBlocks = append(codeBlocks, utils.CodeBlock{Start: start, End: sb.Len(), Lang: block.Lang})
+			sb.WriteString("\n\n")
+			continue
+		}
+		if block.Code {
+			start := sb.Len()
+			code := utils.ExpandTabs(block.Text)
+			add(code, m.urlRegex.FindAllStringIndex(code, -1))
+			codeBlocks = append(codeBlocks, utils.CodeBlock{Start: start, End: sb.Len(), Lang: block.Lang})
+			// Set the code off from the text after it, as wrapped prose is.
+			sb.WriteString("\n\n")
+			continue
+		}
+		add(m.renderProse(block.Text))
+	}
+	return sb.String(), codeBlocks, urls
+}
+
+// renderProse formats and wraps article text other than code, also returning the spans of
+// its addresses.
+func (m Model) renderProse(text string) (string, [][]int) {
+	return m.wrapProse(text, m.textWidth())
+}
+
+// wrapProse formats text and wraps it to width, also returning the spans of its addresses.
+// They are found before wrapping, which breaks addresses longer than the width across lines.
+func (m Model) wrapProse(text string, width int) (string, [][]int) {
+	if !m.keepCitations {
+		text = utils.StripCitations(text)
+	}
+	formatted := utils.FormatText(text)
+	wrap := utils.WrapText
+	if m.justify {
+		wrap = utils.JustifyText
+	}
+	wrapped := wrap(formatted, width)
+	return wrapped, utils.MapSpans(formatted, wrapped, m.urlRegex.FindAllStringIndex(formatted, -1))
+}
+
+// quoteBorder sets off the lines of a quotation.
+const quoteBorder = "│ "
+
+// renderQuote wraps a quotation to fit behind its border, so every line of it, including
+// the continuation lines of wrapped paragraphs, is indented. The last line ends without a
+// newline, leaving the quotation's end to the caller. The spans of its addresses are returned
+// too.
+func (m Model) renderQuote(text string) (string, [][]int) {
+	border := ansi.StringWidth(quoteBorder)
+	wrapped, urls := m.wrapProse(strings.TrimSpace(text), max(1, m.textWidth()-border))
+	wrapped = strings.TrimRight(wrapped, "\n")
+	lines := strings.Split(wrapped, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(quoteBorder+line, " ")
+	}
+	quote := strings.Join(lines, "\n")
+	return quote, utils.MapSpans(wrapped, quote, urls)
+}
+
+// numberHeadings prefixes the section headings found in text with their section numbers.
+// text may be part of the article, such as a section page; headings outside it are skipped.
+func numberHeadings(text string, sections []wiki.Section) string {
+	numbered := wiki.NumberSections(sections)
+	var sb strings.Builder
+	last := 0
+	for i, offset := range wiki.SectionOffsets(text, sections) {
+		if offset < 0 || numbered[i].Number == "" {
+			continue
+		}
+		// Offsets point at the start of the heading's line; number the heading text itself.
+		offset += len(text[offset:]) - len(strings.TrimLeft(text[offset:], " \t"))
+		sb.WriteString(text[last:offset])
+		sb.WriteString(numbered[i].Number + " ")
+		last = offset
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// gotoFragment shows the section a URL fragment refers to, switching section page if needed.
+// It reports whether the section was found.
+func (m *Model) gotoFragment(fragment string) bool {
+	i := wiki.FindFragment(m.sections, fragment)
+	if i < 0 {
+		return false
+	}
+	return m.gotoHeading(i)
+}
+
+// gotoHeading scrolls to the heading of section i, switching section page if needed. It
+// reports whether the heading was found in the text.
+func (m *Model) gotoHeading(i int) bool {
+	offset := wiki.SectionOffsets(m.articleContent, m.sections)[i]
+	if offset < 0 {
+		return false
+	}
+	if m.sectionPaging {
+		for j, page := range m.sectionPages {
+			if offset >= page.start && offset < page.end {
+				m.sectionIndex = j
+				m.refreshContent()
+				offset -= page.start
+				break
+			}
+		}
+	}
+	m.scrollToLineAt(offset)
+	return true
+}
+
+// scrollToLineAt scrolls to the line starting at offset in the displayed text.
+func (m *Model) scrollToLineAt(offset int) {
+	line := 0
+	if offset > 0 {
+		// The line's text renders below that before it, which renders to the lines above it,
+		// plus the empty line rendered after the final newline.
+		line = max(0, strings.Count(m.render(m.displayContent()[:offset-1]), "\n")-1)
+	}
+	m.viewport.SetYOffset(line)
+}
+
+// gotoSection moves delta sections forward or backward, switching to section paging if needed.
+func (m *Model) gotoSection(delta int) {
+	if !m.sectionPaging {
+		m.sectionPaging = true
+	}
+	m.sectionIndex = max(0, min(len(m.sectionPages)-1, m.sectionIndex+delta))
+	m.clearMatches()
+	m.refreshContent()
+	m.viewport.SetYOffset(0)
+}
+
+// toggleSectionPaging switches between showing the article one section at a time and showing
+// it whole, which opens at the section that was shown. Only the whole article's reading
+// position is remembered.
+func (m *Model) toggleSectionPaging() {
+	m.clearMatches()
+	if m.sectionPaging {
+		start := m.sectionPages[m.sectionIndex].start
+		m.sectionPaging = false
+		m.refreshContent()
+		m.scrollToLineAt(start)
+		m.articleNotice = m.verbose("Whole article", "Showing the whole article. Press 'A' to show it one section at a time.")
+		return
+	}
+	m.sectionPaging = true
+	m.refreshContent()
+	m.viewport.SetYOffset(0)
+	m.articleNotice = m.verbose("One section at a time", "Showing one section at a time; ']' and '[' change section. Press 'A' to show the whole article.")
+}
+
+// sectionStatus describes the section currently shown, e.g. "Section 3/12: History".
+func (m Model) sectionStatus() string {
+	if !m.sectionPaging || m.sectionIndex >= len(m.sectionPages) {
+		return ""
+	}
+	page := m.sectionPages[m.sectionIndex]
+	title := m.headingText(page.title)
+	if m.numberedHeadings && page.number != "" {
+		title = page.number + " " + title
+	}
+	return fmt.Sprintf("Section %d/%d: %s", m.sectionIndex+1, len(m.sectionPages), title)
+}
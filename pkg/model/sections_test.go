@@ -0,0 +1,133 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestBuildSectionPages(t *testing.T) {
+	content := "Intro.\nHistory\nEarly.\nOrigins\nOld.\nLegacy\nLater.\n"
+	tests := []struct {
+		name     string
+		content  string
+		sections []wiki.Section
+		want     []sectionPage
+	}{
+		{
+			name:    "top-level sections",
+			content: content,
+			sections: []wiki.Section{
+				{Line: "History", Level: "2", Number: "1"},
+				{Line: "Origins", Level: "3", Number: "1.1"},
+				// Dropped from the text, so it has no page.
+				{Line: "Reception", Level: "2", Number: "2"},
+				{Line: "Legacy", Level: "2", Number: "3"},
+			},
+			want: []sectionPage{
+				{title: "Introduction", start: 0, end: 7},
+				{title: "History", number: "1", start: 7, end: 35},
+				{title: "Legacy", number: "3", start: 35, end: 49},
+			},
+		},
+		{
+			name:    "top level taken from the headings found",
+			content: "Intro.\nOrigins\nOld.\n",
+			sections: []wiki.Section{
+				{Line: "History", Level: "2"},
+				{Line: "Origins", Level: "3"},
+			},
+			want: []sectionPage{
+				{title: "Introduction", start: 0, end: 7},
+				{title: "Origins", number: "1.1", start: 7, end: 20},
+			},
+		},
+		{
+			name:     "empty introduction",
+			content:  "History\nEarly.\nLegacy\nLater.\n",
+			sections: []wiki.Section{{Line: "History", Level: "2"}, {Line: "Legacy", Level: "2"}},
+			want: []sectionPage{
+				{title: "History", number: "1", start: 0, end: 15},
+				{title: "Legacy", number: "2", start: 15, end: 29},
+			},
+		},
+		{
+			name:     "single page",
+			content:  "Intro.\n",
+			sections: []wiki.Section{{Line: "History", Level: "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildSectionPages(tt.content, tt.sections); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildSectionPages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToggleSectionPaging(t *testing.T) {
+	article := testArticle
+	article.Content = "Intro.\n\nHistory\n\n" + strings.Repeat("Old times.\n\n", 30) + "Legacy\n\n" + strings.Repeat("Later.\n\n", 30)
+	article.Sections = []wiki.Section{{Line: "History", Level: "2"}, {Line: "Legacy", Level: "2"}}
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}, keys("]", "]")) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	if !m.sectionPaging || m.sectionIndex != 2 {
+		t.Fatalf("after ] ]: paging %v, section %d; want the third section on its own", m.sectionPaging, m.sectionIndex)
+	}
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	m = model.(Model)
+	if m.sectionPaging || m.displayContent() != article.Content {
+		t.Fatal("A did not return to the whole article")
+	}
+	if top := strings.Split(m.rendered, "\n")[m.viewport.YOffset]; top != "Legacy" {
+		t.Errorf("whole article opened at %q, want the section that was shown", top)
+	}
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	if m = model.(Model); !m.sectionPaging || m.sectionStatus() != "Section 3/3: Legacy" {
+		t.Errorf("A again: paging %v, status %q; want the section shown again", m.sectionPaging, m.sectionStatus())
+	}
+}
+
+func TestSectionRedirect(t *testing.T) {
+	article := testArticle
+	article.Content = "Intro.\n\n" + strings.Repeat("Early days.\n\n", 30) + "History\n\n" + strings.Repeat("Later days.\n\n", 30)
+	article.Sections = []wiki.Section{{Line: "History", Level: "2", Anchor: "History"}}
+	tests := []struct {
+		fragment string
+		top      string
+		notice   string
+	}{
+		{"History", "History", "Redirected to section: History"},
+		{"Legacy", "Intro.", `Redirected to section "Legacy", which could not be found; showing the top of the article.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fragment, func(t *testing.T) {
+			var model tea.Model = newTestModel()
+			for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter")) {
+				model, _ = model.Update(msg)
+			}
+			m := model.(Model)
+			redirected := article
+			redirected.Fragment = tt.fragment
+			// The article arrives as the response to the request made for it.
+			model, _ = m.Update(responseMsg{requestID: m.requestID, msg: redirected})
+			m = model.(Model)
+			if top := strings.Split(m.rendered, "\n")[m.viewport.YOffset]; top != tt.top {
+				t.Errorf("article opened at %q, want %q", top, tt.top)
+			}
+			if m.articleNotice != tt.notice {
+				t.Errorf("notice %q, want %q", m.articleNotice, tt.notice)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// WithMinQueryLength sets how many characters a query needs before the related titles of
+// sparse results and the summaries of selected results are fetched for it on their own.
+// Searching with Enter works for queries of any length.
+func WithMinQueryLength(n int) Option {
+	return func(m *Model) {
+		m.minQueryLength = n
+	}
+}
+
+// shortQuery reports whether the query is too short for automatic requests.
+func (m Model) shortQuery() bool {
+	return utf8.RuneCountInString(strings.TrimSpace(m.textInput.Value())) < m.minQueryLength
+}
+
+// shortQueryHint returns a faint line saying that a query typed on the results screen is
+// too short for related titles and summaries, or empty if it isn't.
+func (m Model) shortQueryHint() string {
+	if strings.TrimSpace(m.textInput.Value()) == "" || !m.shortQuery() {
+		return ""
+	}
+	hint := fmt.Sprintf("Queries under %d characters get no related titles or previews.", m.minQueryLength)
+	return color.New(color.Faint).Sprint(m.fitWidth(hint, 0)) + "\n\n"
+}
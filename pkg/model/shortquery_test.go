@@ -0,0 +1,73 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/settings"
+	"wiki-search/pkg/wiki"
+)
+
+// search types query on the results screen of a fresh model and submits it.
+func search(t *testing.T, m Model, query string) Model {
+	t.Helper()
+	var model tea.Model = m
+	for _, msg := range then(keys("enter", query, "enter")) {
+		model, _ = model.Update(msg)
+	}
+	return model.(Model)
+}
+
+func TestShortQuerySkipsAutomaticRequests(t *testing.T) {
+	sparse := wiki.SearchMsg{Results: []wiki.SearchResult{{Title: "Go (game)"}}, TotalHits: 1}
+	tests := []struct {
+		query    string
+		minimum  int
+		requests bool
+	}{
+		{"go", settings.DefaultMinQueryLength, false},
+		{"golang", settings.DefaultMinQueryLength, true},
+		{"go", 0, true},
+	}
+	for _, tt := range tests {
+		m := search(t, newTestModel(WithMinQueryLength(tt.minimum)), tt.query)
+		_, cmd := m.Update(sparse)
+		if got := cmd != nil; got != tt.requests {
+			t.Errorf("query %q with minimum %d: requests made = %v, want %v", tt.query, tt.minimum, got, tt.requests)
+		}
+	}
+}
+
+func TestShortQueryHint(t *testing.T) {
+	hint := "Queries under 3 characters"
+	if view := Render(newTestModel(), 80, 24, keys("enter", "go")...); !strings.Contains(view, hint) {
+		t.Errorf("no hint for a short query:\n%s", view)
+	}
+	if view := Render(newTestModel(), 80, 24, keys("enter", "golang")...); strings.Contains(view, hint) {
+		t.Errorf("hint for a long enough query:\n%s", view)
+	}
+	// Searching with Enter still works for short queries.
+	if m := search(t, newTestModel(), "go"); !m.loading {
+		t.Error("Enter didn't search for a short query")
+	}
+}
+
+func TestPasteIntoSearchInput(t *testing.T) {
+	var model tea.Model = newTestModel()
+	for _, msg := range keys("enter") {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	m.textInput.CharLimit = 20
+	pasted := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Go (programming\nlanguage)\n\tand more besides"), Paste: true}
+	model, _ = m.Update(pasted)
+	m = model.(Model)
+	if got, want := m.textInput.Value(), "Go (programming lang"; got != want {
+		t.Errorf("input after pasting %q, want %q on one line, cut at the limit", got, want)
+	}
+	if m.loading {
+		t.Error("the newline in the paste started a search")
+	}
+}
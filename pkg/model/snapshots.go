@@ -0,0 +1,74 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/store"
+	"wiki-search/pkg/wiki"
+)
+
+// WithOfflineMode starts in the list of saved snapshots so articles can be read without network access.
+func WithOfflineMode() Option {
+	return func(m *Model) {
+		m.showSnapshots()
+	}
+}
+
+// showSnapshots switches to the list of offline snapshots.
+func (m *Model) showSnapshots() {
+	snapshots, err := store.ListSnapshots()
+	m.state = snapshotListView
+	m.snapshots = snapshots
+	m.snapshotCursor = 0
+	m.textInput.Blur()
+	if err != nil {
+		m.statusMsg = fmt.Sprintf("Could not list snapshots: %v", err)
+		return
+	}
+	m.statusMsg = m.verbose(fmt.Sprintf("%d snapshots", len(snapshots)), fmt.Sprintf("%d saved snapshots.", len(snapshots)))
+}
+
+// saveSnapshot stores the current article for offline reading.
+func (m *Model) saveSnapshot() {
+	err := store.SaveSnapshot(store.Snapshot{
+		Title:    m.selectedTitle,
+		WikiType: m.searchType,
+		Article:  m.currentArticle(),
+	})
+	if err != nil {
+		m.articleNotice = fmt.Sprintf("Could not save snapshot: %v", err)
+		return
+	}
+	m.articleNotice = m.verbose("Saved", fmt.Sprintf("Saved '%s' for offline reading.", m.selectedTitle))
+}
+
+// currentArticle returns the article being read, as it was fetched.
+func (m Model) currentArticle() wiki.Article {
+	return wiki.Article{
+		Title:        m.resolvedTitle,
+		DisplayTitle: m.displayTitle,
+		Content:      m.articleContent,
+		Sections:     m.sections,
+		RevID:        m.revisionID,
+		Fragment:     m.fragment,
+		Categories:   m.categories,
+		Description:  m.description,
+		Coordinates:  m.coordinates,
+		Mode:         m.articleMode,
+		Links:        m.articleLinks,
+	}
+}
+
+// openSnapshot feeds a saved snapshot into the article view like a fetched article.
+func openSnapshot(wikiType, title string) wiki.Request {
+	return func(context.Context) tea.Msg {
+		snapshot, err := store.LoadSnapshot(wikiType, title)
+		if err != nil {
+			return wiki.ArticleMsg{Err: err}
+		}
+		return wiki.ArticleMsg{Article: snapshot.Article}
+	}
+}
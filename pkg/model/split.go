@@ -0,0 +1,239 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// Below this pane size the split view stacks the panes, and below it in both directions the
+// split view is not available.
+const (
+	minPaneWidth  = 30
+	minPaneHeight = 3
+)
+
+// splitPane is one of the two articles of the split view, scrolled independently.
+type splitPane struct {
+	title    string
+	wikiType string
+	content  string
+	sections []wiki.Section
+	viewport viewport.Model
+}
+
+// splitArticleMsg carries the article fetched for the second pane of the split view.
+type splitArticleMsg struct {
+	title    string
+	wikiType string
+	article  wiki.ArticleMsg
+}
+
+// fetchPane fetches an article for the split view.
+func fetchPane(title, wikiType string) wiki.Request {
+	fetch := wiki.FetchArticle(title, wikiType)
+	return func(ctx context.Context) tea.Msg {
+		article, _ := fetch(ctx).(wiki.ArticleMsg)
+		return splitArticleMsg{title: title, wikiType: wikiType, article: article}
+	}
+}
+
+// startCompare prompts for the title of an article to read next to the current one.
+func (m *Model) startCompare() tea.Cmd {
+	switch {
+	case m.partial:
+		m.articleNotice = "Only the introduction has loaded; wait for the full article to compare it."
+		return nil
+	case m.offline:
+		m.articleNotice = "Articles can't be compared in offline mode."
+		return nil
+	}
+	m.state = compareInputView
+	m.textInput.Prompt = "Compare with: "
+	m.textInput.SetValue("")
+	return m.textInput.Focus()
+}
+
+// compare fetches the entered article for the split view, returning to the article meanwhile.
+func (m *Model) compare() tea.Cmd {
+	title := strings.TrimSpace(m.textInput.Value())
+	m.textInput.Blur()
+	m.state = articleView
+	if title == "" {
+		return nil
+	}
+	m.articleNotice = fmt.Sprintf("Fetching %q to compare...", title)
+	return m.startRequest(m.articleNotice, wiki.ArticleTimeout, fetchPane(title, m.searchType))
+}
+
+// openSplit shows the current article and the fetched one side by side, with the fetched one
+// focused.
+func (m *Model) openSplit(msg splitArticleMsg) {
+	if msg.article.Err != nil {
+		m.articleNotice = fmt.Sprintf("Could not fetch %q to compare: %v. Press 'r' to retry.", msg.title, msg.article.Err)
+		return
+	}
+	title := msg.title
+	if msg.article.DisplayTitle != "" {
+		title = msg.article.DisplayTitle
+	}
+	m.panes = [2]splitPane{
+		{title: m.articleTitle(), wikiType: m.searchType, content: m.articleContent, sections: m.sections},
+		{title: title, wikiType: msg.wikiType, content: msg.article.Content, sections: msg.article.Sections},
+	}
+	m.paneFocus = 1
+	if !m.layoutSplit() {
+		m.panes = [2]splitPane{}
+		m.articleNotice = fmt.Sprintf("The terminal is too small to compare articles (need %d×%d side by side or %d×%d stacked).",
+			2*minPaneWidth+3, minPaneHeight+3, minPaneWidth, 2*minPaneHeight+4)
+		return
+	}
+	m.state = splitView
+}
+
+// closeSplit returns to the article the split view was opened from.
+func (m *Model) closeSplit() {
+	m.panes = [2]splitPane{}
+	m.state = articleView
+}
+
+// layoutSplit sizes the panes for the window and renders their articles, keeping each one's
+// share of the text above the screen. Panes go side by side when the window is wide enough
+// and are stacked otherwise; it reports false when the window is too small for either.
+func (m *Model) layoutSplit() bool {
+	var widths, heights [2]int
+	switch {
+	case m.width >= 2*minPaneWidth+3 && m.height-3 >= minPaneHeight:
+		// A title row above and the footer below the panes, which a border separates.
+		m.splitStacked = false
+		widths = [2]int{(m.width - 3) / 2, m.width - 3 - (m.width-3)/2}
+		heights = [2]int{m.height - 3, m.height - 3}
+	case m.width >= minPaneWidth && m.height-4 >= 2*minPaneHeight:
+		// A title row above each pane and the footer below them.
+		m.splitStacked = true
+		widths = [2]int{m.width, m.width}
+		heights = [2]int{(m.height - 4) / 2, m.height - 4 - (m.height-4)/2}
+	default:
+		return false
+	}
+	for i := range m.panes {
+		pane := &m.panes[i]
+		scrolled := pane.viewport.YOffset > 0
+		percent := pane.viewport.ScrollPercent()
+		pane.viewport = viewport.New(widths[i], heights[i])
+		pane.viewport.SetContent(m.renderPane(*pane))
+		if scrolled {
+			maxOffset := max(0, pane.viewport.TotalLineCount()-pane.viewport.Height)
+			pane.viewport.SetYOffset(int(percent * float64(maxOffset)))
+		}
+	}
+	return true
+}
+
+// renderPane formats and highlights the article of a pane for its width, as the article view
+// would.
+func (m Model) renderPane(pane splitPane) string {
+	r := m
+	r.viewport.Width = pane.viewport.Width
+	r.sections = pane.sections
+	rendered, codeBlocks, urls := r.renderBlocks(pane.content)
+	styles := m.highlightStyles
+	if m.codeStyle != "" {
+		styles.Code = func(code, lang string) string {
+			return utils.HighlightCode(code, lang, m.codeStyle)
+		}
+	}
+	spans := utils.NoSpans()
+	spans.URLs, spans.Identifiers = m.findLinks(rendered, urls)
+	spans.CodeBlocks = codeBlocks
+	return utils.HighlightText(rendered, spans, styles)
+}
+
+// updateSplit handles a key in the split view. Scrolling keys apply to the focused pane.
+func (m *Model) updateSplit(msg tea.KeyMsg) tea.Cmd {
+	pane := &m.panes[m.paneFocus].viewport
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return tea.Quit
+	case "esc":
+		m.closeSplit()
+		return nil
+	case "tab", "shift+tab":
+		m.switchPane()
+		return nil
+	case "up", "k":
+		pane.ScrollUp(m.scrollStep)
+		return nil
+	case "down", "j":
+		pane.ScrollDown(m.scrollStep)
+		return nil
+	case "ctrl+u":
+		pane.ScrollUp(max(1, int(float64(pane.Height)*m.pageFraction)))
+		return nil
+	case "ctrl+d":
+		pane.ScrollDown(max(1, int(float64(pane.Height)*m.pageFraction)))
+		return nil
+	}
+	var cmd tea.Cmd
+	*pane, cmd = pane.Update(msg)
+	return cmd
+}
+
+// switchPane moves the focus to the other pane.
+func (m *Model) switchPane() {
+	m.paneFocus = 1 - m.paneFocus
+}
+
+// splitViewString renders the split view: the two panes with their titles, the focused one's
+// in color, and the footer.
+func (m Model) splitViewString() string {
+	titles := [2]string{}
+	for i, pane := range m.panes {
+		title := utils.TruncateRunes(pane.title, max(1, pane.viewport.Width))
+		if i == m.paneFocus {
+			titles[i] = color.New(color.Bold, color.FgCyan).Sprint(title)
+		} else {
+			titles[i] = color.New(color.Faint).Sprint(title)
+		}
+	}
+	s := strings.Builder{}
+	if m.splitStacked {
+		s.WriteString(titles[0] + "\n" + m.panes[0].viewport.View() + "\n")
+		s.WriteString(titles[1] + "\n" + m.panes[1].viewport.View())
+	} else {
+		border := color.New(color.Faint).Sprint(" │ ")
+		s.WriteString(padLine(titles[0], m.panes[0].viewport.Width) + "   " + titles[1])
+		left := strings.Split(m.panes[0].viewport.View(), "\n")
+		right := strings.Split(m.panes[1].viewport.View(), "\n")
+		for i := range max(len(left), len(right)) {
+			var l, r string
+			if i < len(left) {
+				l = left[i]
+			}
+			if i < len(right) {
+				r = right[i]
+			}
+			s.WriteString("\n" + padLine(l, m.panes[0].viewport.Width) + border + r)
+		}
+	}
+	footer := "Tab to switch panes, Up/Down to scroll the focused pane, 'ctrl+u/ctrl+d' to page, Esc to close, 'q' to quit."
+	if m.articleNotice != "" {
+		footer = m.articleNotice
+	}
+	s.WriteString(color.New(color.FgWhite).Sprint("\n\n" + footer))
+	return s.String()
+}
+
+// padLine cuts or pads a line of styled text to exactly width columns.
+func padLine(line string, width int) string {
+	line = ansi.Truncate(line, width, "")
+	return line + strings.Repeat(" ", max(0, width-ansi.StringWidth(line)))
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+// linuxArticle is the article compared with testArticle, long enough to scroll.
+var linuxArticle = wiki.ArticleMsg{Article: wiki.Article{
+	Title:   "Linux",
+	Content: strings.Repeat("Linux is a family of open-source Unix-like operating systems based on the Linux kernel.\n\n", 40),
+	Mode:    wiki.ContentReadable,
+}}
+
+// openSplitView compares a long testArticle with linuxArticle in a window of the given size.
+func openSplitView(t *testing.T, width, height int) Model {
+	t.Helper()
+	long := testArticle
+	long.Content = strings.Repeat(testArticle.Content+"\n\n", 10)
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: width, Height: height}}, toResults, keys("enter"), []tea.Msg{long}, keys("V", "Linux")) {
+		model, _ = model.Update(msg)
+	}
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("entering a title to compare with fetched nothing")
+	}
+	m := model.(Model)
+	model, _ = m.Update(splitArticleMsg{title: "Linux", wikiType: m.searchType, article: linuxArticle})
+	return model.(Model)
+}
+
+// offsets returns how far each pane is scrolled.
+func offsets(m Model) [2]int {
+	return [2]int{m.panes[0].viewport.YOffset, m.panes[1].viewport.YOffset}
+}
+
+func TestSplitFocus(t *testing.T) {
+	m := openSplitView(t, 80, 24)
+	if m.state != splitView || m.paneFocus != 1 {
+		t.Fatalf("state %v, focus %d, want the split view with the fetched article focused", m.state, m.paneFocus)
+	}
+	if m.panes[0].title != "Go (programming language)" || m.panes[1].title != "Linux" {
+		t.Errorf("panes %q and %q, want the article read and the one fetched", m.panes[0].title, m.panes[1].title)
+	}
+
+	steps := []struct {
+		key   tea.KeyMsg
+		focus int
+		want  [2]int
+	}{
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}}, 1, [2]int{0, 1}},
+		{tea.KeyMsg{Type: tea.KeyTab}, 0, [2]int{0, 1}},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}}, 0, [2]int{1, 1}},
+		{tea.KeyMsg{Type: tea.KeyCtrlD}, 0, [2]int{11, 1}},
+		{tea.KeyMsg{Type: tea.KeyShiftTab}, 1, [2]int{11, 1}},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}}, 1, [2]int{11, 0}},
+	}
+	for _, step := range steps {
+		model, _ := m.Update(step.key)
+		m = model.(Model)
+		if m.paneFocus != step.focus || offsets(m) != step.want {
+			t.Errorf("after %s: focus %d, offsets %v, want focus %d, offsets %v", step.key, m.paneFocus, offsets(m), step.focus, step.want)
+		}
+	}
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = model.(Model)
+	if m.state != articleView || m.panes[0].title != "" {
+		t.Errorf("state %v after esc, want the article with the panes closed", m.state)
+	}
+}
+
+func TestSplitLayout(t *testing.T) {
+	m := openSplitView(t, 80, 24)
+	if m.splitStacked || m.panes[0].viewport.Width+m.panes[1].viewport.Width != 77 {
+		t.Errorf("panes %d and %d wide, stacked %v, want them side by side", m.panes[0].viewport.Width, m.panes[1].viewport.Width, m.splitStacked)
+	}
+	for _, line := range strings.Split(m.View(), "\n") {
+		if w := widest(line); w > 80 {
+			t.Errorf("line %q is %d columns wide", line, w)
+		}
+	}
+
+	// Scrolled panes stay scrolled when the window narrows and stacks them.
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 40, Height: 24})
+	m = model.(Model)
+	if !m.splitStacked || m.panes[1].viewport.Width != 40 {
+		t.Errorf("panes not stacked in a narrow window")
+	}
+	if offsets(m)[1] == 0 {
+		t.Error("focused pane scrolled back to the top")
+	}
+
+	// A window too small for either layout closes the split view.
+	model, _ = m.Update(tea.WindowSizeMsg{Width: 25, Height: 24})
+	m = model.(Model)
+	if m.state != articleView || !strings.Contains(m.articleNotice, "too small") {
+		t.Errorf("state %v, notice %q, want the article with a notice", m.state, m.articleNotice)
+	}
+
+	// Nor does it open in one.
+	m = openSplitView(t, 25, 24)
+	if m.state != articleView || !strings.Contains(m.articleNotice, "too small to compare") {
+		t.Errorf("state %v, notice %q, want the article with a notice", m.state, m.articleNotice)
+	}
+}
+
+func TestSplitFetchError(t *testing.T) {
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toArticle) {
+		model, _ = model.Update(msg)
+	}
+	model, _ = model.Update(splitArticleMsg{title: "Nope", article: wiki.ArticleMsg{Err: errors.New("connection refused")}})
+	m := model.(Model)
+	if m.state != articleView || !strings.Contains(m.articleNotice, `Could not fetch "Nope"`) {
+		t.Errorf("state %v, notice %q, want the article with the error", m.state, m.articleNotice)
+	}
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/utils"
+	"wiki-search/pkg/wiki"
+)
+
+// summaryDelay is how long a search result has to stay selected before its summary is
+// fetched, so moving through the results doesn't fetch one for each.
+const summaryDelay = 400 * time.Millisecond
+
+// summaryDueMsg reports that a search result stayed selected for summaryDelay.
+type summaryDueMsg struct {
+	result articleOrigin
+}
+
+// scheduleSummary starts waiting for the selected search result to stay selected, when the
+// selection has moved to a result without an extract. The first sentence of its introduction
+// is then shown under it.
+func (m *Model) scheduleSummary() tea.Cmd {
+	if m.state != searchResultsView || m.textInput.Focused() || m.cursor >= len(m.results) || m.shortQuery() {
+		return nil
+	}
+	result := articleOrigin{title: m.results[m.cursor].Title, wikiType: m.searchType}
+	if m.results[m.cursor].Extract != "" || result == m.summaryFor {
+		return nil
+	}
+	m.summaryFor = result
+	m.summary = ""
+	return tea.Tick(summaryDelay, func(time.Time) tea.Msg {
+		return summaryDueMsg{result: result}
+	})
+}
+
+// fetchSummary fetches the summary of a search result that stayed selected.
+func (m Model) fetchSummary(msg summaryDueMsg) tea.Cmd {
+	if m.state != searchResultsView || msg.result != m.summaryFor {
+		return nil
+	}
+	return wiki.FetchSummary(msg.result.title, msg.result.wikiType)
+}
+
+// showSummary shows the first sentence of a fetched summary, if its result is still selected.
+// Summaries are a nicety, so failures are not reported.
+func (m *Model) showSummary(msg wiki.SummaryMsg) {
+	if msg.Err != nil || (articleOrigin{title: msg.Title, wikiType: msg.WikiType}) != m.summaryFor {
+		return
+	}
+	m.summary = utils.FirstSentence(msg.Text)
+}
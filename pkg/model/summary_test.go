@@ -0,0 +1,55 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestResultSummary(t *testing.T) {
+	var model tea.Model = newTestModel()
+	var cmd tea.Cmd
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, keys("enter", "golang", "enter"), []tea.Msg{testResults}) {
+		model, cmd = model.Update(msg)
+	}
+	m := model.(Model)
+	first := m.summaryFor
+	if cmd == nil || first.title != "Go (programming language)" {
+		t.Fatalf("no summary scheduled for the selected result, summaryFor %+v", first)
+	}
+	if _, cmd := m.Update(summaryDueMsg{result: first}); cmd == nil {
+		t.Error("no summary fetched for a result that stayed selected")
+	}
+
+	model, _ = m.Update(wiki.SummaryMsg{Title: first.title, WikiType: first.wikiType,
+		Text: "Go is a language designed at Google, e.g. by Pike. It is similar to C."})
+	m = model.(Model)
+	if want := "Go is a language designed at Google, e.g. by Pike."; m.summary != want {
+		t.Errorf("summary %q, want %q", m.summary, want)
+	}
+	if view := m.View(); !strings.Contains(view, "      Go is a language designed at Google, e.g. by Pike.") || strings.Contains(view, "similar to C") {
+		t.Errorf("summary not shown under the result as its first sentence:\n%s", view)
+	}
+
+	// Once the selection moves on, the old result's summary is neither fetched nor shown.
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = model.(Model)
+	if m.summaryFor == first || m.summary != "" {
+		t.Fatalf("summary %q of %+v kept after moving to the next result", m.summary, m.summaryFor)
+	}
+	if _, cmd := m.Update(summaryDueMsg{result: first}); cmd != nil {
+		t.Error("summary fetched for a result no longer selected")
+	}
+	model, _ = m.Update(wiki.SummaryMsg{Title: first.title, WikiType: first.wikiType, Text: "Go is a language."})
+	if summary := model.(Model).summary; summary != "" {
+		t.Errorf("stale summary %q shown", summary)
+	}
+	model, _ = m.Update(wiki.SummaryMsg{Title: m.summaryFor.title, WikiType: m.summaryFor.wikiType, Err: errors.New("timed out")})
+	if summary := model.(Model).summary; summary != "" {
+		t.Errorf("failed summary shown as %q", summary)
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestTableInArticle(t *testing.T) {
+	article := wiki.ArticleMsg{Article: wiki.Article{
+		Title: "Planet",
+		Content: "The inner planets:\n\n```wikitable\nh\tPlanet\tMoons\tDay length\nd\tMercury\t0\t176 days[1]\nd\tEarth\t1\t24 hours\n```\n\n" +
+			"Outer planets follow.",
+		Mode: wiki.ContentReadable,
+	}}
+	var model tea.Model = newTestModel()
+	for _, msg := range then([]tea.Msg{tea.WindowSizeMsg{Width: 80, Height: 24}}, toResults, keys("enter"), []tea.Msg{article}) {
+		model, _ = model.Update(msg)
+	}
+	m := model.(Model)
+	table := "Planet  │ Moons │ Day length\n" +
+		"────────┼───────┼───────────\n" +
+		"Mercury │ 0     │ 176 days\n" +
+		"Earth   │ 1     │ 24 hours\n"
+	if !strings.Contains(m.rendered, table) || !strings.Contains(m.rendered, "Outer planets follow.") {
+		t.Errorf("table not laid out in columns between the text:\n%s", m.rendered)
+	}
+
+	// The cells are searched like the rest of the text.
+	m = find(t, m, "Earth")
+	if got := m.rendered[m.matchSpans[0][0]:]; !strings.HasPrefix(got, "Earth   │ 1") {
+		t.Errorf("match at %q, want the table cell", got)
+	}
+
+	// Narrowing the window lays the table out again to fit.
+	model, _ = m.Update(tea.WindowSizeMsg{Width: 24, Height: 24})
+	m = model.(Model)
+	if !strings.Contains(m.rendered, "…") {
+		t.Errorf("table not narrowed to the window:\n%s", m.rendered)
+	}
+}
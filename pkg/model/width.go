@@ -0,0 +1,48 @@
+package model
+
+import (
+	"fmt"
+
+	"wiki-search/pkg/settings"
+)
+
+// readingWidthStep is how many columns +/- widen or narrow the text by.
+const readingWidthStep = 4
+
+// WithReadingWidth wraps article text at n columns, or at the window width if that is
+// smaller. Zero uses the whole window.
+func WithReadingWidth(n int) Option {
+	return func(m *Model) {
+		m.readingWidth = n
+	}
+}
+
+// textWidth is the width article text is wrapped to.
+func (m Model) textWidth() int {
+	if m.readingWidth > 0 {
+		return min(m.readingWidth, m.viewport.Width)
+	}
+	return m.viewport.Width
+}
+
+// adjustReadingWidth widens (delta > 0) or narrows the text column by delta steps, between
+// settings.MinReadingWidth and the window width, keeping the reading position.
+func (m *Model) adjustReadingWidth(delta int) {
+	width := max(settings.MinReadingWidth, min(m.viewport.Width, m.textWidth()+delta*readingWidthStep))
+	m.readingWidth = width
+	if width >= m.viewport.Width {
+		// Back at full width, the text follows the window as it is resized.
+		m.readingWidth = 0
+	}
+	scrolled := m.viewport.YOffset > 0
+	percent := m.viewport.ScrollPercent()
+	m.refreshContent()
+	if scrolled {
+		m.scrollToPercent(percent)
+	}
+	if m.readingWidth == 0 {
+		m.articleNotice = m.verbose("Full width", fmt.Sprintf("Reading width: full window (%d columns).", width))
+	} else {
+		m.articleNotice = m.verbose(fmt.Sprintf("%d columns", width), fmt.Sprintf("Reading width: %d columns.", width))
+	}
+}
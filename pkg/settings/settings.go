@@ -0,0 +1,49 @@
+// Package settings holds what the config file and the user interface both need to know about
+// the reader's settings: their defaults, their limits and the parsing of their values. It
+// keeps the config package from depending on the user interface.
+package settings
+
+import "fmt"
+
+// Default scroll steps, matching the viewport's own key handling.
+const (
+	DefaultScrollStep   = 1
+	DefaultPageFraction = 0.5
+)
+
+// DefaultMaxArticleLength is the article size, in bytes, above which articles are paged by
+// section.
+const DefaultMaxArticleLength = 60000
+
+// DefaultMinQueryLength is the number of characters a query needs before requests are made
+// for it automatically.
+const DefaultMinQueryLength = 3
+
+// MinReadingWidth is the narrowest the text column can be made.
+const MinReadingWidth = 20
+
+// DefaultFooter is the footer template used for articles unless another one is configured.
+const DefaultFooter = `{{if .MatchPos}}Match {{.MatchPos}}{{if gt .MatchesOnLine 1}} ({{.MatchesOnLine}} on this line){{end}} | {{end}}{{if .Section}}{{.Section}} | {{end}}{{.Keys}}`
+
+// LinkAction selects what happens when a link is activated, e.g. with the 'o' key.
+type LinkAction string
+
+const (
+	// LinkOpen opens the link in the browser and keeps the application running.
+	LinkOpen LinkAction = "open"
+	// LinkCopy copies the link to the clipboard.
+	LinkCopy LinkAction = "copy"
+	// LinkOpenAndQuit opens the link in the browser and quits.
+	LinkOpenAndQuit LinkAction = "open-and-quit"
+	// LinkPrint quits and prints the link, e.g. for use in shell pipelines.
+	LinkPrint LinkAction = "print"
+)
+
+// ParseLinkAction validates a link action name.
+func ParseLinkAction(name string) (LinkAction, error) {
+	switch action := LinkAction(name); action {
+	case LinkOpen, LinkCopy, LinkOpenAndQuit, LinkPrint:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown link action %q (want open, copy, open-and-quit or print)", name)
+}
@@ -0,0 +1,16 @@
+package settings
+
+import "testing"
+
+func TestParseLinkAction(t *testing.T) {
+	for _, name := range []string{"open", "copy", "open-and-quit", "print"} {
+		if action, err := ParseLinkAction(name); err != nil || string(action) != name {
+			t.Errorf("ParseLinkAction(%q) = %q, %v", name, action, err)
+		}
+	}
+	for _, name := range []string{"", "Open", "browse"} {
+		if _, err := ParseLinkAction(name); err == nil {
+			t.Errorf("ParseLinkAction(%q) succeeded, want an error", name)
+		}
+	}
+}
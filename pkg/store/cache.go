@@ -0,0 +1,19 @@
+package store
+
+import "wiki-search/pkg/wiki"
+
+const cacheFile = "cache.json"
+
+// ArticleCache returns the persisted article cache.
+func ArticleCache() ([]wiki.CacheEntry, error) {
+	var entries []wiki.CacheEntry
+	if err := load(cacheFile, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SaveArticleCache persists the article cache.
+func SaveArticleCache(entries []wiki.CacheEntry) error {
+	return save(cacheFile, entries)
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"wiki-search/pkg/wiki"
+)
+
+func TestArticleCacheRoundTrip(t *testing.T) {
+	useTempDir(t)
+	if entries, err := ArticleCache(); err != nil || len(entries) != 0 {
+		t.Fatalf("ArticleCache() before saving = %v, %v; want none", entries, err)
+	}
+	fetched := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	saved := []wiki.CacheEntry{{
+		WikiType:  "wikipedia",
+		Title:     "Go",
+		Article:   wiki.Article{Title: "Go (programming language)", Content: "Go is a language.", RevID: 7, Mode: wiki.ContentReadable},
+		FetchedAt: fetched,
+	}}
+	if err := SaveArticleCache(saved); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ArticleCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("ArticleCache() = %+v, want the saved entry", entries)
+	}
+	got := entries[0]
+	if got.WikiType != "wikipedia" || got.Title != "Go" || got.Article.Content != "Go is a language." || got.Article.RevID != 7 || !got.FetchedAt.Equal(fetched) {
+		t.Errorf("loaded entry %+v, want %+v", got, saved[0])
+	}
+}
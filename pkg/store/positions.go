@@ -0,0 +1,37 @@
+package store
+
+const positionsFile = "positions.json"
+
+// positionKey identifies an article across wikis.
+func positionKey(wikiType, title string) string {
+	return wikiType + "|" + title
+}
+
+// SavePosition records how far through an article the reader scrolled, as a fraction between 0 and 1.
+// A position at the very top is forgotten rather than stored.
+func SavePosition(wikiType, title string, percent float64) error {
+	positions := map[string]float64{}
+	if err := load(positionsFile, &positions); err != nil {
+		return err
+	}
+	key := positionKey(wikiType, title)
+	if percent <= 0 {
+		if _, ok := positions[key]; !ok {
+			return nil
+		}
+		delete(positions, key)
+	} else {
+		positions[key] = min(percent, 1)
+	}
+	return save(positionsFile, positions)
+}
+
+// LoadPosition returns the saved scroll fraction for an article, if there is one.
+func LoadPosition(wikiType, title string) (float64, bool) {
+	positions := map[string]float64{}
+	if err := load(positionsFile, &positions); err != nil {
+		return 0, false
+	}
+	percent, ok := positions[positionKey(wikiType, title)]
+	return percent, ok
+}
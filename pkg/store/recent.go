@@ -0,0 +1,38 @@
+package store
+
+import "strings"
+
+const recentFile = "recent.json"
+
+// MaxRecentSearches bounds how many recent search terms are kept.
+const MaxRecentSearches = 10
+
+// PushRecent returns terms with term moved to the front, without duplicates and
+// trimmed to MaxRecentSearches entries.
+func PushRecent(terms []string, term string) []string {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return terms
+	}
+	updated := []string{term}
+	for _, t := range terms {
+		if t != term && len(updated) < MaxRecentSearches {
+			updated = append(updated, t)
+		}
+	}
+	return updated
+}
+
+// RecentSearches returns the persisted recent search terms, newest first.
+func RecentSearches() ([]string, error) {
+	var terms []string
+	if err := load(recentFile, &terms); err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+// SaveRecentSearches persists the recent search terms.
+func SaveRecentSearches(terms []string) error {
+	return save(recentFile, terms)
+}
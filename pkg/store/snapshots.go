@@ -0,0 +1,125 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"wiki-search/pkg/wiki"
+)
+
+// snapshotsDir holds offline snapshots. They are kept apart from any cached
+// data: snapshots are only created and removed on the user's request.
+const snapshotsDir = "snapshots"
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Snapshot is an article saved for reading without network access. Title is the title it
+// was opened under, which names the snapshot; Article.Title is where a redirect led, if it did.
+type Snapshot struct {
+	Title    string       `json:"title"`
+	WikiType string       `json:"wiki"`
+	Article  wiki.Article `json:"article"`
+	SavedAt  time.Time    `json:"saved_at"`
+}
+
+// legacySnapshot holds the article of a snapshot saved before snapshots kept all of it.
+type legacySnapshot struct {
+	Content  string         `json:"content"`
+	Sections []wiki.Section `json:"sections"`
+	RevID    int            `json:"revid"`
+}
+
+// decodeSnapshot reads a snapshot file, in either layout.
+func decodeSnapshot(data []byte) (Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Snapshot{}, err
+	}
+	if s.Article.Content == "" {
+		var old legacySnapshot
+		if err := json.Unmarshal(data, &old); err != nil {
+			return Snapshot{}, err
+		}
+		s.Article.Content, s.Article.Sections, s.Article.RevID = old.Content, old.Sections, old.RevID
+	}
+	return s, nil
+}
+
+// snapshotFile returns the file name used for an article's snapshot.
+func snapshotFile(wikiType, title string) string {
+	h := fnv.New32a()
+	h.Write([]byte(wikiType + "|" + title))
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(title, "_"), "_")
+	if len(slug) > 60 {
+		slug = slug[:60]
+	}
+	return fmt.Sprintf("%s-%s-%08x.json", wikiType, slug, h.Sum32())
+}
+
+// SaveSnapshot stores an article for offline reading, replacing any earlier snapshot of it.
+func SaveSnapshot(s Snapshot) error {
+	if s.SavedAt.IsZero() {
+		s.SavedAt = time.Now()
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, snapshotsDir), 0o755); err != nil {
+		return err
+	}
+	return save(filepath.Join(snapshotsDir, snapshotFile(s.WikiType, s.Title)), s)
+}
+
+// LoadSnapshot reads the snapshot of an article.
+func LoadSnapshot(wikiType, title string) (Snapshot, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, snapshotsDir, snapshotFile(wikiType, title)))
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("no snapshot of %q: %w", title, err)
+	}
+	s, err := decodeSnapshot(data)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot of %q: %w", title, err)
+	}
+	return s, nil
+}
+
+// ListSnapshots returns all saved snapshots, most recently saved first.
+// Unreadable snapshot files are skipped.
+func ListSnapshots() ([]Snapshot, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, snapshotsDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		s, err := decodeSnapshot(data)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].SavedAt.After(snapshots[j].SavedAt)
+	})
+	return snapshots, nil
+}
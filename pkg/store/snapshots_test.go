@@ -0,0 +1,70 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"wiki-search/pkg/wiki"
+)
+
+// useTempDir points the state directory at a fresh temporary directory.
+func useTempDir(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", base)
+	t.Setenv("HOME", base)
+	dir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSnapshotKeepsArticle(t *testing.T) {
+	useTempDir(t)
+	article := wiki.Article{
+		Title:        "Go (programming language)",
+		DisplayTitle: "Go (programming language)",
+		Content:      "Go is a programming language.",
+		Sections:     []wiki.Section{{Index: "1", Level: "2", Line: "History", Number: "1"}},
+		RevID:        42,
+		Categories:   []string{"Programming languages"},
+		Description:  "Programming language",
+		Coordinates:  &wiki.Coordinates{Lat: 1.5, Lon: -2.25},
+		Mode:         wiki.ContentReadable,
+	}
+	if err := SaveSnapshot(Snapshot{Title: "Golang", WikiType: "wikipedia", Article: article}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSnapshot("wikipedia", "Golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Article, article) {
+		t.Errorf("loaded article = %+v, want %+v", got.Article, article)
+	}
+	list, err := ListSnapshots()
+	if err != nil || len(list) != 1 || list[0].Article.Description != article.Description {
+		t.Errorf("ListSnapshots() = %+v, %v; want the saved snapshot", list, err)
+	}
+}
+
+func TestLegacySnapshotLoads(t *testing.T) {
+	dir := useTempDir(t)
+	if err := os.MkdirAll(filepath.Join(dir, snapshotsDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"title":"Go","wiki":"wikipedia","content":"Old text.","sections":[{"line":"History"}],"revid":7,"saved_at":"2024-01-02T03:04:05Z"}`
+	if err := os.WriteFile(filepath.Join(dir, snapshotsDir, snapshotFile("wikipedia", "Go")), []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSnapshot("wikipedia", "Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Article.Content != "Old text." || got.Article.RevID != 7 || len(got.Article.Sections) != 1 {
+		t.Errorf("legacy snapshot loaded as %+v", got.Article)
+	}
+}
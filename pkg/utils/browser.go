@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// RunCommand starts a command without waiting for it to finish. OpenInBrowser starts the
+// browser with it; tests may replace it to record the command instead.
+var RunCommand = func(name string, args ...string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("%s not found", name)
+	}
+	return exec.Command(path, args...).Start()
+}
+
+// OpenInBrowser opens pageURL in the user's web browser. It fails when the platform is not
+// supported or the browser can't be started.
+func OpenInBrowser(pageURL string) error {
+	name, args, err := BrowserCommand(runtime.GOOS, os.Getenv("BROWSER"), pageURL)
+	if err != nil {
+		return err
+	}
+	return RunCommand(name, args...)
+}
+
+// BrowserCommand returns the command and arguments that open pageURL on the operating system
+// goos. On Unix a $BROWSER value overrides the platform default; it may list several commands
+// separated by colons, of which the first is used.
+func BrowserCommand(goos, browser, pageURL string) (string, []string, error) {
+	if goos != "windows" {
+		if args := strings.Fields(strings.Split(strings.TrimSpace(browser), ":")[0]); len(args) > 0 {
+			return args[0], append(args[1:], pageURL), nil
+		}
+	}
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return "xdg-open", []string{pageURL}, nil
+	case "darwin":
+		return "open", []string{pageURL}, nil
+	case "windows":
+		return "cmd", []string{"/c", "start", pageURL}, nil
+	}
+	return "", nil, fmt.Errorf("opening a browser is not supported on %s", goos)
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	const page = "https://en.wikipedia.org/wiki/Go"
+	tests := []struct {
+		goos    string
+		browser string
+		name    string
+		args    []string
+		err     bool
+	}{
+		{"linux", "", "xdg-open", []string{page}, false},
+		{"freebsd", "", "xdg-open", []string{page}, false},
+		{"darwin", "", "open", []string{page}, false},
+		{"windows", "", "cmd", []string{"/c", "start", page}, false},
+		{"plan9", "", "", nil, true},
+		{"linux", "firefox", "firefox", []string{page}, false},
+		{"linux", "  firefox --new-window : chromium", "firefox", []string{"--new-window", page}, false},
+		{"darwin", "w3m", "w3m", []string{page}, false},
+		{"plan9", "w3m", "w3m", []string{page}, false},
+		// Windows doesn't have $BROWSER.
+		{"windows", "firefox", "cmd", []string{"/c", "start", page}, false},
+		{"linux", " : ", "xdg-open", []string{page}, false},
+	}
+	for _, tt := range tests {
+		name, args, err := BrowserCommand(tt.goos, tt.browser, page)
+		if name != tt.name || !reflect.DeepEqual(args, tt.args) || (err != nil) != tt.err {
+			t.Errorf("BrowserCommand(%q, %q) = %q, %q, %v; want %q, %q, error %v", tt.goos, tt.browser, name, args, err, tt.name, tt.args, tt.err)
+		}
+	}
+}
+
+func TestOpenInBrowserRunsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("$BROWSER isn't used on Windows")
+	}
+	var ran []string
+	run := RunCommand
+	RunCommand = func(name string, args ...string) error {
+		ran = append([]string{name}, args...)
+		return nil
+	}
+	defer func() { RunCommand = run }()
+	t.Setenv("BROWSER", "lynx -accept_all_cookies")
+
+	if err := OpenInBrowser("https://wiki.archlinux.org/title/Pacman"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"lynx", "-accept_all_cookies", "https://wiki.archlinux.org/title/Pacman"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+}
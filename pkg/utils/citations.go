@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// citationRegex matches inline citation markers such as "[1]", "[a]", "[note 3]" and
+// "[citation needed]".
+var citationRegex = regexp.MustCompile(`\[(\d+|[a-z]|note \d+|citation needed)\]`)
+
+// StripCitations removes inline citation markers from article text.
+func StripCitations(text string) string {
+	return citationRegex.ReplaceAllString(text, "")
+}
+
+// CitationNumbers returns the spans of the numbered citation markers in text, such as "[12]",
+// and the reference number of each.
+func CitationNumbers(text string) (spans [][]int, numbers []int) {
+	for _, match := range citationRegex.FindAllStringSubmatchIndex(text, -1) {
+		n, err := strconv.Atoi(text[match[2]:match[3]])
+		if err != nil {
+			continue
+		}
+		spans = append(spans, match[:2])
+		numbers = append(numbers, n)
+	}
+	return spans, numbers
+}
+
+// FindReference returns the offset of the line holding the entry for reference number n in
+// a numbered list, searching from offset from, or -1 if there is none.
+func FindReference(text string, from, n int) int {
+	entry := regexp.MustCompile(`(?m)^ *` + strconv.Itoa(n) + `\. `)
+	loc := entry.FindStringIndex(text[from:])
+	if loc == nil {
+		return -1
+	}
+	return from + loc[0]
+}
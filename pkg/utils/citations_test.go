@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripCitations(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Go is fast.[1] It is typed.[12]", "Go is fast. It is typed."},
+		{"Designed in 2007[a][note 3] at Google.", "Designed in 2007 at Google."},
+		{"Widely used.[citation needed]", "Widely used."},
+		// Bracketed text that isn't a marker is left alone.
+		{"See [Go], [AB] or [10%] instead.", "See [Go], [AB] or [10%] instead."},
+	}
+	for _, tt := range tests {
+		if got := StripCitations(tt.in); got != tt.want {
+			t.Errorf("StripCitations(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCitationNumbers(t *testing.T) {
+	text := "Go[1] is typed[a].[citation needed] Fast.[12]"
+	spans, numbers := CitationNumbers(text)
+	if want := [][]int{{2, 5}, {41, 45}}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("spans = %v, want %v", spans, want)
+	}
+	if want := []int{1, 12}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("numbers = %v, want %v", numbers, want)
+	}
+}
+
+func TestFindReference(t *testing.T) {
+	text := "Go[2] is typed.\n\nReferences\n\n 1. Pike, Rob.\n 2. Griesemer, Robert.\n 12. Thompson, Ken.\n"
+	from := len("Go[2] is typed.\n\nReferences")
+	tests := []struct {
+		n, from, want int
+	}{
+		{1, from, 29},
+		{2, from, 44},
+		{12, from, 67},
+		{3, from, -1},
+		// Entries before from are not found.
+		{1, 67, -1},
+	}
+	for _, tt := range tests {
+		if got := FindReference(text, tt.from, tt.n); got != tt.want {
+			t.Errorf("FindReference(%d from %d) = %d, want %d", tt.n, tt.from, got, tt.want)
+		}
+	}
+}
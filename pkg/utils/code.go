@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// CodeFence opens and closes the code blocks marked by MarkCodeBlocks. An opening fence may
+// be followed by the block's language, e.g. "```bash".
+const CodeFence = "```"
+
+// tabWidth is the tab stop used when code blocks are expanded for display.
+const tabWidth = 8
+
+// MarkCodeBlocks puts every preformatted block in an HTML fragment between code fences on
+// their own lines, naming the language when the wiki's syntax highlighter recorded it. This
+// keeps code recognizable once the HTML is flattened to text, so it can be shown verbatim.
+func MarkCodeBlocks(htmlContent string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		markCode(node)
+		if err := html.Render(&buf, node); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// markCode fences the pre elements in the tree below n.
+func markCode(n *html.Node) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Pre {
+		n.InsertBefore(&html.Node{Type: html.TextNode, Data: "\n" + CodeFence + codeLanguage(n) + "\n"}, n.FirstChild)
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: "\n" + CodeFence + "\n"})
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		markCode(c)
+	}
+}
+
+// codeLanguage finds the language of a pre element in the classes MediaWiki's
+// SyntaxHighlight extension ("mw-highlight-lang-bash") or other highlighters ("language-bash")
+// put on it, its wrapper or its code element. It is empty when no language is given.
+func codeLanguage(pre *html.Node) string {
+	candidates := []*html.Node{pre, pre.Parent}
+	if c := pre.FirstChild; c != nil && c.DataAtom == atom.Code {
+		candidates = append(candidates, c)
+	}
+	for _, n := range candidates {
+		if n == nil {
+			continue
+		}
+		for _, attr := range n.Attr {
+			if attr.Key != "class" {
+				continue
+			}
+			for _, class := range strings.Fields(attr.Val) {
+				for _, prefix := range []string{"mw-highlight-lang-", "language-", "lang-"} {
+					if lang, ok := strings.CutPrefix(class, prefix); ok && lang != "" {
+						return lang
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// TextBlock is a run of article text: prose, or a code block to be shown verbatim.
+type TextBlock struct {
+	Text string
+	Code bool
+	// Lang is the language of a code block, or empty if it is unknown.
+	Lang string
+}
+
+// SplitCodeBlocks splits text at the code fences added by MarkCodeBlocks, dropping the
+// fence lines. A block that is never closed runs to the end of the text, and text without
+// fences is returned as a single prose block.
+func SplitCodeBlocks(text string) []TextBlock {
+	var blocks []TextBlock
+	current := TextBlock{}
+	var lines []string
+	flush := func() {
+		current.Text = strings.Join(lines, "\n")
+		if current.Code {
+			// The highlighter's own trailing newline would show as an empty line.
+			current.Text = strings.TrimRight(current.Text, "\n")
+		}
+		if current.Text != "" || current.Code {
+			blocks = append(blocks, current)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fence := strings.TrimSpace(line)
+		if !strings.HasPrefix(fence, CodeFence) || (current.Code && fence != CodeFence) || strings.ContainsAny(fence, " \t") {
+			lines = append(lines, line)
+			continue
+		}
+		flush()
+		if current.Code {
+			current = TextBlock{}
+		} else {
+			current = TextBlock{Code: true, Lang: strings.TrimPrefix(fence, CodeFence)}
+		}
+	}
+	flush()
+	if len(blocks) == 0 {
+		return []TextBlock{{Text: text}}
+	}
+	return blocks
+}
+
+// ExpandTabs replaces the tabs in each line of code with spaces up to the next tab stop, so
+// the code lines up the same way in every terminal.
+func ExpandTabs(code string) string {
+	if !strings.Contains(code, "\t") {
+		return code
+	}
+	var sb strings.Builder
+	column := 0
+	for _, r := range code {
+		switch r {
+		case '\t':
+			spaces := tabWidth - column%tabWidth
+			sb.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		case '\n':
+			sb.WriteRune(r)
+			column = 0
+		default:
+			sb.WriteRune(r)
+			column++
+		}
+	}
+	return sb.String()
+}
+
+// CodeBlock is where a code block ended up in rendered text, as start and end indexes.
+// Quotations are located the same way, with QuoteLang as their language.
+type CodeBlock struct {
+	Start, End int
+	Lang       string
+}
+
+// CodeStyleExists reports whether name is one of chroma's highlighting styles, such as
+// "monokai" or "github".
+func CodeStyleExists(name string) bool {
+	_, ok := styles.Registry[strings.ToLower(name)]
+	return ok
+}
+
+// HighlightCode colors code with the named chroma style. The language is guessed from the
+// code when lang is empty or unknown. Each line carries its own color codes, like the spans
+// of HighlightText, and code is returned as is when colors are disabled or it can't be lexed.
+func HighlightCode(code, lang, style string) string {
+	if color.NoColor || code == "" {
+		return code
+	}
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return code
+	}
+	formatter := formatters.TTY256
+	var lines []string
+	for _, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		var buf bytes.Buffer
+		// SplitTokensIntoLines keeps each line's newline, which is added back by the join.
+		if n := len(tokens); n > 0 {
+			tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "\n")
+		}
+		if err := formatter.Format(&buf, styles.Get(style), chroma.Literator(tokens...)); err != nil {
+			return code
+		}
+		lines = append(lines, buf.String())
+	}
+	highlighted := strings.Join(lines, "\n")
+	if strings.HasSuffix(code, "\n") {
+		highlighted += "\n"
+	}
+	return highlighted
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+)
+
+// bashBlock is a code block as MediaWiki's SyntaxHighlight extension renders it.
+const bashBlock = `<p>Create the timer:</p>` +
+	`<div class="mw-highlight mw-highlight-lang-bash mw-content-ltr" dir="ltr"><pre><span></span><span class="c1"># systemctl enable --now backup.timer</span>` + "\n" +
+	`<span class="k">for</span> unit <span class="k">in</span> *.timer<span class="p">;</span> <span class="k">do</span>` + "\n" +
+	"\tsystemctl status <span class=\"s2\">\"</span><span class=\"nv\">$unit</span><span class=\"s2\">\"</span>\n" +
+	`<span class="k">done</span>` + "\n" + `</pre></div><p>Then check it.</p>`
+
+func TestMarkCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name, html, fence string
+	}{
+		{"SyntaxHighlight wrapper", bashBlock, "```bash"},
+		{"class on the code element", `<pre><code class="language-ini">[Timer]</code></pre>`, "```ini"},
+		{"no language", `<pre>ls -l</pre>`, "```"},
+	}
+	for _, tt := range tests {
+		marked, err := MarkCodeBlocks(tt.html)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !strings.Contains(marked, "\n"+tt.fence+"\n") || strings.Count(marked, CodeFence) != 2 {
+			t.Errorf("%s: %q, want the block between a %q and a closing fence", tt.name, marked, tt.fence)
+		}
+	}
+}
+
+func TestSplitCodeBlocks(t *testing.T) {
+	text := "Create the timer:\n```bash\n# systemctl enable --now backup.timer\nfor unit in *.timer; do\n\tsystemctl status \"$unit\"\ndone\n\n```\nThen check it.\n```\nunclosed"
+	want := []TextBlock{
+		{Text: "Create the timer:"},
+		{Text: "# systemctl enable --now backup.timer\nfor unit in *.timer; do\n\tsystemctl status \"$unit\"\ndone", Code: true, Lang: "bash"},
+		{Text: "Then check it."},
+		{Text: "unclosed", Code: true},
+	}
+	if got := SplitCodeBlocks(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitCodeBlocks() = %+v, want %+v", got, want)
+	}
+	// Fences inside prose, with text after them, don't start a block.
+	prose := "Wrap code in ``` fences like ```this```."
+	if got := SplitCodeBlocks(prose); !reflect.DeepEqual(got, []TextBlock{{Text: prose}}) {
+		t.Errorf("SplitCodeBlocks(%q) = %+v, want it as prose", prose, got)
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"\tsystemctl", "        systemctl"},
+		{"do\tdone", "do      done"},
+		{"12345678\tx\n\ty", "12345678        x\n        y"},
+		{"no tabs", "no tabs"},
+	}
+	for _, tt := range tests {
+		if got := ExpandTabs(tt.in); got != tt.want {
+			t.Errorf("ExpandTabs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightCode(t *testing.T) {
+	code := "for unit in *.timer; do\n    systemctl status \"$unit\"\ndone\n"
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	color.NoColor = true
+	if got := HighlightCode(code, "bash", "monokai"); got != code {
+		t.Errorf("HighlightCode() without colors = %q, want the code as is", got)
+	}
+
+	color.NoColor = false
+	for _, lang := range []string{"bash", "", "no-such-language"} {
+		highlighted := HighlightCode(code, lang, "monokai")
+		if highlighted == code {
+			t.Errorf("%q: code not highlighted", lang)
+		}
+		if got := ansi.Strip(highlighted); got != code {
+			t.Errorf("%q: highlighted code reads %q, want %q", lang, got, code)
+		}
+		// Each line carries its own colors, so wrapping and scrolling can't bleed them.
+		for _, line := range strings.Split(highlighted, "\n") {
+			if strings.Count(line, "\x1b[0m") < strings.Count(line, "\x1b[38") {
+				t.Errorf("%q: line %q leaves a color open", lang, line)
+			}
+		}
+	}
+}
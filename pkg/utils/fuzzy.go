@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FindApproxMatches returns the [start, end] byte spans of passages in content that
+// approximately match query, tolerating about one typo per four characters. Candidates start at
+// word boundaries and span as many characters as the query; case is ignored. Unlike
+// FindMatchSpans, the result is ranked: closest matches come first, ties are ordered by
+// position.
+func FindApproxMatches(content, query string) [][]int {
+	want := []rune(strings.ToLower(query))
+	if len(want) == 0 {
+		return nil
+	}
+	maxDistance := max(1, len(want)/4)
+
+	// offsets holds the byte offset of every rune in content, and of its end.
+	var runes []rune
+	var offsets []int
+	for i, r := range content {
+		runes = append(runes, unicode.ToLower(r))
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(content))
+
+	type candidate struct {
+		span     []int
+		distance int
+	}
+	var candidates []candidate
+	next := 0
+	for i, r := range runes {
+		prev := ' '
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i < next || isWordRune(prev) || !isWordRune(r) || i+len(want) > len(runes) {
+			continue
+		}
+		if d := levenshtein(want, runes[i:i+len(want)]); d <= maxDistance {
+			candidates = append(candidates, candidate{span: []int{offsets[i], offsets[i+len(want)]}, distance: d})
+			next = i + len(want)
+		}
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].distance < candidates[b].distance
+	})
+	spans := make([][]int, len(candidates))
+	for i, c := range candidates {
+		spans[i] = c.span
+	}
+	return spans
+}
+
+// isWordRune reports whether r can be part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next := min(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = row[j]
+			row[j] = next
+		}
+	}
+	return row[len(b)]
+}
+
+// FuzzyFilter ranks candidates by how well they match query as a command palette does: the
+// query's characters must appear in a candidate in order, ignoring case and spaces, so "erly"
+// finds "Early years". Matches at word starts and runs of consecutive characters rank higher.
+// It returns the indexes of the matching candidates, best first, ties in their original order;
+// an empty query matches every candidate.
+func FuzzyFilter(candidates []string, query string) []int {
+	type ranked struct {
+		index int
+		score int
+	}
+	var matches []ranked
+	for i, candidate := range candidates {
+		if score, ok := fuzzyScore(candidate, query); ok {
+			matches = append(matches, ranked{index: i, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].score > matches[b].score
+	})
+	indexes := make([]int, len(matches))
+	for i, match := range matches {
+		indexes[i] = match.index
+	}
+	return indexes
+}
+
+// fuzzyScore reports whether the characters of query appear in candidate in order, and how
+// well they match. Characters are matched greedily, from left to right.
+func fuzzyScore(candidate, query string) (int, bool) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), ""))
+	want := []rune(query)
+	if len(want) == 0 {
+		return 0, true
+	}
+	score := 0
+	next := 0
+	prev := ' '
+	consecutive := false
+	for _, r := range strings.ToLower(candidate) {
+		atWordStart := !isWordRune(prev) && isWordRune(r)
+		prev = r
+		if next == len(want) {
+			break
+		}
+		if r != want[next] {
+			consecutive = false
+			continue
+		}
+		score++
+		if atWordStart {
+			score += 2
+		}
+		if consecutive {
+			score += 3
+		}
+		consecutive = true
+		next++
+	}
+	return score, next == len(want)
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindApproxMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    [][]int
+	}{
+		{"exact", "the history of go", "history", [][]int{{4, 11}}},
+		{"typo", "the histary of go", "history", [][]int{{4, 11}}},
+		{"closest first", "histary and history", "history", [][]int{{12, 19}, {0, 7}}},
+		{"case", "HISTORY", "history", [][]int{{0, 7}}},
+		// "İ" shrinks when lowercased; the span must still cover the original bytes.
+		{"changing width", "İstanbul ist", "istanbul", [][]int{{0, 9}}},
+		{"multibyte", "über straße", "strase", [][]int{{6, 13}}},
+		{"no match", "nothing here", "history", nil},
+		{"empty query", "anything", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindApproxMatches(tt.content, tt.query)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindApproxMatches(%q, %q) = %v, want %v", tt.content, tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLText flattens an HTML fragment to its text, as readability does for the content it
+// keeps, but without dropping anything but scripts and styles. Block elements such as list
+// items and table rows start on a line of their own, and paragraphs and headings are followed
+// by a blank line; line breaks become newlines.
+func HTMLText(htmlContent string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, node := range nodes {
+		writeText(&sb, node)
+	}
+	return strings.TrimLeft(sb.String(), "\n"), nil
+}
+
+// blockElements start on a line of their own; the value is how many newlines end them, 2 for
+// those that are followed by a blank line.
+var blockElements = map[atom.Atom]int{
+	atom.Address: 1, atom.Article: 1, atom.Aside: 1, atom.Caption: 1, atom.Dd: 1, atom.Div: 1,
+	atom.Dt: 1, atom.Figcaption: 1, atom.Figure: 1, atom.Footer: 1, atom.Header: 1, atom.Hr: 1,
+	atom.Li: 1, atom.Main: 1, atom.Nav: 1, atom.Section: 1, atom.Tr: 1,
+	atom.Blockquote: 2, atom.Dl: 2, atom.H1: 2, atom.H2: 2, atom.H3: 2, atom.H4: 2, atom.H5: 2,
+	atom.H6: 2, atom.Ol: 2, atom.P: 2, atom.Pre: 2, atom.Table: 2, atom.Ul: 2,
+}
+
+// writeText writes the text in the tree below n.
+func writeText(sb *strings.Builder, n *html.Node) {
+	switch {
+	case n.Type == html.TextNode:
+		// The whitespace between blocks would only add to the lines that end them.
+		if strings.TrimSpace(n.Data) == "" && strings.HasSuffix(sb.String(), "\n") {
+			return
+		}
+		sb.WriteString(n.Data)
+		return
+	case n.Type != html.ElementNode:
+	case n.DataAtom == atom.Script || n.DataAtom == atom.Style:
+		return
+	case n.DataAtom == atom.Br:
+		sb.WriteString("\n")
+	case n.DataAtom == atom.Td || n.DataAtom == atom.Th:
+		// Cells of a row are kept apart by a space.
+		if text := sb.String(); text != "" && !strings.HasSuffix(text, "\n") && !strings.HasSuffix(text, " ") {
+			sb.WriteString(" ")
+		}
+	}
+	newlines, block := blockElements[n.DataAtom]
+	if block {
+		endLine(sb, 1)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(sb, c)
+	}
+	if block {
+		endLine(sb, newlines)
+	}
+}
+
+// endLine ends the text written so far with at least n newlines, unless nothing was written.
+func endLine(sb *strings.Builder, n int) {
+	text := strings.TrimRight(sb.String(), " \t")
+	if text == "" {
+		return
+	}
+	have := len(text) - len(strings.TrimRight(text, "\n"))
+	if have == len(text) {
+		return
+	}
+	sb.WriteString(strings.Repeat("\n", max(0, n-have)))
+}
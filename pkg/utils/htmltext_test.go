@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestHTMLText(t *testing.T) {
+	tests := []struct {
+		html, want string
+	}{
+		{"<p>One.</p><p>Two.</p>", "One.\n\nTwo.\n\n"},
+		{"<p>One.</p>\n<p>Two.</p>\n", "One.\n\nTwo.\n\n"},
+		{"<h2>History</h2><p>Designed in <b>2007</b>.</p>", "History\n\nDesigned in 2007.\n\n"},
+		{"<ul><li>Go</li><li>C</li></ul><p>After.</p>", "Go\nC\n\nAfter.\n\n"},
+		{"<div>Box</div><div>Other box</div>", "Box\nOther box\n"},
+		{"<table><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></table>", "a b\nc d\n\n"},
+		{"Line<br>break", "Line\nbreak"},
+		{"<p>Kept</p><script>dropped()</script><style>p {}</style>", "Kept\n\n"},
+		{"<span>inline</span> <i>text</i>", "inline text"},
+	}
+	for _, tt := range tests {
+		got, err := HTMLText(tt.html)
+		if err != nil || got != tt.want {
+			t.Errorf("HTMLText(%q) = %q, %v; want %q", tt.html, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// doiRegex matches a DOI such as 10.1000/xyz123. Where it ends is settled by trimDOI, as a
+// DOI may contain punctuation that also ends sentences.
+var doiRegex = regexp.MustCompile(`\b10\.\d{4,9}/[^\s"<>]+`)
+
+// isbnRegex matches an ISBN-10 or ISBN-13 with its "ISBN" label, grouped with hyphens or
+// spaces in any way; validISBN checks the digits. The label keeps phone numbers and other
+// runs of digits from being taken for ISBNs.
+var isbnRegex = regexp.MustCompile(`\bISBN(?:-1[03])?:?\s*(?:97[89][-\s]?)?(?:\d[-\s]?){9}[\dXx]\b`)
+
+// FindIdentifiers returns the start and end index of every DOI and valid ISBN in content,
+// in order, leaving out those inside the spans of skip, such as the DOI in a doi.org link.
+func FindIdentifiers(content string, skip [][]int) [][]int {
+	outside := func(span []int) bool {
+		for _, s := range skip {
+			if span[0] < s[1] && s[0] < span[1] {
+				return false
+			}
+		}
+		return true
+	}
+	var dois, isbns [][]int
+	for _, span := range doiRegex.FindAllStringIndex(content, -1) {
+		span[1] = span[0] + len(trimDOI(content[span[0]:span[1]]))
+		if outside(span) {
+			dois = append(dois, span)
+		}
+	}
+	for _, span := range isbnRegex.FindAllStringIndex(content, -1) {
+		if validISBN(isbnDigits(content[span[0]:span[1]])) && outside(span) {
+			isbns = append(isbns, span)
+		}
+	}
+	return MergeSpans(dois, isbns)
+}
+
+// MergeSpans returns the spans of a and b together, ordered by where they start.
+func MergeSpans(a, b [][]int) [][]int {
+	merged := make([][]int, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		if len(b) == 0 || (len(a) > 0 && a[0][0] <= b[0][0]) {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	return merged
+}
+
+// IdentifierURL returns the address that resolves a DOI or ISBN as found by FindIdentifiers:
+// doi.org for a DOI, and a search of Open Library for the book of an ISBN.
+func IdentifierURL(identifier string) (string, bool) {
+	if doiRegex.MatchString(identifier) && strings.HasPrefix(identifier, "10.") {
+		return "https://doi.org/" + (&url.URL{Path: trimDOI(identifier)}).EscapedPath(), true
+	}
+	if isbnRegex.MatchString(identifier) {
+		if digits := isbnDigits(identifier); validISBN(digits) {
+			return "https://openlibrary.org/search?isbn=" + digits, true
+		}
+	}
+	return "", false
+}
+
+// trimDOI drops the punctuation that follows a DOI in running text: a full stop or comma, a
+// quote, or a closing bracket that doesn't close one opened in the DOI.
+func trimDOI(doi string) string {
+	for doi != "" {
+		last := doi[len(doi)-1]
+		switch {
+		case strings.IndexByte(".,;:'!?", last) >= 0:
+		case last == ')' && strings.Count(doi, "(") < strings.Count(doi, ")"):
+		case last == ']' && strings.Count(doi, "[") < strings.Count(doi, "]"):
+		default:
+			return doi
+		}
+		doi = doi[:len(doi)-1]
+	}
+	return doi
+}
+
+// isbnDigits returns the digits of an ISBN, with a check digit X in upper case, dropping the
+// label and the grouping.
+func isbnDigits(isbn string) string {
+	_, number, _ := strings.Cut(isbn, "ISBN")
+	if rest, ok := strings.CutPrefix(number, "-1"); ok && len(rest) > 0 {
+		number = rest[1:]
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == 'x' || r == 'X':
+			return 'X'
+		}
+		return -1
+	}, number)
+}
+
+// validISBN reports whether digits form an ISBN-10 or ISBN-13 with a correct check digit.
+func validISBN(digits string) bool {
+	sum := 0
+	switch len(digits) {
+	case 10:
+		for i, r := range digits {
+			d := int(r - '0')
+			if r == 'X' {
+				if i != 9 {
+					return false
+				}
+				d = 10
+			}
+			sum += (10 - i) * d
+		}
+		return sum%11 == 0
+	case 13:
+		for i, r := range digits {
+			if r == 'X' {
+				return false
+			}
+			d := int(r - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	return false
+}
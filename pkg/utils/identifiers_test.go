@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindIdentifiers(t *testing.T) {
+	tests := []struct {
+		name, content string
+		skip          [][]int
+		want          []string
+	}{
+		{"DOI ending a sentence", "Published as doi:10.1000/xyz123.", nil, []string{"10.1000/xyz123"}},
+		{"DOI in brackets", "(see 10.1038/nphys1170) and [10.1000/abc(1)def].", nil, []string{"10.1038/nphys1170", "10.1000/abc(1)def"}},
+		{"DOI inside a link", "https://doi.org/10.1000/xyz123 is resolved.", [][]int{{0, 30}}, nil},
+		{"ISBN-13", "Printed as ISBN 978-0-306-40615-7, in paper.", nil, []string{"ISBN 978-0-306-40615-7"}},
+		{"ISBN-10 with check digit X", "ISBN-10: 0-8044-2957-X and ISBN 0 306 40615 2", nil, []string{"ISBN-10: 0-8044-2957-X", "ISBN 0 306 40615 2"}},
+		{"wrong check digit", "ISBN 978-0-306-40615-8", nil, nil},
+		{"digits without a label", "Call 030640615 2 or 978-0-306-40615-7.", nil, nil},
+		{"in order of appearance", "ISBN 9780306406157 cites 10.1000/xyz123.", nil, []string{"ISBN 9780306406157", "10.1000/xyz123"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, span := range FindIdentifiers(tt.content, tt.skip) {
+			got = append(got, tt.content[span[0]:span[1]])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: FindIdentifiers(%q) = %q, want %q", tt.name, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifierURL(t *testing.T) {
+	tests := []struct {
+		identifier, want string
+	}{
+		{"10.1000/xyz123", "https://doi.org/10.1000/xyz123"},
+		{"10.1002/(SICI)1097-4571(199806)49:8<693::AID-ASI4>3.0.CO;2-0", "https://doi.org/10.1002/%28SICI%291097-4571%28199806%2949:8%3C693::AID-ASI4%3E3.0.CO;2-0"},
+		{"10.1000/a b#c", "https://doi.org/10.1000/a%20b%23c"},
+		{"ISBN 978-0-306-40615-7", "https://openlibrary.org/search?isbn=9780306406157"},
+		{"ISBN-10: 0-8044-2957-x", "https://openlibrary.org/search?isbn=080442957X"},
+		{"ISBN 978-0-306-40615-8", ""},
+		{"https://example.org/", ""},
+	}
+	for _, tt := range tests {
+		got, ok := IdentifierURL(tt.identifier)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("IdentifierURL(%q) = %q, %v, want %q", tt.identifier, got, ok, tt.want)
+		}
+	}
+}
+
+func TestMergeSpans(t *testing.T) {
+	a := [][]int{{0, 5}, {20, 25}}
+	b := [][]int{{10, 15}, {30, 35}}
+	want := [][]int{{0, 5}, {10, 15}, {20, 25}, {30, 35}}
+	if got := MergeSpans(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeSpans() = %v, want %v", got, want)
+	}
+	if got := MergeSpans(nil, b); !reflect.DeepEqual(got, b) {
+		t.Errorf("MergeSpans(nil, b) = %v, want b", got)
+	}
+}
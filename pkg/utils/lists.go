@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// listBullets are the markers used for unordered list items, by nesting level.
+var listBullets = []string{"•", "-"}
+
+// listItemRegex matches a line produced by MarkListItems: indentation, a marker and a space.
+var listItemRegex = regexp.MustCompile(`^( *)(•|-|\d+\.) `)
+
+// MarkListItems prefixes every list item in an HTML fragment with a bullet (or number for
+// ordered lists) on its own line, indented by indent spaces per nesting level. This keeps the
+// list structure visible once the HTML is flattened to text.
+func MarkListItems(htmlContent string, indent int) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		markList(node, 0, indent)
+		if err := html.Render(&buf, node); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// markList walks the tree below n, where depth is the number of enclosing lists.
+func markList(n *html.Node, depth, indent int) {
+	isList := n.Type == html.ElementNode && (n.Data == "ul" || n.Data == "ol")
+	if isList {
+		depth++
+		number := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				continue
+			}
+			marker := listBullets[(depth-1)%len(listBullets)]
+			if n.Data == "ol" {
+				number++
+				marker = fmt.Sprintf("%d.", number)
+			}
+			prefix := "\n" + strings.Repeat(" ", indent*(depth-1)) + marker + " "
+			c.InsertBefore(&html.Node{Type: html.TextNode, Data: prefix}, c.FirstChild)
+		}
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: "\n"})
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		markList(c, depth, indent)
+	}
+}
+
+// listIndent returns the indentation of a list item line and the hanging indent for its
+// continuation lines. Both are empty for lines that aren't list items.
+func listIndent(line string) (indent, hanging string) {
+	match := listItemRegex.FindStringSubmatch(line)
+	if match == nil {
+		return "", ""
+	}
+	return match[1], match[1] + strings.Repeat(" ", len([]rune(match[2]))+1)
+}
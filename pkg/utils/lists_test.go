@@ -0,0 +1,56 @@
+package utils
+
+import "testing"
+
+func TestMarkListItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		indent int
+		want   string
+	}{
+		{
+			name:   "unordered",
+			html:   "<ul><li>One</li><li>Two</li></ul>",
+			indent: 2,
+			want:   "<ul><li>\n• One</li><li>\n• Two</li>\n</ul>",
+		},
+		{
+			name:   "ordered",
+			html:   "<p>Steps:</p><ol><li>First</li><li>Second</li></ol>",
+			indent: 2,
+			want:   "<p>Steps:</p><ol><li>\n1. First</li><li>\n2. Second</li>\n</ol>",
+		},
+		{
+			name:   "nested",
+			html:   "<ul><li>Fruit<ul><li>Apple</li><li>Pear<ol><li>Conference</li></ol></li></ul></li></ul>",
+			indent: 3,
+			want:   "<ul><li>\n• Fruit<ul><li>\n   - Apple</li><li>\n   - Pear<ol><li>\n      1. Conference</li>\n</ol></li>\n</ul></li>\n</ul>",
+		},
+		{
+			name:   "text without lists",
+			html:   "<p>Plain &amp; simple</p>",
+			indent: 2,
+			want:   "<p>Plain &amp; simple</p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarkListItems(tt.html, tt.indent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("MarkListItems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapTextHangsListItems(t *testing.T) {
+	text := "  - A list item long enough to wrap\n12. Numbered and long enough\nPlain text that wraps too\n"
+	want := "  - A list item\n    long enough\n    to wrap\n12. Numbered\n    and long\n    enough\nPlain text that\nwraps too\n\n"
+	if got := WrapText(text, 15); got != want {
+		t.Errorf("WrapText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// QuoteLang is the language MarkQuotes gives the fenced blocks holding quotations.
+const QuoteLang = "quote"
+
+// quoteBlocks are the elements that start a new line within a quotation, such as its
+// paragraphs and the attribution under them.
+var quoteBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Ul: true, atom.Ol: true, atom.Dl: true,
+	atom.Blockquote: true, atom.Footer: true,
+}
+
+// MarkQuotes puts the text of every blockquote in an HTML fragment between code fences named
+// QuoteLang, with each paragraph on its own line, so quotations can still be set off from the
+// text around them once the HTML is flattened. Quotations holding code or tables are left
+// alone, as fenced blocks can't be nested. It runs before MarkCodeBlocks and MarkTables.
+func MarkQuotes(htmlContent string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		markQuotes(node)
+		if err := html.Render(&buf, node); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// markQuotes fences the blockquotes in the tree below n. Nested quotations are part of the
+// outer one.
+func markQuotes(n *html.Node) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Blockquote {
+		if contains(n, atom.Pre) || contains(n, atom.Table) {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && quoteBlocks[c.DataAtom] {
+				n.InsertBefore(&html.Node{Type: html.TextNode, Data: "\n"}, c)
+			}
+		}
+		n.InsertBefore(&html.Node{Type: html.TextNode, Data: "\n" + CodeFence + QuoteLang + "\n"}, n.FirstChild)
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: "\n" + CodeFence + "\n"})
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		markQuotes(c)
+	}
+}
+
+// contains reports whether the tree below n has an element a.
+func contains(n *html.Node, a atom.Atom) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if (c.Type == html.ElementNode && c.DataAtom == a) || contains(c, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkQuotes(t *testing.T) {
+	tests := []struct {
+		name, html string
+		want       []TextBlock
+	}{
+		{
+			"paragraphs and attribution",
+			`<p>Before.</p><blockquote><p>First paragraph of the quote.</p><p>Second.</p><footer>— Someone</footer></blockquote><p>After.</p>`,
+			[]TextBlock{
+				{Text: "Before."},
+				{Text: "First paragraph of the quote.\n\nSecond.\n\n— Someone", Code: true, Lang: QuoteLang},
+				{Text: "After."},
+			},
+		},
+		{
+			"nested quotation",
+			`<blockquote>Outer <blockquote>inner</blockquote></blockquote>`,
+			[]TextBlock{{Text: "Outer \ninner", Code: true, Lang: QuoteLang}},
+		},
+		{
+			"holding code",
+			`<blockquote><pre>ls -l</pre></blockquote>`,
+			[]TextBlock{{Text: "ls -l"}},
+		},
+	}
+	for _, tt := range tests {
+		marked, err := MarkQuotes(tt.html)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		text, err := HTMLText(marked)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []TextBlock
+		for _, block := range SplitCodeBlocks(text) {
+			block.Text = strings.TrimSpace(block.Text)
+			if block.Text != "" {
+				got = append(got, block)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: blocks = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// abbreviations end with a full stop that rarely ends a sentence, in lower case and without
+// the stop. Single letters, as in initials, are abbreviations too.
+var abbreviations = map[string]bool{
+	"e.g": true, "i.e": true, "etc": true, "vs": true, "cf": true, "approx": true, "ca": true,
+	"c": true, "no": true, "nos": true, "vol": true, "p": true, "pp": true, "ed": true, "eds": true,
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "st": true, "sr": true,
+	"jr": true, "gen": true, "col": true, "lt": true, "sgt": true, "rev": true, "hon": true,
+	"inc": true, "ltd": true, "co": true, "corp": true, "dept": true, "univ": true, "est": true,
+	"fig": true, "al": true, "u.s": true, "u.k": true, "a.d": true, "b.c": true, "jan": true,
+	"feb": true, "mar": true, "apr": true, "jun": true, "jul": true, "aug": true, "sep": true,
+	"sept": true, "oct": true, "nov": true, "dec": true, "mt": true, "ft": true,
+}
+
+// SplitSentences splits text into sentences. A sentence ends at a full stop, question mark or
+// exclamation mark, with any closing quotes or brackets after it, that is followed by
+// whitespace and then an upper-case letter, a digit or an opening quote or bracket. A full
+// stop after an abbreviation such as "e.g." or "Dr." or after an initial doesn't end one.
+func SplitSentences(text string) []string {
+	text = strings.Join(strings.Fields(text), " ")
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if !strings.ContainsRune(".!?", rune(text[i])) {
+			continue
+		}
+		end := i + 1
+		for end < len(text) && strings.ContainsRune(`"')]”’»`, firstRune(text[end:])) {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			end += size
+		}
+		if end < len(text) && (text[end] != ' ' || !startsSentence(text[end+1:])) {
+			continue
+		}
+		if text[i] == '.' && isAbbreviation(text[start:i]) {
+			continue
+		}
+		sentences = append(sentences, strings.TrimSpace(text[start:end]))
+		start = end
+		i = end - 1
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// longLineBytes is the length above which a line is taken for text that lost its paragraph
+// breaks, and paragraphBytes the length BreakLongLines aims for when it puts them back.
+const (
+	longLineBytes  = 3000
+	paragraphBytes = 800
+)
+
+// BreakLongLines splits the lines of text longer than longLineBytes into paragraphs of a few
+// sentences each, separated by blank lines. Some flattened pages come back as one gigantic
+// line, which would otherwise be a single wall of text with no place for headings or section
+// breaks. Only text that has fewer lines than one per longLineBytes is taken for such a page;
+// in any other text a long line is a long paragraph, and kept. Lines inside code fences are
+// kept as they are too.
+func BreakLongLines(text string) string {
+	if strings.Count(text, "\n") >= len(text)/longLineBytes {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), CodeFence) {
+			inCode = !inCode
+			continue
+		}
+		if inCode || len(line) <= longLineBytes {
+			continue
+		}
+		var paragraphs []string
+		var paragraph strings.Builder
+		for _, sentence := range SplitSentences(line) {
+			if paragraph.Len() > 0 && paragraph.Len()+1+len(sentence) > paragraphBytes {
+				paragraphs = append(paragraphs, paragraph.String())
+				paragraph.Reset()
+			}
+			if paragraph.Len() > 0 {
+				paragraph.WriteString(" ")
+			}
+			paragraph.WriteString(sentence)
+		}
+		if paragraph.Len() > 0 {
+			paragraphs = append(paragraphs, paragraph.String())
+		}
+		lines[i] = strings.Join(paragraphs, "\n\n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FirstSentence returns the first sentence of text, as split by SplitSentences.
+func FirstSentence(text string) string {
+	sentences := SplitSentences(text)
+	if len(sentences) == 0 {
+		return ""
+	}
+	return sentences[0]
+}
+
+// startsSentence reports whether text could be the start of a sentence.
+func startsSentence(text string) bool {
+	r := firstRune(text)
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || strings.ContainsRune(`"'(“‘«[`, r)
+}
+
+// isAbbreviation reports whether the text before a full stop ends with an abbreviation.
+func isAbbreviation(before string) bool {
+	word := before[strings.LastIndexAny(before, " (\"")+1:]
+	word = strings.ToLower(word)
+	if utf8.RuneCountInString(word) == 1 {
+		return unicode.IsLetter(firstRune(word))
+	}
+	return abbreviations[word]
+}
+
+// firstRune returns the first rune of s, or utf8.RuneError if s is empty.
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
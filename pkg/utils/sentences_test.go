@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// flattened returns a page of n sentences that lost its paragraph breaks.
+func flattened(n int) string {
+	var sentences []string
+	for i := range n {
+		sentences = append(sentences, fmt.Sprintf("Sentence %d says something about the subject.", i+1))
+	}
+	return strings.Join(sentences, " ")
+}
+
+func TestBreakLongLines(t *testing.T) {
+	line := flattened(220)
+	if len(line) < 10000 {
+		t.Fatalf("test line is only %d bytes", len(line))
+	}
+	got := BreakLongLines(line)
+	paragraphs := strings.Split(got, "\n\n")
+	if len(paragraphs) < len(line)/paragraphBytes {
+		t.Errorf("%d-byte line broken into %d paragraphs", len(line), len(paragraphs))
+	}
+	for _, paragraph := range paragraphs {
+		if len(paragraph) > paragraphBytes || strings.Contains(paragraph, "\n") {
+			t.Errorf("paragraph of %d bytes: %q", len(paragraph), paragraph)
+		}
+	}
+	if !slices.Equal(strings.Fields(got), strings.Fields(line)) {
+		t.Error("breaking the line changed its words")
+	}
+}
+
+func TestBreakLongLinesKeepsStructuredText(t *testing.T) {
+	// A long paragraph in a text with lines enough is a paragraph, not a flattened page.
+	var lines []string
+	for i := range 40 {
+		lines = append(lines, fmt.Sprintf("Paragraph %d.", i+1))
+	}
+	text := strings.Join(append(lines, flattened(80)), "\n")
+	if got := BreakLongLines(text); got != text {
+		t.Errorf("structured text changed:\n%s", got)
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       []string
+	}{
+		{"plain", "Go is a language. It was designed at Google! Is it fast? Yes.", []string{"Go is a language.", "It was designed at Google!", "Is it fast?", "Yes."}},
+		{"abbreviations", "Languages, e.g. Go, compile fast. Dr. Pike designed it at Bell Labs, Inc. in the U.S. in 1980.", []string{"Languages, e.g. Go, compile fast.", "Dr. Pike designed it at Bell Labs, Inc. in the U.S. in 1980."}},
+		{"initials", "J. R. R. Tolkien wrote it. It sold well.", []string{"J. R. R. Tolkien wrote it.", "It sold well."}},
+		{"closing quotes and brackets", `He said "It works." (It did.) Then he left.`, []string{`He said "It works."`, "(It did.)", "Then he left."}},
+		{"next sentence in lower case", "Version 1.0 was released in Mar. 2012. the next one was not.", []string{"Version 1.0 was released in Mar. 2012. the next one was not."}},
+		{"starting with a digit or quote", "It ended in 2009. 2010 was quieter. “Go” stuck.", []string{"It ended in 2009.", "2010 was quieter.", "“Go” stuck."}},
+		{"line breaks and spacing", "First   line\nwraps here.\n\nSecond.", []string{"First line wraps here.", "Second."}},
+		{"no final stop", "Go is a language. Designed at Google", []string{"Go is a language.", "Designed at Google"}},
+		{"empty", " \n ", nil},
+	}
+	for _, tt := range tests {
+		if got := SplitSentences(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: SplitSentences(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFirstSentence(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Go (game) is an abstract strategy board game for two players. The aim is to surround more territory.", "Go (game) is an abstract strategy board game for two players."},
+		// An abbreviation ending a sentence is taken for one inside it.
+		{"St. Louis is a city in Missouri, U.S. It is on the Mississippi.", "St. Louis is a city in Missouri, U.S. It is on the Mississippi."},
+		{"No stop at all", "No stop at all"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := FirstSentence(tt.text); got != tt.want {
+			t.Errorf("FirstSentence(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
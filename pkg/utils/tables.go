@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TableLang is the language MarkTables gives the fenced blocks holding tables.
+const TableLang = "wikitable"
+
+// minColumnWidth is the narrowest a column is squeezed to when a table is too wide.
+const minColumnWidth = 3
+
+// Row kinds as encoded by MarkTables at the start of each row.
+const (
+	headerRow = "h"
+	dataRow   = "d"
+)
+
+// MarkTables replaces every table of class "wikitable" in an HTML fragment with a fenced
+// block holding its cells, one row per line, so tables survive flattening to text and can be
+// laid out as aligned columns once the width is known. Cells spanning several columns or rows
+// are followed by empty cells, keeping the columns aligned. It runs after MarkCodeBlocks, which
+// would otherwise fence the tables again.
+func MarkTables(htmlContent string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", err
+	}
+	// Put the nodes under a common parent, so tables at the top level are replaced too.
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+	markTables(body)
+	var buf bytes.Buffer
+	for node := body.FirstChild; node != nil; node = node.NextSibling {
+		if err := html.Render(&buf, node); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// markTables replaces the wikitables in the tree below n.
+func markTables(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && c.DataAtom == atom.Table && hasClass(c, "wikitable") {
+			pre := &html.Node{Type: html.ElementNode, Data: "pre", DataAtom: atom.Pre}
+			pre.AppendChild(&html.Node{Type: html.TextNode, Data: "\n" + CodeFence + TableLang + "\n" + encodeTable(c) + CodeFence + "\n"})
+			n.InsertBefore(pre, c)
+			n.RemoveChild(c)
+		} else {
+			markTables(c)
+		}
+		c = next
+	}
+}
+
+// hasClass reports whether n has the given class.
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" && strings.Contains(" "+attr.Val+" ", " "+class+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeTable writes the rows of a table, each a row kind followed by its cells, separated by
+// tabs. Cell text is collapsed to a single line, so it holds no tabs or line breaks.
+func encodeTable(table *html.Node) string {
+	var sb strings.Builder
+	// spans holds, per column, how many more rows a cell above still covers.
+	var spans []int
+	for _, row := range tableRows(table) {
+		var cells []string
+		kind := headerRow
+		column := 0
+		skipSpanned := func() {
+			for column < len(spans) && spans[column] > 0 {
+				spans[column]--
+				cells = append(cells, "")
+				column++
+			}
+		}
+		for c := row.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+				continue
+			}
+			if c.DataAtom == atom.Td {
+				kind = dataRow
+			}
+			skipSpanned()
+			colspan := max(1, spanAttr(c, "colspan"))
+			rowspan := max(1, spanAttr(c, "rowspan"))
+			for i := range colspan {
+				if i == 0 {
+					cells = append(cells, SingleLine(textContent(c)))
+				} else {
+					cells = append(cells, "")
+				}
+				for len(spans) <= column {
+					spans = append(spans, 0)
+				}
+				spans[column] = rowspan - 1
+				column++
+			}
+		}
+		skipSpanned()
+		if len(cells) == 0 {
+			continue
+		}
+		sb.WriteString(kind + "\t" + strings.Join(cells, "\t") + "\n")
+	}
+	return sb.String()
+}
+
+// tableRows returns the rows of a table, including those in its head, body and foot but not
+// those of nested tables.
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Tr:
+				rows = append(rows, c)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			}
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// spanAttr returns the numeric value of a colspan or rowspan attribute, or 0 if it is missing
+// or invalid. Absurd spans are capped, so a broken table can't blow up the layout.
+func spanAttr(n *html.Node, key string) int {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			value, err := strconv.Atoi(strings.TrimSpace(attr.Val))
+			if err != nil {
+				return 0
+			}
+			return min(value, 100)
+		}
+	}
+	return 0
+}
+
+// textContent returns the text below n, with line breaks as spaces and reference markers
+// left in for the caller to strip like those in prose.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Style || n.DataAtom == atom.Script):
+			return
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// Table is a table decoded from a block marked by MarkTables.
+type Table struct {
+	Rows [][]string
+	// Header is the number of leading rows made of header cells only.
+	Header int
+}
+
+// ParseTable decodes the text of a table block. Lines that aren't rows are ignored.
+func ParseTable(text string) Table {
+	var table Table
+	inHeader := true
+	for _, line := range strings.Split(text, "\n") {
+		kind, cells, ok := strings.Cut(line, "\t")
+		if !ok || (kind != headerRow && kind != dataRow) {
+			continue
+		}
+		if kind != headerRow {
+			inHeader = false
+		} else if inHeader {
+			table.Header++
+		}
+		table.Rows = append(table.Rows, strings.Split(cells, "\t"))
+	}
+	return table
+}
+
+// Map returns a copy of the table with f applied to every cell, e.g. to strip citations.
+func (t Table) Map(f func(string) string) Table {
+	rows := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			rows[i][j] = f(cell)
+		}
+	}
+	return Table{Rows: rows, Header: t.Header}
+}
+
+// RenderTable lays a table out in aligned columns separated by " │ ", with a rule under the
+// header rows. Columns are narrowed, widest first, until the table fits width; cells that no
+// longer fit are cut off with an ellipsis. A table that can't fit even then is drawn wider, for
+// the viewport to cut off.
+func RenderTable(table Table, width int) string {
+	columns := 0
+	for _, row := range table.Rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+	widths := make([]int, columns)
+	for _, row := range table.Rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+	const separator = " │ "
+	total := func() int {
+		sum := (columns - 1) * ansi.StringWidth(separator)
+		for _, w := range widths {
+			sum += w
+		}
+		return sum
+	}
+	for width > 0 && total() > width {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+	}
+
+	var sb strings.Builder
+	for i, row := range table.Rows {
+		cells := make([]string, columns)
+		for j := range columns {
+			cell := ""
+			if j < len(row) {
+				cell = ansi.Truncate(row[j], widths[j], "…")
+			}
+			cells[j] = cell + strings.Repeat(" ", widths[j]-ansi.StringWidth(cell))
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(cells, separator), " ") + "\n")
+		if i+1 == table.Header && table.Header < len(table.Rows) {
+			rules := make([]string, columns)
+			for j, w := range widths {
+				rules[j] = strings.Repeat("─", w)
+			}
+			sb.WriteString(strings.Join(rules, "─┼─") + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// planets is a 3×3 wikitable as MediaWiki renders it, with a header row and a citation.
+const planets = `<p>The inner planets:</p><table class="wikitable sortable"><tbody>` +
+	`<tr><th>Planet</th><th>Moons</th><th>Day length</th></tr>` +
+	`<tr><td><a href="/wiki/Mercury_(planet)">Mercury</a></td><td>0</td><td>176 days<sup class="reference">[1]</sup></td></tr>` +
+	`<tr><td>Earth</td><td>1</td><td>24<br>hours</td></tr>` +
+	`</tbody></table><p>Outer planets follow.</p>`
+
+// tableBlock marks the tables of htmlContent and returns the one table block.
+func tableBlock(t *testing.T, htmlContent string) TextBlock {
+	t.Helper()
+	marked, err := MarkTables(htmlContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables []TextBlock
+	for _, block := range SplitCodeBlocks(stripTags(marked)) {
+		if block.Code && block.Lang == TableLang {
+			tables = append(tables, block)
+		}
+	}
+	if len(tables) != 1 {
+		t.Fatalf("%d tables in %q, want 1", len(tables), marked)
+	}
+	return tables[0]
+}
+
+// stripTags flattens HTML to its text, as readability does with the marked page.
+func stripTags(s string) string {
+	var sb strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+		case !inTag:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func TestMarkTables(t *testing.T) {
+	table := ParseTable(tableBlock(t, planets).Text)
+	want := Table{
+		Rows: [][]string{
+			{"Planet", "Moons", "Day length"},
+			{"Mercury", "0", "176 days[1]"},
+			{"Earth", "1", "24 hours"},
+		},
+		Header: 1,
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("table = %+v, want %+v", table, want)
+	}
+
+	// Cells spanning columns or rows are followed by empty cells, keeping columns aligned.
+	spanned := `<table class="wikitable"><tr><th colspan="2">Name</th><th>Moons</th></tr>` +
+		`<tr><td rowspan="2">Gas giants</td><td>Jupiter</td><td>95</td></tr><tr><td>Saturn</td><td>146</td></tr></table>`
+	want = Table{
+		Rows:   [][]string{{"Name", "", "Moons"}, {"Gas giants", "Jupiter", "95"}, {"", "Saturn", "146"}},
+		Header: 1,
+	}
+	if got := ParseTable(tableBlock(t, spanned).Text); !reflect.DeepEqual(got, want) {
+		t.Errorf("spanned table = %+v, want %+v", got, want)
+	}
+
+	// Other tables, such as infoboxes, are left for readability.
+	infobox := `<table class="infobox"><tr><td>Mass</td></tr></table>`
+	if marked, err := MarkTables(infobox); err != nil || strings.Contains(marked, CodeFence) {
+		t.Errorf("MarkTables(%q) = %q, %v; want it unmarked", infobox, marked, err)
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	table := ParseTable(tableBlock(t, planets).Text).Map(StripCitations)
+	want := "" +
+		"Planet  │ Moons │ Day length\n" +
+		"────────┼───────┼───────────\n" +
+		"Mercury │ 0     │ 176 days\n" +
+		"Earth   │ 1     │ 24 hours\n"
+	if got := RenderTable(table, 80); got != want {
+		t.Errorf("RenderTable() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Too wide a table has its widest columns narrowed, cutting off what no longer fits.
+	narrow := RenderTable(table, 24)
+	for _, line := range strings.Split(strings.TrimSuffix(narrow, "\n"), "\n") {
+		if w := ansi.StringWidth(line); w > 24 {
+			t.Errorf("line %q is %d wide, want at most 24", line, w)
+		}
+	}
+	if !strings.Contains(narrow, "…") || !strings.Contains(narrow, "Earth") {
+		t.Errorf("narrowed table:\n%s\nwant cells cut off with an ellipsis", narrow)
+	}
+
+	// Columns aren't squeezed below a few characters; the viewport cuts the rest off.
+	if squeezed := RenderTable(table, 5); !strings.HasPrefix(squeezed, "Pl… │ Mo… │ Da…\n") {
+		t.Errorf("table in 5 columns:\n%s", squeezed)
+	}
+	if got := RenderTable(Table{}, 80); got != "" {
+		t.Errorf("empty table rendered as %q", got)
+	}
+}
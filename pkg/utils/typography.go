@@ -0,0 +1,25 @@
+package utils
+
+import "strings"
+
+// typography replaces typographic characters with the ASCII a keyboard types: curly quotes,
+// primes, dashes and minus signs, the ellipsis, and spaces of other widths. Invisible
+// characters that only guide line breaking are dropped.
+var typography = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "−", "-",
+	"—", "--", "―", "--",
+	"…", "...",
+	// No-break, narrow no-break, en, em, figure and thin spaces.
+	"\u00a0", " ", "\u202f", " ", "\u2002", " ", "\u2003", " ", "\u2007", " ", "\u2009", " ",
+	// Soft hyphen, zero-width space and word joiner.
+	"\u00ad", "", "\u200b", "", "\u2060", "",
+)
+
+// NormalizeTypography converts the typographic quotes, dashes and spaces wiki text is full of
+// to their ASCII equivalents, so a query typed with plain hyphens and quotes finds them and
+// every space is one column wide. Newlines are left alone, so text keeps its lines.
+func NormalizeTypography(s string) string {
+	return typography.Replace(s)
+}
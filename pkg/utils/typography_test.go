@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestNormalizeTypography(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"left single quote", "‘", "'"},
+		{"right single quote", "’", "'"},
+		{"low single quote", "‚", "'"},
+		{"reversed single quote", "‛", "'"},
+		{"prime", "′", "'"},
+		{"left double quote", "“", `"`},
+		{"right double quote", "”", `"`},
+		{"low double quote", "„", `"`},
+		{"reversed double quote", "‟", `"`},
+		{"double prime", "″", `"`},
+		{"hyphen", "‐", "-"},
+		{"non-breaking hyphen", "‑", "-"},
+		{"figure dash", "‒", "-"},
+		{"en dash", "–", "-"},
+		{"minus", "−", "-"},
+		{"em dash", "—", "--"},
+		{"horizontal bar", "―", "--"},
+		{"ellipsis", "…", "..."},
+		{"no-break space", "\u00a0", " "},
+		{"narrow no-break space", "\u202f", " "},
+		{"en space", "\u2002", " "},
+		{"em space", "\u2003", " "},
+		{"figure space", "\u2007", " "},
+		{"thin space", "\u2009", " "},
+		{"soft hyphen", "hy\u00adphen", "hyphen"},
+		{"zero-width space", "zero\u200bwidth", "zerowidth"},
+		{"word joiner", "word\u2060joiner", "wordjoiner"},
+		{"newlines kept", "“One”\n\n‘two’", "\"One\"\n\n'two'"},
+		{"ASCII unchanged", `It's "plain" -- text...`, `It's "plain" -- text...`},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTypography(tt.in); got != tt.want {
+			t.Errorf("%s: NormalizeTypography(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
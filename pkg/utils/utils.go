@@ -1,16 +1,26 @@
 package utils
 
 import (
-	"github.com/fatih/color"
+	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
 )
 
+// ansiReset is the escape sequence that ends all colors and styles.
+const ansiReset = "\x1b[0m"
+
 // FormatText applies basic formatting for readability (e.g., bold for headers).
 func FormatText(text string) string {
 	var formatted strings.Builder
 	lines := strings.Split(text, "\n")
 	for _, line := range lines {
-		if strings.ToUpper(line) == line && len(line) > 0 {
+		if strings.ToUpper(line) == line && len(line) > 0 && !listItemRegex.MatchString(line) {
 			formatted.WriteString(color.New(color.Bold).Sprint(line))
 			formatted.WriteString("\n\n")
 		} else {
@@ -21,81 +31,361 @@ func FormatText(text string) string {
 	return formatted.String()
 }
 
-// FindMatches returns the starting index of all matches
-func FindMatches(content, query string) []int {
-	if query == "" {
+// FindMatchSpans returns the start and end index of every match of query in wrapped text.
+// Matching ignores case, and the spaces between the query's words match any run of
+// whitespace, so a phrase still matches when wrapping or justification split it up.
+func FindMatchSpans(content, query string) [][]int {
+	words := strings.Fields(query)
+	if len(words) == 0 {
 		return nil
 	}
-	var matches []int
-	lowerContent := strings.ToLower(content)
-	lowerQuery := strings.ToLower(query)
-	start := 0
-	for {
-		i := strings.Index(lowerContent[start:], lowerQuery)
-		if i == -1 {
-			break
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`(?i)`+strings.Join(words, `\s+`)).FindAllStringIndex(content, -1)
+}
+
+// FindWordSpans is like FindMatchSpans, but only returns matches that form whole words,
+// so "go" matches "go" and "Go!" but not "going".
+func FindWordSpans(content, query string) [][]int {
+	var spans [][]int
+	for _, span := range FindMatchSpans(content, query) {
+		before, _ := utf8.DecodeLastRuneInString(content[:span[0]])
+		after, _ := utf8.DecodeRuneInString(content[span[1]:])
+		first, _ := utf8.DecodeRuneInString(content[span[0]:])
+		last, _ := utf8.DecodeLastRuneInString(content[:span[1]])
+		if (isWordRune(first) && isWordRune(before)) || (isWordRune(last) && isWordRune(after)) {
+			continue
 		}
-		matches = append(matches, start+i)
-		start += i + 1
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// HighlightStyles holds the colors HighlightText uses for each kind of span.
+// A nil field falls back to the corresponding default style.
+type HighlightStyles struct {
+	Match        *color.Color
+	CurrentMatch *color.Color
+	URL          *color.Color
+	CurrentURL   *color.Color
+	Text         *color.Color
+	// Dim is the color of the text Focus dims.
+	Dim *color.Color
+	// Quote is the style of quotations, which are given as code blocks of QuoteLang.
+	Quote *color.Color
+	// ReadingLine is the background HighlightLine puts behind the reading cursor's line.
+	ReadingLine *color.Color
+	// Identifier is the color of DOIs and ISBNs.
+	Identifier *color.Color
+	// Focus dims everything but the line of the current search match, so the match stands out
+	// in a wall of text. Without a current match nothing is dimmed.
+	Focus bool
+	// HideURLs shows the links that aren't identifiers as their LinkMarker where that is
+	// shorter than the link, so bare URLs don't clutter the prose. Search matches inside
+	// hidden links aren't drawn.
+	HideURLs bool
+	// Code highlights the text of code blocks in a language, which may be empty if unknown.
+	// When it is nil, code is shown in the Text color.
+	Code func(code, lang string) string
+}
+
+// HighlightSpans are the parts of a text HighlightText draws, as start and end byte indexes.
+type HighlightSpans struct {
+	// Matches are search matches; the one at CurrentMatch, if any, is the current one.
+	Matches      [][]int
+	CurrentMatch int
+	// URLs are links; the one at CurrentURL, if any, is the selected one.
+	URLs       [][]int
+	CurrentURL int
+	// Identifiers are the DOIs and ISBNs, which may also be among the URLs.
+	Identifiers [][]int
+	// CodeBlocks locate the code blocks and quotations.
+	CodeBlocks []CodeBlock
+}
+
+// NoSpans returns HighlightSpans with nothing current or selected, to fill in.
+func NoSpans() HighlightSpans {
+	return HighlightSpans{CurrentMatch: -1, CurrentURL: -1}
+}
+
+// DefaultHighlightStyles returns the built-in highlight colors.
+func DefaultHighlightStyles() HighlightStyles {
+	return HighlightStyles{
+		Match:        color.New(color.BgYellow, color.FgBlack),
+		CurrentMatch: color.New(color.BgHiYellow, color.FgBlack),
+		URL:          color.New(color.FgHiBlue),
+		CurrentURL:   color.New(color.BgHiBlue, color.FgBlack),
+		Text:         color.New(color.FgWhite),
+		Dim:          color.New(color.Faint),
+		Quote:        color.New(color.Italic, color.Faint),
+		ReadingLine:  color.New(color.BgHiBlack),
+		Identifier:   color.New(color.FgHiMagenta),
+	}
+}
+
+// withDefaults fills unset styles from DefaultHighlightStyles.
+func (s HighlightStyles) withDefaults() HighlightStyles {
+	defaults := DefaultHighlightStyles()
+	if s.Match == nil {
+		s.Match = defaults.Match
+	}
+	if s.CurrentMatch == nil {
+		s.CurrentMatch = defaults.CurrentMatch
+	}
+	if s.URL == nil {
+		s.URL = defaults.URL
+	}
+	if s.CurrentURL == nil {
+		s.CurrentURL = defaults.CurrentURL
+	}
+	if s.Text == nil {
+		s.Text = defaults.Text
+	}
+	if s.Dim == nil {
+		s.Dim = defaults.Dim
+	}
+	if s.Quote == nil {
+		s.Quote = defaults.Quote
 	}
-	return matches
+	if s.ReadingLine == nil {
+		s.ReadingLine = defaults.ReadingLine
+	}
+	if s.Identifier == nil {
+		s.Identifier = defaults.Identifier
+	}
+	return s
+}
+
+// LinkMarker returns the marker HideURLs shows in place of link i of the links given to
+// HighlightText, e.g. "[link 3]" for the third one.
+func LinkMarker(i int) string {
+	return fmt.Sprintf("[link %d]", i+1)
+}
+
+// HighlightLine puts the ReadingLine background behind a line of highlighted text, padding
+// it to width columns so the whole row is marked. The background is reapplied after every
+// reset in the line, which would otherwise end it early.
+func HighlightLine(line string, width int, styles HighlightStyles) string {
+	background := styles.withDefaults().ReadingLine
+	line += strings.Repeat(" ", max(0, width-ansi.StringWidth(line)))
+	parts := strings.Split(line, ansiReset)
+	for i, part := range parts {
+		parts[i] = background.Sprint(part)
+	}
+	return strings.Join(parts, ansiReset)
 }
 
-// HighlightText handles all text formatting, including search matches and URLs
-func HighlightText(content, query string, searchMatches []int, currentMatch int, urlMatches [][]int) string {
+// HighlightText handles all text formatting, including search matches and URLs. A span that
+// continues on the next line is colored line by line, so each wrapped line carries its own
+// color codes. The selected link stands out, and identifiers are drawn in the Identifier color
+// unless selected. The text of code blocks is highlighted with styles.Code, and that of
+// quotations with styles.Quote, where no match covers it. With styles.Focus, text outside the
+// lines of the current match is drawn in the Dim color instead. With styles.HideURLs, links
+// are drawn as markers (see LinkMarker) numbered by their index in spans.URLs.
+func HighlightText(content string, spans HighlightSpans, styles HighlightStyles) string {
+	searchMatches, currentMatch := spans.Matches, spans.CurrentMatch
+	urlMatches, currentURL := spans.URLs, spans.CurrentURL
+	identifiers, codeBlocks := spans.Identifiers, spans.CodeBlocks
 	var sb strings.Builder
 	lastIndex := 0
-	searchMatchColor := color.New(color.BgYellow, color.FgBlack).SprintFunc()
-	currentMatchColor := color.New(color.BgHiYellow, color.FgBlack).SprintFunc()
-	urlColor := color.New(color.FgHiBlue).SprintFunc()
-	defaultColor := color.New(color.FgWhite).SprintFunc()
+	styles = styles.withDefaults()
+	searchMatchColor := styles.Match.SprintFunc()
+	currentMatchColor := styles.CurrentMatch.SprintFunc()
+	urlColor := styles.URL.SprintFunc()
+	currentURLColor := styles.CurrentURL.SprintFunc()
+	identifierColor := styles.Identifier.SprintFunc()
+	defaultColor := styles.Text.SprintFunc()
+	dimColor := styles.Dim.SprintFunc()
+
+	// Text between focusStart and focusEnd is drawn as usual, and the rest dimmed.
+	focusStart, focusEnd := 0, len(content)
+	if styles.Focus && currentMatch >= 0 && currentMatch < len(searchMatches) && searchMatches[currentMatch][1] <= len(content) {
+		focusStart = strings.LastIndexByte(content[:searchMatches[currentMatch][0]], '\n') + 1
+		if end := strings.IndexByte(content[searchMatches[currentMatch][1]:], '\n'); end >= 0 {
+			focusEnd = searchMatches[currentMatch][1] + end
+		}
+	}
+	focus := func(start, end int, draw func(start, end int) string) string {
+		var sb strings.Builder
+		if start < focusStart {
+			sb.WriteString(colorLines(dimColor, content[start:min(end, focusStart)]))
+		}
+		if from, to := max(start, focusStart), min(end, focusEnd); from < to {
+			sb.WriteString(draw(from, to))
+		}
+		if end > focusEnd {
+			sb.WriteString(colorLines(dimColor, content[max(start, focusEnd):end]))
+		}
+		return sb.String()
+	}
+	colorSpan := func(colorize func(a ...interface{}) string) func(start, end int) string {
+		return func(start, end int) string {
+			return colorLines(colorize, content[start:end])
+		}
+	}
+
+	quoteColor := styles.Quote.SprintFunc()
+	plain := func(start, end int) string {
+		var sb strings.Builder
+		for _, block := range codeBlocks {
+			if block.End <= start || block.Start >= end {
+				continue
+			}
+			if block.Start > start {
+				sb.WriteString(colorLines(defaultColor, content[start:block.Start]))
+				start = block.Start
+			}
+			text := content[start:min(end, block.End)]
+			switch {
+			case block.Lang == QuoteLang:
+				sb.WriteString(colorLines(quoteColor, text))
+			case styles.Code != nil:
+				sb.WriteString(styles.Code(text, block.Lang))
+			default:
+				sb.WriteString(colorLines(defaultColor, text))
+			}
+			start = min(end, block.End)
+		}
+		sb.WriteString(colorLines(defaultColor, content[start:end]))
+		return sb.String()
+	}
 
 	type match struct {
-		start           int
-		end             int
-		isURL           bool
-		isCurrentSearch bool
+		start        int
+		end          int
+		isURL        bool
+		isCurrent    bool
+		isIdentifier bool
+	}
+	// hidden maps the start of each link shown as a marker to the marker.
+	hidden := map[int]string{}
+	if styles.HideURLs {
+		isIdentifier := map[int]bool{}
+		for _, identifier := range identifiers {
+			isIdentifier[identifier[0]] = true
+		}
+		for i, urlMatch := range urlMatches {
+			if marker := LinkMarker(i); !isIdentifier[urlMatch[0]] && len(marker) < urlMatch[1]-urlMatch[0] {
+				hidden[urlMatch[0]] = marker
+			}
+		}
+	}
+	insideHidden := func(span []int) bool {
+		for _, urlMatch := range urlMatches {
+			if _, ok := hidden[urlMatch[0]]; ok && span[0] < urlMatch[1] && urlMatch[0] < span[1] {
+				return true
+			}
+		}
+		return false
 	}
+
 	var allMatches []match
-	for i, start := range searchMatches {
-		end := start + len(query)
-		allMatches = append(allMatches, match{start, end, false, i == currentMatch})
+	for i, searchMatch := range searchMatches {
+		if len(hidden) > 0 && insideHidden(searchMatch) {
+			continue
+		}
+		allMatches = append(allMatches, match{searchMatch[0], searchMatch[1], false, i == currentMatch, false})
+	}
+	for i, urlMatch := range urlMatches {
+		allMatches = append(allMatches, match{urlMatch[0], urlMatch[1], true, i == currentURL, false})
 	}
-	for _, urlMatch := range urlMatches {
-		allMatches = append(allMatches, match{urlMatch[0], urlMatch[1], true, false})
+	for _, identifier := range identifiers {
+		allMatches = append(allMatches, match{identifier[0], identifier[1], false, false, true})
 	}
 
-	for i := range allMatches {
-		for j := i + 1; j < len(allMatches); j++ {
-			if allMatches[i].start > allMatches[j].start {
-				allMatches[i], allMatches[j] = allMatches[j], allMatches[i]
-			}
+	// Spans are drawn in order of start, then end. Of spans covering the same text, the current
+	// search match is drawn, then other search matches, then the selected link, identifiers and
+	// other links, so a search match stays visible inside a link.
+	priority := func(m match) int {
+		switch {
+		case m.isCurrent && !m.isURL:
+			return 0
+		case !m.isURL && !m.isIdentifier:
+			return 1
+		case m.isCurrent:
+			return 2
+		case m.isIdentifier:
+			return 3
 		}
+		return 4
 	}
+	sort.SliceStable(allMatches, func(i, j int) bool {
+		a, b := allMatches[i], allMatches[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		if a.end != b.end {
+			return a.end < b.end
+		}
+		return priority(a) < priority(b)
+	})
 
 	for _, m := range allMatches {
+		// Overlapping spans are clipped so no text is written twice.
+		if m.end <= lastIndex || m.end > len(content) {
+			continue
+		}
 		if m.start > lastIndex {
-			sb.WriteString(defaultColor(content[lastIndex:m.start]))
+			sb.WriteString(focus(lastIndex, m.start, plain))
 		}
-		matchStr := content[m.start:m.end]
-		if m.isURL {
-			sb.WriteString(urlColor(matchStr))
-		} else if m.isCurrentSearch {
-			sb.WriteString(currentMatchColor(matchStr))
+		start := max(m.start, lastIndex)
+		marker, hide := hidden[m.start]
+		if m.isURL && hide && (m.start < focusStart || m.start >= focusEnd) {
+			sb.WriteString(dimColor(marker))
+		} else if m.isURL && hide && m.isCurrent {
+			sb.WriteString(currentURLColor(marker))
+		} else if m.isURL && hide {
+			sb.WriteString(urlColor(marker))
+		} else if m.isURL && m.isCurrent {
+			sb.WriteString(focus(start, m.end, colorSpan(currentURLColor)))
+		} else if m.isIdentifier {
+			sb.WriteString(focus(start, m.end, colorSpan(identifierColor)))
+		} else if m.isURL {
+			sb.WriteString(focus(start, m.end, colorSpan(urlColor)))
+		} else if m.isCurrent {
+			sb.WriteString(focus(start, m.end, colorSpan(currentMatchColor)))
 		} else {
-			sb.WriteString(searchMatchColor(matchStr))
+			sb.WriteString(focus(start, m.end, colorSpan(searchMatchColor)))
+		}
+		if m.isURL && hide {
+			// A link broken across lines keeps its line breaks, so the text keeps its lines.
+			sb.WriteString(strings.Repeat("\n", strings.Count(content[m.start:m.end], "\n")))
 		}
 		lastIndex = m.end
 	}
 
 	if lastIndex < len(content) {
-		sb.WriteString(defaultColor(content[lastIndex:]))
+		sb.WriteString(focus(lastIndex, len(content), plain))
 	}
 	return sb.String()
 }
 
+// colorLines applies colorize to each line of s separately, leaving the line breaks uncolored.
+func colorLines(colorize func(a ...interface{}) string, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = colorize(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // WrapText wraps the given string to the specified width.
+// Words longer than the width, such as long URLs, are hard-broken at the width boundary.
+// List items keep their indentation, and their continuation lines hang under the item text.
 func WrapText(text string, width int) string {
+	return wrapText(text, width, false)
+}
+
+// JustifyText wraps text like WrapText, then pads each wrapped line with extra spaces between
+// words so it fills the width. The last line of each paragraph is left ragged.
+func JustifyText(text string, width int) string {
+	return wrapText(text, width, true)
+}
+
+func wrapText(text string, width int, justify bool) string {
 	if width <= 0 {
 		return text
 	}
@@ -108,20 +398,147 @@ func WrapText(text string, width int) string {
 			continue
 		}
 
-		currentLine := words[0]
-		for _, word := range words[1:] {
-			if len(currentLine)+1+len(word) > width {
-				result.WriteString(currentLine + "\n")
+		prefix, hanging := listIndent(line)
+		// marker is the list item's marker and the space after it, which start the item's first
+		// line; justification leaves that space alone so the item text lines up.
+		marker := ""
+		if match := listItemRegex.FindStringSubmatch(line); match != nil {
+			marker = match[2] + " "
+		}
+		currentLine := ""
+		available := func() int {
+			return max(1, width-len(prefix))
+		}
+		flush := func(last bool) {
+			if justify && !last {
+				if item, ok := strings.CutPrefix(currentLine, marker); ok && marker != "" {
+					currentLine = marker + padLine(item, available()-utf8.RuneCountInString(marker))
+				} else {
+					currentLine = padLine(currentLine, available())
+				}
+			}
+			result.WriteString(prefix + currentLine + "\n")
+			currentLine = ""
+			prefix = hanging
+			marker = ""
+		}
+		for _, word := range words {
+			for utf8.RuneCountInString(word) > available() {
+				if currentLine != "" {
+					flush(false)
+				}
+				runes := []rune(word)
+				currentLine = string(runes[:available()])
+				word = string(runes[len([]rune(currentLine)):])
+				flush(false)
+			}
+			if currentLine == "" {
+				currentLine = word
+			} else if utf8.RuneCountInString(currentLine)+1+utf8.RuneCountInString(word) > available() {
+				flush(false)
 				currentLine = word
 			} else {
 				currentLine += " " + word
 			}
 		}
-		result.WriteString(currentLine + "\n")
+		if currentLine != "" {
+			flush(true)
+		}
 	}
 	return result.String()
 }
 
+// MapSpans maps spans of from onto to, which holds the same text laid out again: the runes of
+// from other than whitespace, in the same order, with only whitespace and line prefixes such
+// as a quotation's border added or removed, as wrapping does. A span broken across lines in to
+// covers the line breaks between its pieces.
+func MapSpans(from, to string, spans [][]int) [][]int {
+	// at holds the offset in to of each rune of from other than whitespace, and -1 elsewhere.
+	at := make([]int, len(from))
+	for i := range at {
+		at[i] = -1
+	}
+	j := 0
+	for i, r := range from {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for j < len(to) {
+			tr, size := utf8.DecodeRuneInString(to[j:])
+			if tr == r {
+				break
+			}
+			j += size
+		}
+		if j == len(to) {
+			break
+		}
+		at[i] = j
+		_, size := utf8.DecodeRuneInString(to[j:])
+		j += size
+	}
+	mapped := make([][]int, 0, len(spans))
+	for _, span := range spans {
+		start, end := -1, -1
+		for i := span[0]; i < span[1]; i++ {
+			if at[i] < 0 {
+				continue
+			}
+			if start < 0 {
+				start = at[i]
+			}
+			_, size := utf8.DecodeRuneInString(to[at[i]:])
+			end = at[i] + size
+		}
+		if start >= 0 {
+			mapped = append(mapped, []int{start, end})
+		}
+	}
+	return mapped
+}
+
+// padLine widens the gaps between words so line is width runes long, giving the leftmost
+// gaps the extra spaces when they cannot be shared evenly.
+func padLine(line string, width int) string {
+	words := strings.Fields(line)
+	gaps := len(words) - 1
+	extra := width - utf8.RuneCountInString(line)
+	if gaps <= 0 || extra <= 0 {
+		return line
+	}
+	var padded strings.Builder
+	for i, word := range words {
+		padded.WriteString(word)
+		if i < gaps {
+			spaces := 1 + extra/gaps
+			if i < extra%gaps {
+				spaces++
+			}
+			padded.WriteString(strings.Repeat(" ", spaces))
+		}
+	}
+	return padded.String()
+}
+
+// SingleLine collapses line breaks and other runs of whitespace in s to single spaces and
+// trims the ends, e.g. for pasting multi-line text into a one-line input.
+func SingleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// TruncateRunes shortens s to at most limit runes, replacing the cut-off tail with an
+// ellipsis. It cuts on rune boundaries, so multi-byte characters are never split.
+func TruncateRunes(s string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
 // CalculateLineFromIndex determines the line number based on a character index
 func CalculateLineFromIndex(content string, index int) int {
 	return strings.Count(content[:index], "\n")
@@ -0,0 +1,405 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/fatih/color"
+)
+
+func TestHighlightTextURLDisplay(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	content := "See https://example.org/a/long/path and doi:10.1000/182 or a.io/x here."
+	span := func(s string) []int {
+		i := strings.Index(content, s)
+		return []int{i, i + len(s)}
+	}
+	spans := NoSpans()
+	spans.URLs = [][]int{span("https://example.org/a/long/path"), span("doi:10.1000/182"), span("a.io/x")}
+	spans.Identifiers = [][]int{span("doi:10.1000/182")}
+	spans.Matches = [][]int{span("example"), span("here")}
+	spans.CurrentURL = 0
+
+	tests := []struct {
+		name     string
+		hideURLs bool
+		want     string
+	}{
+		{"URLs", false, content},
+		// Identifiers and links shorter than their marker stay as they are.
+		{"markers", true, "See [link 1] and doi:10.1000/182 or a.io/x here."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			styles := HighlightStyles{HideURLs: tt.hideURLs}
+			if got := HighlightText(content, spans, styles); got != tt.want {
+				t.Errorf("HighlightText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightTextSelectedMarker(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	content := "Read https://example.org/a/long/path next."
+	spans := NoSpans()
+	spans.URLs = [][]int{{5, 36}}
+	spans.CurrentURL = 0
+	selected := color.New(color.Underline)
+	got := HighlightText(content, spans, HighlightStyles{HideURLs: true, CurrentURL: selected})
+	if want := selected.Sprint("[link 1]"); !strings.Contains(got, want) {
+		t.Errorf("HighlightText() = %q, want the selected marker drawn as %q", got, want)
+	}
+	if strings.Contains(got, "example.org") {
+		t.Errorf("HighlightText() = %q still shows the hidden URL", got)
+	}
+}
+
+func TestHighlightTextMarkerKeepsLineBreaks(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	content := "See https://exam\nple.org/a/long\n/path here."
+	spans := NoSpans()
+	spans.URLs = [][]int{{4, 37}}
+	got := HighlightText(content, spans, HighlightStyles{HideURLs: true})
+	if want := "See [link 1]\n\n here."; got != want {
+		t.Errorf("HighlightText() = %q, want %q", got, want)
+	}
+}
+
+func TestWrapTextBreaksLongWords(t *testing.T) {
+	const link = "https://example.org/wiki/Pages"
+	for _, wrap := range []func(string, int) string{WrapText, JustifyText} {
+		wrapped := wrap("See "+link+" now", 10)
+		for _, line := range strings.Split(strings.TrimRight(wrapped, "\n"), "\n") {
+			if n := len([]rune(line)); n > 10 {
+				t.Errorf("line %q of %q is %d wide, want at most 10", line, wrapped, n)
+			}
+		}
+		if got := strings.Join(strings.Fields(wrapped), ""); got != "See"+link+"now" {
+			t.Errorf("wrapped text %q lost characters", wrapped)
+		}
+	}
+}
+
+func TestMapSpans(t *testing.T) {
+	const link = "https://example.org/wiki/Pages"
+	from := "See " + link + " now, and  " + link + "."
+	tests := []struct {
+		name string
+		to   string
+	}{
+		{"unchanged", from},
+		{"wrapped", WrapText(from, 10)},
+		{"justified", JustifyText(from, 12)},
+		{"indented", strings.ReplaceAll(WrapText(from, 10), "\n", "\n  ")},
+		{"quoted", "│ " + strings.ReplaceAll(WrapText(from, 10), "\n", "\n│ ")},
+	}
+	spans := [][]int{{4, 4 + len(link)}, {strings.LastIndex(from, link), len(from) - 1}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapped := MapSpans(from, tt.to, spans)
+			if len(mapped) != len(spans) {
+				t.Fatalf("MapSpans() = %v, want %d spans", mapped, len(spans))
+			}
+			for _, span := range mapped {
+				got := strings.NewReplacer("\n", "", " ", "", "│", "").Replace(tt.to[span[0]:span[1]])
+				if got != link {
+					t.Errorf("span %v of %q holds %q, want %q", span, tt.to, got, link)
+				}
+			}
+		})
+	}
+}
+
+func TestHighlightStyles(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	content := "Go and go at https://go.dev/doc and https://example.org, doi:10.1000/182 end"
+	span := func(s string) []int {
+		i := strings.Index(content, s)
+		return []int{i, i + len(s)}
+	}
+	styles := HighlightStyles{
+		Match:        color.New(color.BgGreen),
+		CurrentMatch: color.New(color.BgRed),
+		URL:          color.New(color.Underline),
+		CurrentURL:   color.New(color.BgCyan),
+		Identifier:   color.New(color.FgMagenta),
+	}
+	spans := NoSpans()
+	spans.Matches = [][]int{span("Go"), span("go at"), span("https://go")}
+	spans.CurrentMatch = 1
+	spans.URLs = [][]int{span("https://go.dev/doc"), span("https://example.org,")}
+	spans.CurrentURL = 1
+	spans.Identifiers = [][]int{span("doi:10.1000/182")}
+	got := HighlightText(content, spans, styles)
+
+	want := []struct {
+		kind  string
+		style *color.Color
+		text  string
+	}{
+		{"match", styles.Match, "Go"},
+		{"current match", styles.CurrentMatch, "go at"},
+		// A search match at the start of a link is drawn over it, the rest as a link.
+		{"match in a link", styles.Match, "https://go"},
+		{"link", styles.URL, ".dev/doc"},
+		{"selected link", styles.CurrentURL, "https://example.org,"},
+		{"identifier", styles.Identifier, "doi:10.1000/182"},
+		// Unset styles fall back to the defaults.
+		{"text", DefaultHighlightStyles().Text, " end"},
+	}
+	for _, w := range want {
+		if drawn := w.style.Sprint(w.text); !strings.Contains(got, drawn) {
+			t.Errorf("%s %q not drawn as %q in %q", w.kind, w.text, drawn, got)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s     string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exact", 5, "exact"},
+		{"too long", 5, "too …"},
+		// Byte slicing at 5 would split the "é" and the "ü" in two.
+		{"Pokémon Zürich", 5, "Poké…"},
+		{"Zürich", 3, "Zü…"},
+		{"東京都の区", 4, "東京都…"},
+		{"👋🏽 hello", 2, "👋…"},
+		{"anything", 1, "…"},
+		{"anything", 0, ""},
+		{"", 3, ""},
+	}
+	for _, tt := range tests {
+		got := TruncateRunes(tt.s, tt.limit)
+		if got != tt.want {
+			t.Errorf("TruncateRunes(%q, %d) = %q, want %q", tt.s, tt.limit, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("TruncateRunes(%q, %d) = %q is not valid UTF-8", tt.s, tt.limit, got)
+		}
+	}
+}
+
+func TestJustifyText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{
+			name:  "lines filled, last line ragged",
+			text:  "The quick brown fox jumps over the lazy dog",
+			width: 16,
+			want:  "The  quick brown\nfox  jumps  over\nthe lazy dog\n",
+		},
+		{
+			// Extra spaces go to the leftmost gaps when they can't be shared evenly.
+			name:  "uneven gaps",
+			text:  "a b c d eeeeeeeee",
+			width: 9,
+			want:  "a  b  c d\neeeeeeeee\n",
+		},
+		{
+			name:  "single word line left alone",
+			text:  "Supercalifragilistic is long",
+			width: 21,
+			want:  "Supercalifragilistic\nis long\n",
+		},
+		{
+			name:  "each paragraph's last line ragged",
+			text:  "one two three four\n\nfive six",
+			width: 10,
+			want:  "one    two\nthree four\n\nfive six\n",
+		},
+		{
+			name:  "list items justified behind their marker",
+			text:  "- alpha beta gamma delta\n  • one two three",
+			width: 14,
+			want:  "- alpha   beta\n  gamma delta\n  • one    two\n    three\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JustifyText(tt.text, tt.width); got != tt.want {
+				t.Errorf("JustifyText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightTextPhraseAcrossWrap(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	wrapped := WrapText("The quick brown fox jumps", 9)
+	if !strings.Contains(wrapped, "quick\nbrown") {
+		t.Fatalf("WrapText() = %q, want the phrase split across lines", wrapped)
+	}
+	spans := NoSpans()
+	spans.Matches = FindMatchSpans(wrapped, "quick brown")
+	spans.CurrentMatch = 0
+	if len(spans.Matches) != 1 {
+		t.Fatalf("FindMatchSpans() = %v, want the one phrase", spans.Matches)
+	}
+	current := color.New(color.BgRed)
+	got := HighlightText(wrapped, spans, HighlightStyles{CurrentMatch: current})
+	// Each word is highlighted on its own line, and the line break between them isn't, so the
+	// highlight doesn't bleed to the end of the line or the start of the next.
+	if want := current.Sprint("quick") + "\n" + current.Sprint("brown"); !strings.Contains(got, want) {
+		t.Errorf("HighlightText() = %q, want both words highlighted as %q", got, want)
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"one line", "one line"},
+		{"two\nlines", "two lines"},
+		{"windows\r\nline end", "windows line end"},
+		{"tab\tseparated\t\tfields", "tab separated fields"},
+		{"  padded  \n\n  paragraphs \n", "padded paragraphs"},
+		{"\u00a0no-break\u2028separator", "no-break separator"},
+		{"\n\t \n", ""},
+	}
+	for _, tt := range tests {
+		if got := SingleLine(tt.s); got != tt.want {
+			t.Errorf("SingleLine(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightTextCoincidentSpans(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	styles := HighlightStyles{
+		Match:        color.New(color.BgGreen),
+		CurrentMatch: color.New(color.BgRed),
+		URL:          color.New(color.Underline),
+		CurrentURL:   color.New(color.BgCyan),
+		Identifier:   color.New(color.FgMagenta),
+	}
+	const content = "doi:10.1000/182 end"
+	whole := []int{0, 15}
+	tests := []struct {
+		name  string
+		spans HighlightSpans
+		want  *color.Color
+	}{
+		{"current match over match", HighlightSpans{Matches: [][]int{whole, whole}, CurrentMatch: 1, CurrentURL: -1}, styles.CurrentMatch},
+		{"match over link", HighlightSpans{Matches: [][]int{whole}, CurrentMatch: -1, URLs: [][]int{whole}, CurrentURL: -1}, styles.Match},
+		{"match over selected link", HighlightSpans{Matches: [][]int{whole}, CurrentMatch: -1, URLs: [][]int{whole}, CurrentURL: 0}, styles.Match},
+		{"selected link over identifier", HighlightSpans{CurrentMatch: -1, URLs: [][]int{whole}, CurrentURL: 0, Identifiers: [][]int{whole}}, styles.CurrentURL},
+		{"identifier over link", HighlightSpans{CurrentMatch: -1, URLs: [][]int{whole}, CurrentURL: -1, Identifiers: [][]int{whole}}, styles.Identifier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want.Sprint(content[:15]) + DefaultHighlightStyles().Text.Sprint(content[15:])
+			// The same spans give the same output however often they are drawn.
+			for range 10 {
+				if got := HighlightText(content, tt.spans, styles); got != want {
+					t.Fatalf("HighlightText() = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHighlightTextFocus(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	content := "First line with go.\nSecond line with go and more go.\nThird line."
+	spans := NoSpans()
+	for i := 0; ; {
+		j := strings.Index(content[i:], "go")
+		if j < 0 {
+			break
+		}
+		spans.Matches = append(spans.Matches, []int{i + j, i + j + 2})
+		i += j + 2
+	}
+	spans.CurrentMatch = 1
+	styles := HighlightStyles{Dim: color.New(color.FgRed), Text: color.New(color.FgBlue), Focus: true}
+
+	color.NoColor = false
+	got := HighlightText(content, spans, styles)
+	want := []struct {
+		kind  string
+		style *color.Color
+		text  string
+	}{
+		{"line before", styles.Dim, "First line with "},
+		// Other matches outside the current line are dimmed with the rest.
+		{"match on another line", styles.Dim, "go"},
+		{"current line", styles.Text, "Second line with "},
+		{"current match", DefaultHighlightStyles().CurrentMatch, "go"},
+		{"other match on the current line", DefaultHighlightStyles().Match, "go"},
+		{"line after", styles.Dim, "Third line."},
+	}
+	for _, w := range want {
+		if drawn := w.style.Sprint(w.text); !strings.Contains(got, drawn) {
+			t.Errorf("%s %q not drawn as %q in %q", w.kind, w.text, drawn, got)
+		}
+	}
+	if strings.Contains(got, styles.Text.Sprint("First line with ")) || strings.Contains(got, styles.Text.Sprint("Third line.")) {
+		t.Errorf("text outside the current line drawn at full brightness: %q", got)
+	}
+
+	// Without a current match nothing is dimmed.
+	none := spans
+	none.CurrentMatch = -1
+	if got := HighlightText(content, none, styles); strings.Contains(got, styles.Dim.Sprint("Third line.")) {
+		t.Errorf("text dimmed without a current match: %q", got)
+	}
+
+	// Without colors the text reads the same.
+	color.NoColor = true
+	if got := HighlightText(content, spans, styles); got != content {
+		t.Errorf("focus without colors = %q, want the text as is", got)
+	}
+}
+
+func TestHighlightLine(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+	color.NoColor = false
+
+	styles := HighlightStyles{ReadingLine: color.New(color.BgBlue)}
+	line := "Plain " + color.New(color.FgRed).Sprint("red") + " text"
+	got := HighlightLine(line, 20, styles)
+	if want := "Plain red text      "; ansi.Strip(got) != want {
+		t.Errorf("HighlightLine() reads %q, want %q padded to the width", ansi.Strip(got), want)
+	}
+	// The background is turned on again after every reset inside the line.
+	on, _, _ := strings.Cut(styles.ReadingLine.Sprint("x"), "x")
+	if n := strings.Count(got, on); n != strings.Count(line, ansiReset)+1 {
+		t.Errorf("background turned on %d times in %q, want after every reset", n, got)
+	}
+	if !strings.HasPrefix(got, on) {
+		t.Errorf("HighlightLine() = %q, want it to start with the background", got)
+	}
+}
@@ -0,0 +1,289 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"wiki-search/pkg/utils"
+)
+
+func TestFetchArticleChunked(t *testing.T) {
+	html := `<div><p>Go is a programming language designed at <a href="/wiki/Google">Google</a>.</p>` +
+		strings.Repeat(`<p>It is statically typed and compiled, with garbage collection.</p>`, 50) + `</div>`
+	parse := map[string]any{"parse": map[string]any{
+		"title":    "Go",
+		"revid":    7,
+		"text":     map[string]string{"*": html},
+		"sections": []Section{{Index: "1", Level: "2", Line: "History", Number: "1"}},
+	}}
+	extract := map[string]any{"query": map[string]any{"pages": []map[string]string{{"title": "Go", "extract": "Go is a programming language."}}}}
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		switch {
+		case r.FormValue("action") == "parse":
+			body, _ = json.Marshal(parse)
+		case r.FormValue("prop") == "extracts":
+			body, _ = json.Marshal(extract)
+		default:
+			body = []byte(`{}`)
+		}
+		writeChunks(w, string(body), 64)
+	})
+
+	msg := FetchArticle("Go", name)(context.Background()).(ArticleMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(msg.Content), "Go is a programming language designed at Google.") || strings.Count(msg.Content, "garbage collection") != 50 {
+		t.Errorf("content assembled from chunks:\n%s", msg.Content)
+	}
+	if msg.Title != "Go" || msg.RevID != 7 || len(msg.Sections) != 1 || len(msg.Links) != 1 {
+		t.Errorf("article = %+v", msg.Article)
+	}
+
+	// The introduction isn't fetched again for an article in the cache.
+	if lead := FetchArticleLead("Go", name)(context.Background()).(ArticleLeadMsg); lead.Content != "" {
+		t.Errorf("lead of a cached article = %q, want none", lead.Content)
+	}
+	if lead := FetchArticleLead("Gopher", name)(context.Background()).(ArticleLeadMsg); lead.Err != nil || lead.Content != "Go is a programming language." {
+		t.Errorf("lead = %q, %v", lead.Content, lead.Err)
+	}
+}
+
+func TestFetchArticleDoesNotWaitForPageInfo(t *testing.T) {
+	wait, interval := pageInfoWait, MinRequestInterval
+	pageInfoWait, MinRequestInterval = 50*time.Millisecond, 0
+	defer func() { pageInfoWait, MinRequestInterval = wait, interval }()
+	parse := `{"parse":{"title":"Slow description","text":{"*":"<p>An article whose description takes long.</p>"}}}`
+	abandoned := make(chan struct{})
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("prop") == "description|coordinates" {
+			<-r.Context().Done()
+			close(abandoned)
+			return
+		}
+		w.Write([]byte(parse))
+	})
+
+	start := time.Now()
+	msg := fetchArticle(context.Background(), "Slow description", name, ContentReadable)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	if elapsed := time.Since(start); elapsed > SearchTimeout/2 {
+		t.Errorf("article took %v, waiting for its description", elapsed)
+	}
+	if msg.Title != "Slow description" || msg.Description != "" {
+		t.Errorf("article = %+v", msg.Article)
+	}
+	select {
+	case <-abandoned:
+	case <-time.After(time.Second):
+		t.Error("the description request went on after the article was returned")
+	}
+}
+
+func TestFetchArticleFields(t *testing.T) {
+	html := `<div>` + strings.Repeat(`<p>Berlin is the capital and largest city of Germany, by both area and population.</p>`, 8) + `</div>`
+	parse := `{"parse":{"title":"Berlin","displaytitle":"<span>Berlin</span>","revid":1234,` +
+		`"text":{"*":` + strconv.Quote(html) + `},` +
+		`"sections":[{"index":"1","level":"2","line":"History","number":"1","anchor":"History"}],` +
+		`"redirects":[{"from":"Berlin, Germany","to":"Berlin","tofragment":"History"}],` +
+		`"categories":[{"*":"Capitals_in_Europe"},{"*":"Articles_with_short_description","hidden":""}]}}`
+	info := `{"query":{"pages":[{"description":"Capital of Germany","coordinates":[{"lat":52.52,"lon":13.405,"globe":"earth"}]}]}}`
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.FormValue("action") == "parse":
+			w.Write([]byte(parse))
+		case r.FormValue("prop") == "description|coordinates":
+			w.Write([]byte(info))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+
+	msg := fetchArticle(context.Background(), "Berlin, Germany", name, ContentReadable)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	got := msg.Article
+	got.Content = ""
+	want := Article{
+		Title:        "Berlin",
+		DisplayTitle: "Berlin",
+		Sections:     []Section{{Index: "1", Level: "2", Line: "History", Number: "1", Anchor: "History"}},
+		RevID:        1234,
+		Fragment:     "History",
+		Categories:   []string{"Capitals in Europe"},
+		Description:  "Capital of Germany",
+		Coordinates:  &Coordinates{Lat: 52.52, Lon: 13.405},
+		Mode:         ContentReadable,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("article = %+v\nwant %+v", got, want)
+	}
+	if !strings.Contains(msg.Content, "capital and largest city") {
+		t.Errorf("content = %q", msg.Content)
+	}
+}
+
+func TestFetchArticleExtractFallbackHasTitle(t *testing.T) {
+	extract := `{"query":{"pages":[{"title":"Berlin","extract":"Berlin is a city.\n\n\n== History ==\nIt is old."}]}}`
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.FormValue("action") == "parse":
+			// A page the parser rendered nothing of.
+			w.Write([]byte(`{"parse":{"title":"Berlin","text":{"*":""}}}`))
+		case r.FormValue("prop") == "extracts":
+			w.Write([]byte(extract))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+	msg := fetchArticle(context.Background(), "Berlin, Germany", name, ContentReadable)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	if msg.Title != "Berlin" || len(msg.Sections) != 1 || msg.Mode != ContentReadable {
+		t.Errorf("article from the extract = %+v", msg.Article)
+	}
+}
+
+func TestCacheEntryDecodesArticleMsgJSON(t *testing.T) {
+	// Caches written when entries held an ArticleMsg have its error among the fields.
+	old := `{"wiki":"wikipedia","title":"Go","article":{"Title":"Go","Content":"Text.","RevID":7,"Mode":"readable","Err":null},"fetched_at":"2024-01-02T03:04:05Z"}`
+	var entry CacheEntry
+	if err := json.Unmarshal([]byte(old), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Article.Title != "Go" || entry.Article.Content != "Text." || entry.Article.RevID != 7 || entry.Article.Mode != ContentReadable {
+		t.Errorf("decoded article = %+v", entry.Article)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"article":{"Title":"Go"`) {
+		t.Errorf("encoded entry = %s", data)
+	}
+}
+
+func TestFetchArticleMissingFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse string
+		want  string
+	}{
+		{"no parse object", `{"batchcomplete":""}`, "no parseable content for this page"},
+		{"no text", `{"parse":{"title":"Special:Random","sections":[]}}`, "no parseable content for this page"},
+		{"blank text", `{"parse":{"title":"Blank","text":{"*":"  \n "}}}`, "no parseable content for this page"},
+		{"API error", `{"error":{"code":"missingtitle","info":"The page you specified doesn't exist."}}`, "wiki API error (missingtitle): The page you specified doesn't exist."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.FormValue("action") == "parse" {
+					w.Write([]byte(tt.parse))
+					return
+				}
+				// Nor is there an extract to fall back on.
+				w.Write([]byte(`{"query":{"pages":[{"title":"Blank","missing":true}]}}`))
+			})
+			msg := fetchArticle(context.Background(), "Blank", name, ContentReadable)
+			if msg.Err == nil || msg.Err.Error() != tt.want {
+				t.Errorf("error = %v, want %q", msg.Err, tt.want)
+			}
+			if msg.Content != "" {
+				t.Errorf("content = %q, want none", msg.Content)
+			}
+		})
+	}
+}
+
+func TestPerformSearchAPIError(t *testing.T) {
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":{"code":"srsearch-text-disabled","info":"text search is disabled."}}`))
+	})
+	msg := PerformSearch("go", name, SearchOptions{})(context.Background()).(SearchMsg)
+	var apiErr *APIError
+	if !errors.As(msg.Err, &apiErr) || apiErr.Code != "srsearch-text-disabled" {
+		t.Errorf("error = %v, want the API's error", msg.Err)
+	}
+	if len(msg.Results) != 0 {
+		t.Errorf("results = %v, want none", msg.Results)
+	}
+}
+
+func TestFetchArticleKeepsCodeBlocks(t *testing.T) {
+	html := `<div>` + strings.Repeat(`<p>Timers are systemd unit files whose name ends in .timer and that control service files.</p>`, 6) +
+		`<div class="mw-highlight mw-highlight-lang-bash mw-content-ltr" dir="ltr"><pre><span></span><span class="c1"># systemctl list-timers --all</span>` + "\n" +
+		`<span class="k">for</span> unit <span class="k">in</span> *.timer<span class="p">;</span> <span class="k">do</span>` + "\n" +
+		"\tsystemctl   status <span class=\"nv\">$unit</span>\n" +
+		`<span class="k">done</span>` + "\n</pre></div><p>The timers are then listed.</p></div>"
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("action") != "parse" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"parse":{"title":"Systemd/Timers","revid":1,"text":{"*":` + strconv.Quote(html) + `}}}`))
+	})
+
+	msg := fetchArticle(context.Background(), "Systemd/Timers", name, ContentReadable)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	// The code keeps its lines, tab and spacing, between fences naming its language.
+	code := "```bash\n# systemctl list-timers --all\nfor unit in *.timer; do\n\tsystemctl   status $unit\ndone\n"
+	if !strings.Contains(msg.Content, code) {
+		t.Errorf("content doesn't hold the code verbatim:\n%s", msg.Content)
+	}
+	blocks := utils.SplitCodeBlocks(msg.Content)
+	var langs []string
+	for _, block := range blocks {
+		if block.Code {
+			langs = append(langs, block.Lang)
+		}
+	}
+	if !reflect.DeepEqual(langs, []string{"bash"}) || !strings.Contains(blocks[len(blocks)-1].Text, "The timers are then listed.") {
+		t.Errorf("blocks = %+v, want one bash block followed by the text after it", blocks)
+	}
+}
+
+func TestFetchArticleKeepsQuotes(t *testing.T) {
+	html := `<div><p>Torvalds announced the project in 1991:</p>` +
+		`<blockquote><p>I'm doing a (free) operating system (just a hobby, won't be big and professional like gnu) for 386(486) AT clones.</p><p>— Linus Torvalds</p></blockquote>` +
+		`<p>The kernel was released later that year.</p></div>`
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("action") != "parse" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"parse":{"title":"Linux","revid":1,"text":{"*":` + strconv.Quote(html) + `}}}`))
+	})
+
+	msg := fetchArticle(context.Background(), "Linux", name, ContentReadable)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	// The quotation is fenced on its own, with its paragraphs on lines of their own.
+	var quotes []string
+	for _, block := range utils.SplitCodeBlocks(msg.Content) {
+		if block.Code && block.Lang == utils.QuoteLang {
+			quotes = append(quotes, strings.TrimSpace(block.Text))
+		}
+	}
+	want := "I'm doing a (free) operating system (just a hobby, won't be big and professional like gnu) for 386(486) AT clones.\n— Linus Torvalds"
+	if len(quotes) != 1 || quotes[0] != want {
+		t.Errorf("quotations = %q, want %q", quotes, want)
+	}
+	if !strings.Contains(msg.Content, "The kernel was released later that year.") {
+		t.Errorf("text after the quotation lost:\n%s", msg.Content)
+	}
+}
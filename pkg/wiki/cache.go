@@ -0,0 +1,104 @@
+package wiki
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// CacheTTL is how long a cached article is served before it is fetched again. Zero keeps
+// cached articles until the program exits.
+var CacheTTL = 24 * time.Hour
+
+// cacheKey identifies an article across wikis.
+type cacheKey struct {
+	wikiType string
+	title    string
+}
+
+// CacheEntry is a cached article, as exported for persisting the cache between sessions.
+type CacheEntry struct {
+	WikiType  string    `json:"wiki"`
+	Title     string    `json:"title"`
+	Article   Article   `json:"article"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// expired reports whether the entry is older than CacheTTL.
+func (e CacheEntry) expired(now time.Time) bool {
+	return CacheTTL > 0 && now.Sub(e.FetchedAt) > CacheTTL
+}
+
+// articleCache keeps fetched articles, so reopening one is instant.
+var articleCache = struct {
+	sync.Mutex
+	entries map[cacheKey]CacheEntry
+}{entries: map[cacheKey]CacheEntry{}}
+
+// cachedArticle returns the cached article, if there is one that hasn't expired.
+func cachedArticle(wikiType, title string) (Article, bool) {
+	articleCache.Lock()
+	defer articleCache.Unlock()
+	key := cacheKey{wikiType, title}
+	entry, ok := articleCache.entries[key]
+	if !ok {
+		return Article{}, false
+	}
+	if entry.expired(time.Now()) {
+		delete(articleCache.entries, key)
+		return Article{}, false
+	}
+	return entry.Article, true
+}
+
+// cacheArticle stores a successfully fetched article.
+func cacheArticle(wikiType, title string, msg ArticleMsg) {
+	if msg.Err != nil {
+		return
+	}
+	articleCache.Lock()
+	defer articleCache.Unlock()
+	articleCache.entries[cacheKey{wikiType, title}] = CacheEntry{WikiType: wikiType, Title: title, Article: msg.Article, FetchedAt: time.Now()}
+}
+
+// Prune drops the cached articles that have expired.
+func Prune() {
+	articleCache.Lock()
+	defer articleCache.Unlock()
+	now := time.Now()
+	for key, entry := range articleCache.entries {
+		if entry.expired(now) {
+			delete(articleCache.entries, key)
+		}
+	}
+}
+
+// CacheEntries returns the cached articles that haven't expired, oldest first.
+func CacheEntries() []CacheEntry {
+	articleCache.Lock()
+	defer articleCache.Unlock()
+	now := time.Now()
+	entries := make([]CacheEntry, 0, len(articleCache.entries))
+	for _, entry := range articleCache.entries {
+		if !entry.expired(now) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FetchedAt.Before(entries[j].FetchedAt) })
+	return entries
+}
+
+// RestoreCache adds previously exported entries to the cache, skipping expired ones and
+// keeping any newer copy already cached.
+func RestoreCache(entries []CacheEntry) {
+	articleCache.Lock()
+	defer articleCache.Unlock()
+	now := time.Now()
+	for _, entry := range entries {
+		key := cacheKey{entry.WikiType, entry.Title}
+		if current, ok := articleCache.entries[key]; entry.expired(now) || (ok && current.FetchedAt.After(entry.FetchedAt)) {
+			continue
+		}
+		articleCache.entries[key] = entry
+	}
+}
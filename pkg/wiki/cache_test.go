@@ -0,0 +1,121 @@
+package wiki
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// useCache gives the test an empty article cache and the given TTL, restoring both after it.
+func useCache(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	articleCache.Lock()
+	entries := articleCache.entries
+	articleCache.entries = map[cacheKey]CacheEntry{}
+	articleCache.Unlock()
+	previousTTL := CacheTTL
+	CacheTTL = ttl
+	t.Cleanup(func() {
+		articleCache.Lock()
+		articleCache.entries = entries
+		articleCache.Unlock()
+		CacheTTL = previousTTL
+	})
+}
+
+// entry is a cached article fetched age ago.
+func entry(title string, age time.Duration) CacheEntry {
+	return CacheEntry{WikiType: "wikipedia", Title: title, Article: Article{Title: title, Content: title + "."}, FetchedAt: time.Now().Add(-age)}
+}
+
+func TestCacheEntryExpired(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		ttl  time.Duration
+		age  time.Duration
+		want bool
+	}{
+		{time.Hour, 59 * time.Minute, false},
+		{time.Hour, 61 * time.Minute, true},
+		// Without a TTL entries never expire.
+		{0, 1000 * time.Hour, false},
+	}
+	for _, tt := range tests {
+		useCache(t, tt.ttl)
+		e := CacheEntry{FetchedAt: now.Add(-tt.age)}
+		if got := e.expired(now); got != tt.want {
+			t.Errorf("entry %s old with TTL %s: expired = %v, want %v", tt.age, tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestCachedArticleExpires(t *testing.T) {
+	useCache(t, time.Hour)
+	RestoreCache([]CacheEntry{entry("Fresh", time.Minute)})
+	articleCache.Lock()
+	articleCache.entries[cacheKey{"wikipedia", "Stale"}] = entry("Stale", 2*time.Hour)
+	articleCache.Unlock()
+
+	if article, ok := cachedArticle("wikipedia", "Fresh"); !ok || article.Content != "Fresh." {
+		t.Errorf("fresh article: %+v, %v; want it served from the cache", article, ok)
+	}
+	if _, ok := cachedArticle("wikipedia", "Stale"); ok {
+		t.Error("stale article served from the cache")
+	}
+	articleCache.Lock()
+	_, kept := articleCache.entries[cacheKey{"wikipedia", "Stale"}]
+	articleCache.Unlock()
+	if kept {
+		t.Error("stale article kept in the cache after it was found expired")
+	}
+	if _, ok := cachedArticle("wiktionary", "Fresh"); ok {
+		t.Error("article served for another wiki")
+	}
+}
+
+func TestPruneAndCacheEntries(t *testing.T) {
+	useCache(t, time.Hour)
+	articleCache.Lock()
+	for _, e := range []CacheEntry{entry("Newer", time.Minute), entry("Stale", 2*time.Hour), entry("Older", 30*time.Minute)} {
+		articleCache.entries[cacheKey{e.WikiType, e.Title}] = e
+	}
+	articleCache.Unlock()
+
+	// Expired entries are left out of the export even before they are pruned.
+	if got := titlesOf(CacheEntries()); got != "Older Newer" {
+		t.Errorf("CacheEntries() = %q, want the fresh ones, oldest first", got)
+	}
+	Prune()
+	articleCache.Lock()
+	n := len(articleCache.entries)
+	articleCache.Unlock()
+	if n != 2 {
+		t.Errorf("%d entries after Prune, want 2", n)
+	}
+}
+
+func TestRestoreCache(t *testing.T) {
+	useCache(t, time.Hour)
+	cacheArticle("wikipedia", "Go", ArticleMsg{Article: Article{Title: "Go", Content: "Current."}})
+	older := entry("Go", 10*time.Minute)
+	RestoreCache([]CacheEntry{older, entry("Stale", 2*time.Hour), entry("Restored", time.Minute)})
+
+	if article, _ := cachedArticle("wikipedia", "Go"); article.Content != "Current." {
+		t.Errorf("cached Go = %q, want the newer copy kept", article.Content)
+	}
+	if _, ok := cachedArticle("wikipedia", "Restored"); !ok {
+		t.Error("restored entry not cached")
+	}
+	if _, ok := cachedArticle("wikipedia", "Stale"); ok {
+		t.Error("expired entry restored")
+	}
+}
+
+// titlesOf lists the titles of entries, space separated.
+func titlesOf(entries []CacheEntry) string {
+	var titles []string
+	for _, e := range entries {
+		titles = append(titles, e.Title)
+	}
+	return strings.Join(titles, " ")
+}
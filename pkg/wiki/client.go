@@ -0,0 +1,209 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is shared by every API request so TCP/TLS connections are reused
+// across searches and article fetches. Tests may replace it, or its Transport.
+var Client = &http.Client{Transport: newTransport(), CheckRedirect: checkRedirect}
+
+// newTransport returns a keep-alive transport tuned for a handful of wiki hosts.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          16,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// userAgent identifies the application to the wikis, unless a source overrides it.
+const userAgent = "Your-CLI-Tool-Name/1.0 (Contact: your-email@example.com)"
+
+// MinRequestInterval spaces out requests to the same wiki, so bursts of requests stay clear
+// of its rate limits.
+var MinRequestInterval = 100 * time.Millisecond
+
+// maxRateLimitRetries bounds how often a request answered with 429 Too Many Requests is retried.
+const maxRateLimitRetries = 2
+
+// minRetryDelay is the least a rate-limited request waits before it is retried, when the wiki
+// asks for less or doesn't say.
+var minRetryDelay = time.Second
+
+// HTTPError reports an unexpected HTTP status from a wiki.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("API request failed with status code: %d %s", e.StatusCode, e.Status)
+}
+
+// RateLimitError reports that a wiki refused a request because too many were made.
+type RateLimitError struct {
+	// RetryAfter is how long the wiki asked clients to wait, or 0 if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return "rate limited by the wiki, try again later"
+	}
+	return fmt.Sprintf("rate limited by the wiki, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// limiter holds the earliest time the next request to each host may start.
+var limiter = struct {
+	sync.Mutex
+	next map[string]time.Time
+}{next: map[string]time.Time{}}
+
+// throttle waits until a request to host is allowed by MinRequestInterval.
+func throttle(ctx context.Context, host string) error {
+	limiter.Lock()
+	now := time.Now()
+	start := limiter.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	limiter.next[host] = start.Add(MinRequestInterval)
+	limiter.Unlock()
+	return sleep(ctx, start.Sub(now))
+}
+
+// sleep waits for d, returning early with the context's error if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfter parses a Retry-After header, which holds either a number of seconds or an HTTP date.
+func retryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(0, seconds)) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(0, at.Sub(now))
+	}
+	return 0
+}
+
+// getBackgroundJSON is fetchJSON for requests the user isn't waiting for, which give way to
+// those that they are when MaxConcurrentRequests are in flight.
+func getBackgroundJSON(ctx context.Context, operation string, timeout time.Duration, fullURL string, v any) error {
+	return fetchJSON(inBackground(ctx), operation, timeout, fullURL, v)
+}
+
+// fetchJSON requests fullURL with the shared client and decodes the JSON response into v.
+// The request, including any retries, must finish within timeout; a timeout error names
+// the operation, and cancelling ctx abandons it. Rate-limited requests are retried after the
+// wait the wiki asks for, if that fits in the timeout; otherwise a *RateLimitError says how
+// long to wait.
+func fetchJSON(ctx context.Context, operation string, timeout time.Duration, fullURL string, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	err := doJSON(ctx, fullURL, v)
+	recordLatency(time.Since(start))
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s: %w", operation, timeout, err)
+	}
+	return err
+}
+
+// doJSON performs the request of fetchJSON.
+func doJSON(ctx context.Context, fullURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+	if err != nil {
+		return err
+	}
+	setHeaders(req)
+
+	for attempt := 0; ; attempt++ {
+		if err := throttle(ctx, req.URL.Host); err != nil {
+			return err
+		}
+		release, err := acquireSlot(ctx)
+		if err != nil {
+			return err
+		}
+		resp, err := Client.Do(req)
+		if err != nil {
+			release()
+			return tlsError(req.URL.Host, err)
+		}
+		if location, err := resp.Location(); err == nil && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			resp.Body.Close()
+			release()
+			return &RedirectError{From: req.URL.Host, To: location.Host}
+		}
+		noteRedirect(req.URL, resp.Request.URL)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+			release()
+			wait := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+			delay := max(wait, minRetryDelay)
+			deadline, _ := ctx.Deadline()
+			if attempt >= maxRateLimitRetries || time.Now().Add(delay).After(deadline) {
+				return &RateLimitError{RetryAfter: wait}
+			}
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+		err = decodeResponse(resp, v)
+		release()
+		return err
+	}
+}
+
+// decodeResponse checks the status of resp and decodes its JSON body into v, closing the body.
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse API response: %w", err)
+	}
+	return nil
+}
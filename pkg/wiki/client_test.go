@@ -0,0 +1,197 @@
+package wiki
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchJSONCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		var v any
+		done <- fetchJSON(ctx, "search", time.Minute, server.URL, &v)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("fetchJSON after cancel = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetchJSON kept waiting for the response after its context was cancelled")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 5 ", 5 * time.Second},
+		{"-3", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		// A date that has passed means no wait.
+		{"Wed, 01 May 2024 11:59:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+// rateLimited serves 429 Too Many Requests with the given Retry-After header to the first
+// limited requests, and search results after that. It counts the requests in n.
+func rateLimited(limited int, header string, n *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(n.Add(1)) <= limited {
+			w.Header().Set("Retry-After", header)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"query":{"search":[{"title":"Go"}]}}`))
+	}
+}
+
+func TestRateLimitedSearch(t *testing.T) {
+	interval, delay := MinRequestInterval, minRetryDelay
+	MinRequestInterval, minRetryDelay = 0, time.Millisecond
+	defer func() { MinRequestInterval, minRetryDelay = interval, delay }()
+
+	t.Run("retried", func(t *testing.T) {
+		var n atomic.Int32
+		name := testWiki(t, rateLimited(maxRateLimitRetries, "0", &n))
+		msg := PerformSearch("go", name, SearchOptions{})(context.Background()).(SearchMsg)
+		if msg.Err != nil || len(msg.Results) != 1 {
+			t.Errorf("search = %+v, want the results of the retry", msg)
+		}
+		if got := n.Load(); got != maxRateLimitRetries+1 {
+			t.Errorf("%d requests, want %d", got, maxRateLimitRetries+1)
+		}
+	})
+
+	t.Run("too many retries", func(t *testing.T) {
+		var n atomic.Int32
+		name := testWiki(t, rateLimited(maxRateLimitRetries+1, "0", &n))
+		msg := PerformSearch("go", name, SearchOptions{})(context.Background()).(SearchMsg)
+		var rateErr *RateLimitError
+		if !errors.As(msg.Err, &rateErr) {
+			t.Errorf("error = %v, want a *RateLimitError", msg.Err)
+		}
+		if got := n.Load(); got != maxRateLimitRetries+1 {
+			t.Errorf("%d requests, want %d", got, maxRateLimitRetries+1)
+		}
+	})
+
+	t.Run("wait beyond the timeout", func(t *testing.T) {
+		var n atomic.Int32
+		name := testWiki(t, rateLimited(1, "600", &n))
+		msg := PerformSearch("go", name, SearchOptions{})(context.Background()).(SearchMsg)
+		var rateErr *RateLimitError
+		if !errors.As(msg.Err, &rateErr) || rateErr.RetryAfter != 10*time.Minute {
+			t.Errorf("error = %v, want a *RateLimitError asking to wait 10m", msg.Err)
+		}
+		if got := n.Load(); got != 1 {
+			t.Errorf("%d requests, want 1", got)
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		var n atomic.Int32
+		name := testWiki(t, rateLimited(1, "3", &n))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		msg := PerformSearch("go", name, SearchOptions{})(ctx).(SearchMsg)
+		if !errors.Is(msg.Err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", msg.Err)
+		}
+		var rateErr *RateLimitError
+		if errors.As(msg.Err, &rateErr) {
+			t.Errorf("error = %v, want no rate limit reported for a cancelled request", msg.Err)
+		}
+	})
+}
+
+func TestRedirectedAPI(t *testing.T) {
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	canonical := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query":{"searchinfo":{"totalhits":1},"search":[{"ns":0,"title":"Pacman"}]}}`))
+	}))
+	defer canonical.Close()
+	// The wiki's API redirects to the canonical host, a search for "chain" by way of itself.
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		target := canonical.URL + r.URL.Path + "?" + r.URL.RawQuery
+		if r.FormValue("srsearch") == "chain" && r.FormValue("hop") == "" {
+			target = "/w/api.php?" + r.URL.RawQuery + "&hop=1"
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	api, _ := url.Parse(apiURL(name))
+	t.Cleanup(func() {
+		movedHosts.Lock()
+		delete(movedHosts.hosts, api.Host)
+		movedHosts.Unlock()
+	})
+
+	original := ArticleURL("Pacman", name)
+	msg := PerformSearch("pacman", name, SearchOptions{})(context.Background()).(SearchMsg)
+	if msg.Err != nil || len(msg.Results) != 1 {
+		t.Fatalf("search through a redirect = %v, %v", msg.Results, msg.Err)
+	}
+	// Links to the website now go to the host the API moved to.
+	for _, got := range []string{ArticleURL("Pacman", name), SearchURL("pacman", name), PermalinkURL("Pacman", name, 7)} {
+		if !strings.HasPrefix(got, canonical.URL+"/") {
+			t.Errorf("%s, want it on %s", got, canonical.URL)
+		}
+	}
+	if want := canonical.URL + "/wiki/Pacman"; ArticleURL("Pacman", name) != want || original == want {
+		t.Errorf("article URL %s before and %s after the redirect, want %s after", original, ArticleURL("Pacman", name), want)
+	}
+
+	if err := SetMaxRedirects(name, 1); err != nil {
+		t.Fatal(err)
+	}
+	if msg := PerformSearch("pacman", name, SearchOptions{})(context.Background()).(SearchMsg); msg.Err != nil {
+		t.Errorf("one redirect with a limit of 1: %v", msg.Err)
+	}
+	if msg := PerformSearch("chain", name, SearchOptions{})(context.Background()).(SearchMsg); msg.Err == nil || !strings.Contains(msg.Err.Error(), "stopped after 1 redirects") {
+		t.Errorf("two redirects with a limit of 1: error %v, want the redirects stopped", msg.Err)
+	}
+
+	if err := SetMaxRedirects(name, 0); err != nil {
+		t.Fatal(err)
+	}
+	msg = PerformSearch("pacman", name, SearchOptions{})(context.Background()).(SearchMsg)
+	var redirectErr *RedirectError
+	if !errors.As(msg.Err, &redirectErr) || redirectErr.To != strings.TrimPrefix(canonical.URL, "http://") {
+		t.Errorf("search with redirects disabled: error %v, want a RedirectError to the canonical host", msg.Err)
+	}
+	if err := SetMaxRedirects(name, -1); err == nil {
+		t.Error("SetMaxRedirects accepted a negative number")
+	}
+}
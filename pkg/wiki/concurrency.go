@@ -0,0 +1,72 @@
+package wiki
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// MaxConcurrentRequests bounds how many requests are in flight at once, across all wikis. One
+// of them is kept for requests the user is waiting for, so background requests such as related
+// titles can't hold up opening an article; with a limit of 1 no background requests are made.
+// It must be set before the first request.
+var MaxConcurrentRequests = 4
+
+// errNoBackgroundSlots is returned for background requests when MaxConcurrentRequests leaves
+// no room for them.
+var errNoBackgroundSlots = errors.New("background requests are off with at most one request in flight")
+
+// slots limits the requests in flight: all of them take a slot from all, and background ones
+// first take one from background, which has room for one fewer and is nil when that is none.
+var slots struct {
+	once       sync.Once
+	all        chan struct{}
+	background chan struct{}
+}
+
+// backgroundKey marks the context of a background request.
+type backgroundKey struct{}
+
+// inBackground marks requests made with ctx as background requests, which give way to the
+// requests the user is waiting for.
+func inBackground(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+// acquireSlot waits until the request may start, within MaxConcurrentRequests, and returns the
+// function that gives its slot back. It fails if ctx is done first, and right away for a
+// background request when there are no slots for them.
+func acquireSlot(ctx context.Context) (release func(), err error) {
+	slots.once.Do(func() {
+		limit := max(1, MaxConcurrentRequests)
+		slots.all = make(chan struct{}, limit)
+		if limit > 1 {
+			slots.background = make(chan struct{}, limit-1)
+		}
+	})
+	background, _ := ctx.Value(backgroundKey{}).(bool)
+	if background {
+		if slots.background == nil {
+			return nil, errNoBackgroundSlots
+		}
+		select {
+		case slots.background <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	select {
+	case slots.all <- struct{}{}:
+	case <-ctx.Done():
+		if background {
+			<-slots.background
+		}
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-slots.all
+		if background {
+			<-slots.background
+		}
+	}, nil
+}
@@ -0,0 +1,77 @@
+package wiki
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useSlots makes the next acquireSlot set the slots up for limit, as at startup.
+func useSlots(t *testing.T, limit int) {
+	t.Helper()
+	saved := MaxConcurrentRequests
+	MaxConcurrentRequests = limit
+	reset := func() {
+		slots.once = sync.Once{}
+		slots.all, slots.background = nil, nil
+	}
+	reset()
+	t.Cleanup(func() {
+		MaxConcurrentRequests = saved
+		reset()
+	})
+}
+
+// acquireWithin is acquireSlot giving up after a short while.
+func acquireWithin(t *testing.T, ctx context.Context) (release func(), err error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	return acquireSlot(ctx)
+}
+
+func TestAcquireSlotCap(t *testing.T) {
+	useSlots(t, 3)
+	background := inBackground(context.Background())
+	var releases []func()
+	for i := range 2 {
+		release, err := acquireWithin(t, background)
+		if err != nil {
+			t.Fatalf("background request %d: %v", i+1, err)
+		}
+		releases = append(releases, release)
+	}
+	if _, err := acquireWithin(t, background); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("third background request of 3 slots: err = %v, want it to wait", err)
+	}
+	release, err := acquireWithin(t, context.Background())
+	if err != nil {
+		t.Fatalf("foreground request with the kept slot free: %v", err)
+	}
+	if _, err := acquireWithin(t, context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fourth request of 3 slots: err = %v, want it to wait", err)
+	}
+	release()
+	for _, release := range releases {
+		release()
+	}
+	if release, err := acquireWithin(t, background); err != nil {
+		t.Errorf("background request after the others finished: %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestAcquireSlotSkipsBackgroundWithOneSlot(t *testing.T) {
+	useSlots(t, 1)
+	if _, err := acquireSlot(inBackground(context.Background())); !errors.Is(err, errNoBackgroundSlots) {
+		t.Errorf("background request with 1 slot: err = %v, want %v", err, errNoBackgroundSlots)
+	}
+	release, err := acquireWithin(t, context.Background())
+	if err != nil {
+		t.Fatalf("foreground request with 1 slot: %v", err)
+	}
+	release()
+}
@@ -0,0 +1,57 @@
+package wiki
+
+import "sync"
+
+// The content modes choose how an article's text is made from what the wiki returns.
+const (
+	// ContentReadable renders the parsed article and keeps what readability considers the
+	// article's main content.
+	ContentReadable = "readable"
+	// ContentExtract uses the plain-text extract of the article, which drops tables,
+	// infoboxes and lists but is quick to fetch.
+	ContentExtract = "extract"
+	// ContentHTML renders the parsed article to text as it is, infoboxes and navigation
+	// boxes included.
+	ContentHTML = "html"
+)
+
+// ContentModes lists the content modes, in the order the in-app toggle cycles through them.
+var ContentModes = []string{ContentReadable, ContentExtract, ContentHTML}
+
+// contentMode is the mode articles are fetched in. It is guarded as the app switches it while
+// fetches may be running.
+var contentMode = struct {
+	sync.Mutex
+	mode string
+}{mode: ContentReadable}
+
+// ContentMode returns the mode articles are fetched in.
+func ContentMode() string {
+	contentMode.Lock()
+	defer contentMode.Unlock()
+	return contentMode.mode
+}
+
+// SetContentMode sets the mode articles are fetched in from now on. Cached articles fetched in
+// another mode are fetched again when they are next opened.
+func SetContentMode(mode string) {
+	contentMode.Lock()
+	defer contentMode.Unlock()
+	contentMode.mode = mode
+}
+
+// NextContentMode returns the content mode after mode in ContentModes, wrapping around.
+func NextContentMode(mode string) string {
+	for i, m := range ContentModes {
+		if m == mode {
+			return ContentModes[(i+1)%len(ContentModes)]
+		}
+	}
+	return ContentModes[0]
+}
+
+// fetchedAs reports whether the article was fetched in mode. Articles cached before there
+// were modes were fetched as readable.
+func (a Article) fetchedAs(mode string) bool {
+	return a.Mode == mode || (a.Mode == "" && mode == ContentReadable)
+}
@@ -0,0 +1,153 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFetchArticleContentModes(t *testing.T) {
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	html := `<div><h2>History</h2>` + strings.Repeat(`<p>Go was designed at Google to improve programming productivity, with fast builds and simple concurrency.</p>`, 8) + `</div>`
+	parse, _ := json.Marshal(map[string]any{"parse": map[string]any{
+		"title":    "Go (programming language)",
+		"text":     map[string]string{"*": html},
+		"sections": []Section{{Index: "1", Level: "2", Line: "History", Number: "1"}},
+	}})
+	extract, _ := json.Marshal(map[string]any{"query": map[string]any{"pages": []map[string]string{{
+		"title":   "Go (programming language)",
+		"extract": "Go is a programming language.\n\n\n== History ==\nGo was designed at Google.\n\n\n=== Origins ===\nIn 2007.\n\n\n== Design ==\nIt is compiled.",
+	}}}})
+
+	var mu sync.Mutex
+	var requests []url.Values
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/w/api.php" {
+			t.Errorf("request to %s, want the API", r.URL.Path)
+		}
+		query := r.URL.Query()
+		switch {
+		case query.Get("action") == "parse":
+			w.Write(parse)
+		case query.Get("prop") == "extracts":
+			w.Write(extract)
+		default:
+			// The description and coordinates, fetched in every mode.
+			w.Write([]byte(`{}`))
+			return
+		}
+		mu.Lock()
+		requests = append(requests, query)
+		mu.Unlock()
+	})
+
+	parseParams := map[string]string{"action": "parse", "page": "Go", "prop": "text|sections|displaytitle|categories", "redirects": "1"}
+	tests := []struct {
+		mode   string
+		params map[string]string
+	}{
+		{ContentReadable, parseParams},
+		{ContentHTML, parseParams},
+		{ContentExtract, map[string]string{"action": "query", "titles": "Go", "prop": "extracts", "explaintext": "1", "exsectionformat": "wiki", "redirects": "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			requests = nil
+			msg := fetchArticle(context.Background(), "Go", name, tt.mode)
+			if msg.Err != nil {
+				t.Fatal(msg.Err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("made %d requests for the content, want 1: %v", len(requests), requests)
+			}
+			for key, want := range tt.params {
+				if got := requests[0].Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			if msg.Mode != tt.mode || msg.Title != "Go (programming language)" || !strings.Contains(msg.Content, "designed at Google") {
+				t.Errorf("article = %+v", msg.Article)
+			}
+			if len(msg.Sections) == 0 {
+				t.Error("no sections")
+			}
+		})
+	}
+}
+
+func TestExtractSections(t *testing.T) {
+	content, sections := extractSections("Intro.\n\n== History ==\nText.\n\n==== Origins ====\nMore.\n\n== A & B ==\nEnd.")
+	if want := "Intro.\n\nHistory\nText.\n\nOrigins\nMore.\n\nA & B\nEnd."; content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+	want := []Section{
+		{Index: "1", Level: "2", Line: "History", Number: "1", Anchor: "History"},
+		{Index: "2", Level: "4", Line: "Origins", Number: "1.1", Anchor: "Origins"},
+		{Index: "3", Level: "2", Line: "A &amp; B", Number: "2", Anchor: "A_&_B"},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("sections = %+v, want %+v", sections, want)
+	}
+	if offsets := SectionOffsets(content, sections); offsets[2] != strings.Index(content, "A & B") {
+		t.Errorf("offsets = %v; the headings aren't found in the text", offsets)
+	}
+}
+
+func TestFetchExtractContinuation(t *testing.T) {
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	// The extract arrives in two parts; the continue token is a number in one API version and
+	// a string in another, and is sent back as given.
+	parts := map[string]string{
+		"":  `{"continue":{"excontinue":1,"continue":"||"},"query":{"pages":[{"title":"Go","extract":"Go is a language.\n\n== History ==\n"}]}}`,
+		"1": `{"continue":{"excontinue":"2","continue":"||"},"query":{"pages":[{"title":"Go","extract":"It was designed at Google.\n"}]}}`,
+		"2": `{"batchcomplete":true,"query":{"pages":[{"title":"Go","extract":"It is fast."}]}}`,
+	}
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("excontinue") != "" && r.FormValue("continue") != "||" {
+			t.Errorf("continuation without the continue token: %v", r.Form)
+		}
+		w.Write([]byte(parts[r.FormValue("excontinue")]))
+	})
+	got, err := fetchExtract(context.Background(), "Go", name, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Go is a language.\n\nHistory\nIt was designed at Google.\nIt is fast."; got.Content != want {
+		t.Errorf("content = %q, want %q", got.Content, want)
+	}
+	if got.Title != "Go" || len(got.Sections) != 1 || got.Sections[0].Line != "History" {
+		t.Errorf("extract = %+v, want titled Go with the History section", got)
+	}
+}
+
+func TestFetchExtractContinuationCap(t *testing.T) {
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	var mu sync.Mutex
+	requests := 0
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		// Always more to come.
+		w.Write([]byte(`{"continue":{"excontinue":1,"continue":"||"},"query":{"pages":[{"title":"Go","extract":"More. "}]}}`))
+	})
+	if _, err := fetchExtract(context.Background(), "Go", name, false); err == nil || !strings.Contains(err.Error(), "still incomplete") {
+		t.Errorf("error = %v, want the extract reported incomplete", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != maxContinuations+1 {
+		t.Errorf("%d requests, want %d", requests, maxContinuations+1)
+	}
+}
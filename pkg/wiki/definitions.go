@@ -0,0 +1,95 @@
+package wiki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// definitionURL is Wiktionary's REST endpoint for the definitions of a word.
+const definitionURL = "https://en.wiktionary.org/api/rest_v1/page/definition/"
+
+// maxDefinitions bounds how many definitions are shown for a word.
+const maxDefinitions = 5
+
+// Definition is one sense of a word.
+type Definition struct {
+	PartOfSpeech string
+	Text         string
+}
+
+// DefinitionMsg carries the English definitions of a word from Wiktionary.
+type DefinitionMsg struct {
+	Word        string
+	Definitions []Definition
+	Err         error
+}
+
+// definitionResponse matches the REST API's definition response, keyed by language code.
+type definitionResponse map[string][]struct {
+	PartOfSpeech string `json:"partOfSpeech"`
+	Definitions  []struct {
+		Definition string `json:"definition"`
+	} `json:"definitions"`
+}
+
+// definitionCache keeps looked-up definitions for the session.
+var definitionCache = struct {
+	sync.Mutex
+	entries map[string]DefinitionMsg
+}{entries: map[string]DefinitionMsg{}}
+
+// Define looks up the English definitions of word on Wiktionary. A word without an entry
+// gets a DefinitionMsg without definitions rather than an error.
+func Define(word string) Request {
+	return func(ctx context.Context) tea.Msg {
+		word = strings.TrimSpace(word)
+		definitionCache.Lock()
+		msg, ok := definitionCache.entries[word]
+		definitionCache.Unlock()
+		if ok {
+			return msg
+		}
+		msg = define(ctx, word)
+		if msg.Err == nil {
+			definitionCache.Lock()
+			definitionCache.entries[word] = msg
+			definitionCache.Unlock()
+		}
+		return msg
+	}
+}
+
+// define requests the definitions of word.
+func define(ctx context.Context, word string) DefinitionMsg {
+	msg := DefinitionMsg{Word: word}
+	if word == "" {
+		return msg
+	}
+	var data definitionResponse
+	err := fetchJSON(ctx, "definition lookup", SearchTimeout, definitionURL+url.PathEscape(word), &data)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		return msg
+	}
+	if err != nil {
+		msg.Err = fmt.Errorf("could not look up %q: %w", word, err)
+		return msg
+	}
+	for _, entry := range data["en"] {
+		for _, definition := range entry.Definitions {
+			text := stripMarkup(definition.Definition)
+			if text == "" || len(msg.Definitions) == maxDefinitions {
+				continue
+			}
+			msg.Definitions = append(msg.Definitions, Definition{PartOfSpeech: entry.PartOfSpeech, Text: text})
+		}
+	}
+	return msg
+}
@@ -0,0 +1,195 @@
+package wiki
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"wiki-search/pkg/utils"
+)
+
+// VisitedArticle is an article read during the session, in the order it was first opened.
+type VisitedArticle struct {
+	Title    string
+	WikiType string
+}
+
+// ExportSession writes the visited articles into a single Markdown document at path, with a
+// table of contents linking to each article and its sections. The content comes from the
+// article cache; articles no longer cached are listed as skipped instead.
+func ExportSession(history []VisitedArticle, path string) error {
+	return os.WriteFile(path, []byte(sessionMarkdown(history, time.Now())), 0o644)
+}
+
+// sessionMarkdown renders the document written by ExportSession.
+func sessionMarkdown(history []VisitedArticle, now time.Time) string {
+	type exported struct {
+		visit    VisitedArticle
+		article  Article
+		anchor   string
+		sections []exportedSection
+	}
+	anchors := anchorSet{}
+	anchors.add("Reading session")
+	anchors.add("Contents")
+	var articles []exported
+	var skipped []VisitedArticle
+	for _, visit := range history {
+		article, ok := cachedArticle(visit.WikiType, visit.Title)
+		if !ok {
+			skipped = append(skipped, visit)
+			continue
+		}
+		e := exported{visit: visit, article: article, anchor: anchors.add(exportTitle(visit, article))}
+		e.sections = exportSections(article, anchors)
+		articles = append(articles, e)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Reading session\n\n")
+	sb.WriteString(fmt.Sprintf("Exported by wiki-search on %s.\n\n", now.Format("2006-01-02 15:04")))
+	if len(skipped) > 0 {
+		sb.WriteString("Skipped because they are no longer cached:\n\n")
+		for _, visit := range skipped {
+			sb.WriteString(fmt.Sprintf("- %s (%s)\n", visit.Title, LookupSource(visit.WikiType).Label))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("## Contents\n\n")
+	for i, e := range articles {
+		sb.WriteString(fmt.Sprintf("%d. [%s](#%s)\n", i+1, exportTitle(e.visit, e.article), e.anchor))
+		for _, section := range e.sections {
+			indent := strings.Repeat("   ", section.level-2)
+			sb.WriteString(fmt.Sprintf("%s- [%s](#%s)\n", indent, section.title, section.anchor))
+		}
+	}
+	sb.WriteString("\n")
+	for _, e := range articles {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", exportTitle(e.visit, e.article)))
+		sb.WriteString(fmt.Sprintf("*%s* · <%s>\n\n", LookupSource(e.visit.WikiType).Label, ArticleURL(e.visit.Title, e.visit.WikiType)))
+		sb.WriteString(exportBody(e.article, e.sections))
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// exportedSection is a section heading of an exported article.
+type exportedSection struct {
+	title  string
+	anchor string
+	// level is the Markdown heading level; articles are level 2, so sections start at 3.
+	level  int
+	offset int
+}
+
+// exportSections finds the sections of an article that can be located in its text.
+func exportSections(article Article, anchors anchorSet) []exportedSection {
+	var sections []exportedSection
+	for i, offset := range SectionOffsets(article.Content, article.Sections) {
+		depth := article.Sections[i].Depth()
+		if offset < 0 || depth == 0 {
+			continue
+		}
+		title := article.Sections[i].Title()
+		sections = append(sections, exportedSection{title: title, anchor: anchors.add(title), level: depth, offset: offset})
+	}
+	// The top sections become level 3 headings, under the article's, whatever level the wiki
+	// gave them.
+	if len(sections) > 0 {
+		top := sections[0].level
+		for _, section := range sections {
+			top = min(top, section.level)
+		}
+		for i := range sections {
+			sections[i].level = min(6, sections[i].level-top+3)
+		}
+	}
+	return sections
+}
+
+// exportBody returns the text of an article with its section headings as Markdown headings.
+// Tables are laid out in columns inside code blocks, which keeps them aligned.
+func exportBody(article Article, sections []exportedSection) string {
+	var sb strings.Builder
+	last := 0
+	for _, section := range sections {
+		sb.WriteString(exportText(article.Content[last:section.offset]))
+		sb.WriteString(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", section.level), section.title))
+		// Skip the heading's own line in the text.
+		last = section.offset
+		if end := strings.IndexByte(article.Content[last:], '\n'); end >= 0 {
+			last += end + 1
+		} else {
+			last = len(article.Content)
+		}
+	}
+	sb.WriteString(exportText(article.Content[last:]))
+	return sb.String()
+}
+
+// exportText turns a stretch of article text into Markdown. Citation markers are dropped, as
+// they don't link anywhere in the export.
+func exportText(text string) string {
+	var sb strings.Builder
+	for _, block := range utils.SplitCodeBlocks(text) {
+		switch {
+		case block.Code && block.Lang == utils.TableLang:
+			sb.WriteString("```\n" + utils.RenderTable(utils.ParseTable(block.Text).Map(utils.StripCitations), 0) + "```\n\n")
+		case block.Code && block.Lang == utils.QuoteLang:
+			var paragraphs []string
+			for _, line := range strings.Split(utils.StripCitations(block.Text), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					paragraphs = append(paragraphs, line)
+				}
+			}
+			if len(paragraphs) > 0 {
+				sb.WriteString("> " + strings.Join(paragraphs, "\n>\n> ") + "\n\n")
+			}
+		case block.Code:
+			sb.WriteString(utils.CodeFence + block.Lang + "\n" + block.Text + "\n" + utils.CodeFence + "\n\n")
+		default:
+			// Each line of article text is a paragraph or list item, which Markdown would
+			// otherwise join with the next line. Indentation is dropped, as Markdown would take
+			// deeply indented lines for code.
+			for _, line := range strings.Split(utils.StripCitations(block.Text), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					sb.WriteString(line + "\n\n")
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+// exportTitle is the heading of an exported article: its display title, if known.
+func exportTitle(visit VisitedArticle, article Article) string {
+	if article.DisplayTitle != "" {
+		return article.DisplayTitle
+	}
+	return visit.Title
+}
+
+// anchorSet hands out the anchors Markdown renderers such as GitHub's give headings: the
+// heading in lower case, with spaces as dashes and punctuation dropped, and a numeric suffix
+// for repeated headings.
+type anchorSet map[string]int
+
+// add returns the anchor of the next heading with the given text.
+func (a anchorSet) add(heading string) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ' || r == '-':
+			return '-'
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, strings.TrimSpace(heading))
+	n := a[slug]
+	a[slug] = n + 1
+	if n > 0 {
+		return fmt.Sprintf("%s-%d", slug, n)
+	}
+	return slug
+}
@@ -0,0 +1,50 @@
+package wiki
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionMarkdown(t *testing.T) {
+	RestoreCache([]CacheEntry{
+		{WikiType: "wikipedia", Title: "Go", FetchedAt: time.Now(), Article: Article{
+			DisplayTitle: "Go (programming language)",
+			Content:      "Go is a programming language.[1]\n\nHistory\n\nGo was designed at Google.\n\nDesign\n\nIt is compiled.\n",
+			Sections: []Section{
+				{Index: "1", Level: "2", Line: "History", Number: "1"},
+				{Index: "2", Level: "3", Line: "Design", Number: "1.1"},
+			},
+		}},
+		{WikiType: "arch", Title: "History", FetchedAt: time.Now(), Article: Article{
+			Content: "The history of Arch Linux.\n",
+		}},
+	})
+	history := []VisitedArticle{
+		{Title: "Go", WikiType: "wikipedia"},
+		{Title: "Gone", WikiType: "wikipedia"},
+		{Title: "History", WikiType: "arch"},
+	}
+	got := sessionMarkdown(history, time.Date(2024, 5, 6, 7, 8, 0, 0, time.UTC))
+	want := "# Reading session\n\n" +
+		"Exported by wiki-search on 2024-05-06 07:08.\n\n" +
+		"Skipped because they are no longer cached:\n\n" +
+		"- Gone (" + LookupSource("wikipedia").Label + ")\n\n" +
+		"## Contents\n\n" +
+		"1. [Go (programming language)](#go-programming-language)\n" +
+		"   - [History](#history)\n" +
+		"      - [Design](#design)\n" +
+		"2. [History](#history-1)\n\n" +
+		"## Go (programming language)\n\n" +
+		"*" + LookupSource("wikipedia").Label + "* · <" + ArticleURL("Go", "wikipedia") + ">\n\n" +
+		"Go is a programming language.\n\n" +
+		"### History\n\n" +
+		"Go was designed at Google.\n\n" +
+		"#### Design\n\n" +
+		"It is compiled.\n\n" +
+		"## History\n\n" +
+		"*" + LookupSource("arch").Label + "* · <" + ArticleURL("History", "arch") + ">\n\n" +
+		"The history of Arch Linux.\n"
+	if got != want {
+		t.Errorf("session document:\n%s\nwant:\n%s", got, want)
+	}
+}
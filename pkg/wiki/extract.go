@@ -0,0 +1,138 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxContinuations caps the follow-up requests fetchExtract makes for a single article.
+const maxContinuations = 5
+
+// extractResponse matches the query API's prop=extracts response in format version 2.
+type extractResponse struct {
+	Continue map[string]json.RawMessage `json:"continue"`
+	Query    struct {
+		Pages []struct {
+			Title   string `json:"title"`
+			Extract string `json:"extract"`
+		} `json:"pages"`
+	} `json:"query"`
+	Error *APIError `json:"error"`
+}
+
+// extract is the plain-text extract of an article.
+type extract struct {
+	// Title is the article's title, after following redirects.
+	Title    string
+	Content  string
+	Sections []Section
+}
+
+// fetchExtract fetches the plain-text extract of an article. Long extracts may be split across
+// responses; continue tokens are followed, up to maxContinuations times, and the parts are
+// concatenated in order. An extract that is still incomplete after that is reported as an error
+// rather than shown partially. With intro set, only the text before the first heading is
+// fetched, within SearchTimeout since it is meant to be quick. Headings are left on lines of
+// their own, as in the readable text, and listed in Sections.
+func fetchExtract(ctx context.Context, title string, wikiType string, intro bool) (extract, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	params.Set("prop", "extracts")
+	params.Set("explaintext", "1")
+	// Headings come as "== History ==", so their levels are known.
+	params.Set("exsectionformat", "wiki")
+	params.Set("redirects", "1")
+	params.Set("titles", title)
+	timeout := ArticleTimeout
+	if intro {
+		params.Set("exintro", "1")
+		timeout = SearchTimeout
+	}
+
+	var result extract
+	var content strings.Builder
+	for range maxContinuations + 1 {
+		var data extractResponse
+		if err := fetchJSON(ctx, "article fetch", timeout, apiRequest(wikiType, params), &data); err != nil {
+			return extract{}, err
+		}
+		if data.Error != nil {
+			return extract{}, data.Error
+		}
+		for _, page := range data.Query.Pages {
+			if result.Title == "" {
+				result.Title = page.Title
+			}
+			content.WriteString(page.Extract)
+		}
+		if len(data.Continue) == 0 {
+			result.Content, result.Sections = extractSections(content.String())
+			return result, nil
+		}
+		for key, raw := range data.Continue {
+			params.Set(key, continueValue(raw))
+		}
+	}
+	return extract{}, fmt.Errorf("extract of %q is still incomplete after %d continuations", title, maxContinuations)
+}
+
+// article returns the extract as an article fetched in mode. Extracts have no display title,
+// revision or categories.
+func (e extract) article(mode string) Article {
+	return Article{Title: e.Title, Content: e.Content, Sections: e.Sections, Mode: mode}
+}
+
+// extractHeading matches a heading line of an extract in the wiki section format.
+var extractHeading = regexp.MustCompile(`^(={2,6})\s*(.*?)\s*={2,6}$`)
+
+// extractSections turns the "== History ==" heading lines of an extract into plain lines and
+// lists them as sections, numbered as the parse API numbers them.
+func extractSections(text string) (string, []Section) {
+	lines := strings.Split(text, "\n")
+	var sections []Section
+	var numbers []int
+	for i, line := range lines {
+		match := extractHeading.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || match[2] == "" {
+			continue
+		}
+		lines[i] = match[2]
+		level := len(match[1])
+		// Levels start at 2; a skipped level, as in ==== after ==, still nests only one deeper.
+		if depth := min(level-1, len(numbers)+1); depth <= len(numbers) {
+			numbers = numbers[:depth]
+			numbers[depth-1]++
+		} else {
+			numbers = append(numbers, 1)
+		}
+		parts := make([]string, len(numbers))
+		for j, n := range numbers {
+			parts[j] = strconv.Itoa(n)
+		}
+		sections = append(sections, Section{
+			Index:  strconv.Itoa(len(sections) + 1),
+			Level:  strconv.Itoa(level),
+			Line:   html.EscapeString(match[2]),
+			Number: strings.Join(parts, "."),
+			Anchor: strings.ReplaceAll(match[2], " ", "_"),
+		})
+	}
+	return strings.Join(lines, "\n"), sections
+}
+
+// continueValue converts a continue token, which may be a JSON string or number, to a parameter value.
+func continueValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
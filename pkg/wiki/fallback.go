@@ -0,0 +1,58 @@
+package wiki
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languageEdition returns the edition of a Wikimedia wiki in another language, e.g. German
+// Wikipedia for English Wikipedia and "de", found by swapping the language in its address.
+// Wikis whose address doesn't start with their language, such as Simple English Wikipedia
+// and ArchWiki, have no editions, and neither has a wiki in the language asked for.
+func languageEdition(source Source, lang string) (Source, bool) {
+	if lang == source.Language {
+		return Source{}, false
+	}
+	prefix := "https://" + source.Language + "."
+	swap := func(address string) (string, bool) {
+		rest, ok := strings.CutPrefix(address, prefix)
+		return "https://" + lang + "." + rest, ok
+	}
+	api, apiOK := swap(source.API)
+	articles, articlesOK := swap(source.Articles)
+	index, indexOK := swap(source.Index)
+	if !apiOK || !articlesOK || !indexOK {
+		return Source{}, false
+	}
+	site := source.Site
+	if site == "" {
+		site = source.Label
+	}
+	edition := Source{
+		Name:         source.Name + "-" + lang,
+		Label:        fmt.Sprintf("%s (%s)", site, lang),
+		Site:         site,
+		Group:        source.Group,
+		API:          api,
+		Articles:     articles,
+		Index:        index,
+		Language:     lang,
+		MaxRedirects: source.MaxRedirects,
+		FallbackFor:  source.Name,
+	}
+	return edition, true
+}
+
+// SetFallbackLanguage adds the edition in lang of every wiki that has one, as the wiki searched
+// when a search of the original finds nothing. The editions aren't listed in the menu. It is
+// meant to be called once, at startup, before any request is made.
+func SetFallbackLanguage(lang string) {
+	var editions []Source
+	for i, source := range Sources {
+		if edition, ok := languageEdition(source, lang); ok {
+			Sources[i].Fallback = edition.Name
+			editions = append(editions, edition)
+		}
+	}
+	Sources = append(Sources, editions...)
+}
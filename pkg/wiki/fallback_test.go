@@ -0,0 +1,38 @@
+package wiki
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetFallbackLanguage(t *testing.T) {
+	sources := slices.Clone(Sources)
+	t.Cleanup(func() { Sources = sources })
+	SetFallbackLanguage("de")
+
+	tests := []struct {
+		name, fallback, label, api string
+	}{
+		{"wikipedia", "wikipedia-de", "Wikipedia (de)", "https://de.wikipedia.org/w/api.php"},
+		{"wiktionary", "wiktionary-de", "Wiktionary (de)", "https://de.wiktionary.org/w/api.php"},
+		{"simple", "", "", ""},
+		{"arch", "", "", ""},
+	}
+	for _, tt := range tests {
+		source := LookupSource(tt.name)
+		if source.Fallback != tt.fallback {
+			t.Errorf("fallback of %s = %q, want %q", tt.name, source.Fallback, tt.fallback)
+			continue
+		}
+		if tt.fallback == "" {
+			continue
+		}
+		edition := LookupSource(tt.fallback)
+		if edition.Label != tt.label || edition.API != tt.api || edition.Language != "de" || edition.FallbackFor != tt.name {
+			t.Errorf("edition of %s = %+v", tt.name, edition)
+		}
+	}
+	if names := SourceNames(); !slices.Equal(names, []string{"wikipedia", "simple", "wikiquote", "wikisource", "wikivoyage", "wiktionary", "arch"}) {
+		t.Errorf("menu lists %v; the editions should be left out", names)
+	}
+}
@@ -0,0 +1,92 @@
+package wiki
+
+import "strings"
+
+// ResultFilter transforms search results before they are displayed.
+type ResultFilter func([]SearchResult) []SearchResult
+
+// NoFilter returns the results unchanged.
+func NoFilter(results []SearchResult) []SearchResult {
+	return results
+}
+
+// ChainFilters composes filters into a single filter, applying them in order.
+func ChainFilters(filters ...ResultFilter) ResultFilter {
+	return func(results []SearchResult) []SearchResult {
+		for _, filter := range filters {
+			if filter != nil {
+				results = filter(results)
+			}
+		}
+		return results
+	}
+}
+
+// DropEmptyTitles removes results whose title is blank.
+func DropEmptyTitles(results []SearchResult) []SearchResult {
+	filtered := make([]SearchResult, 0, len(results))
+	for _, result := range results {
+		if strings.TrimSpace(result.Title) != "" {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// DedupeResults removes results whose title was already seen, keeping the first.
+func DedupeResults(results []SearchResult) []SearchResult {
+	seen := make(map[string]bool, len(results))
+	filtered := make([]SearchResult, 0, len(results))
+	for _, result := range results {
+		if seen[result.Title] {
+			continue
+		}
+		seen[result.Title] = true
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// AddRelated appends titles as related results, skipping titles already among results.
+func AddRelated(results []SearchResult, titles []string) []SearchResult {
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		seen[result.Title] = true
+	}
+	for _, title := range titles {
+		if seen[title] || strings.TrimSpace(title) == "" {
+			continue
+		}
+		seen[title] = true
+		results = append(results, SearchResult{Title: title, Related: true})
+	}
+	return results
+}
+
+// AppendPage adds a further page of results to results, ahead of the related ones AddRelated
+// appended, which stay last. Titles already listed are left out of the page, and related
+// titles the page now lists are dropped.
+func AppendPage(results, page []SearchResult) []SearchResult {
+	var found, related []SearchResult
+	listed := map[string]bool{}
+	for _, result := range results {
+		if result.Related {
+			related = append(related, result)
+			continue
+		}
+		found = append(found, result)
+		listed[result.Title] = true
+	}
+	for _, result := range page {
+		if !listed[result.Title] {
+			listed[result.Title] = true
+			found = append(found, result)
+		}
+	}
+	for _, result := range related {
+		if !listed[result.Title] {
+			found = append(found, result)
+		}
+	}
+	return found
+}
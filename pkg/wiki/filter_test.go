@@ -0,0 +1,87 @@
+package wiki
+
+import (
+	"slices"
+	"testing"
+)
+
+// titles returns the titles of results, related ones marked with a "+".
+func titles(results []SearchResult) []string {
+	var list []string
+	for _, result := range results {
+		title := result.Title
+		if result.Related {
+			title = "+" + title
+		}
+		list = append(list, title)
+	}
+	return list
+}
+
+// titled makes search results with the given titles.
+func titled(list ...string) []SearchResult {
+	var results []SearchResult
+	for _, title := range list {
+		results = append(results, SearchResult{Title: title})
+	}
+	return results
+}
+
+func TestResultFilters(t *testing.T) {
+	// appendTitle is a filter that adds a result, to show the order filters run in.
+	appendTitle := func(title string) ResultFilter {
+		return func(results []SearchResult) []SearchResult {
+			return append(results, SearchResult{Title: title})
+		}
+	}
+	tests := []struct {
+		name   string
+		filter ResultFilter
+		in     []SearchResult
+		want   []string
+	}{
+		{"no filter", NoFilter, titled("Go", "", "Go"), []string{"Go", "", "Go"}},
+		{"drop empty titles", DropEmptyTitles, titled("Go", "", "  ", "C"), []string{"Go", "C"}},
+		{"dedupe keeps the first", DedupeResults, titled("Go", "C", "Go", "Rust", "C"), []string{"Go", "C", "Rust"}},
+		{"dedupe is case-sensitive", DedupeResults, titled("Go", "GO"), []string{"Go", "GO"}},
+		{"chain runs in order", ChainFilters(appendTitle("A"), appendTitle("B")), titled("Go"), []string{"Go", "A", "B"}},
+		{"chain skips nil", ChainFilters(nil, DropEmptyTitles, nil), titled("", "Go"), []string{"Go"}},
+		{"empty chain", ChainFilters(), titled("Go"), []string{"Go"}},
+		{"dedupe then add", ChainFilters(DedupeResults, appendTitle("Go")), titled("Go", "Go"), []string{"Go", "Go"}},
+		{"add then dedupe", ChainFilters(appendTitle("Go"), DedupeResults), titled("Go", "Go"), []string{"Go"}},
+		{"drop then dedupe", ChainFilters(DropEmptyTitles, DedupeResults), titled("", "Go", " ", "Go"), []string{"Go"}},
+	}
+	for _, tt := range tests {
+		if got := titles(tt.filter(tt.in)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppendPage(t *testing.T) {
+	shown := AddRelated(titled("Go", "Gopher"), []string{"Go (game)", "Golang"})
+	got := titles(AppendPage(shown, titled("Gopher", "Go (game)", "Go!")))
+	want := []string{"Go", "Gopher", "Go (game)", "Go!", "+Golang"}
+	if !slices.Equal(got, want) {
+		t.Errorf("AppendPage() = %q, want %q", got, want)
+	}
+}
+
+func TestAddRelated(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []SearchResult
+		related []string
+		want    []string
+	}{
+		{"appended after the results", titled("Go"), []string{"Gopher", "Golang"}, []string{"Go", "+Gopher", "+Golang"}},
+		{"skips titles already found", titled("Go", "Gopher"), []string{"Go", "Golang", "Gopher"}, []string{"Go", "Gopher", "+Golang"}},
+		{"skips repeated and blank titles", nil, []string{"Go", "", " ", "Go"}, []string{"+Go"}},
+		{"nothing to add", titled("Go"), nil, []string{"Go"}},
+	}
+	for _, tt := range tests {
+		if got := titles(AddRelated(tt.results, tt.related)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// generatedPage is a page returned by a query with generator=search.
+type generatedPage struct {
+	Title       string `json:"title"`
+	Index       int    `json:"index"`
+	Description string `json:"description"`
+	Extract     string `json:"extract"`
+	Length      int    `json:"length"`
+}
+
+// pageList holds the pages of a query response. The API returns them as an object keyed by
+// page ID, in no particular order; they are sorted by their search rank instead.
+type pageList []generatedPage
+
+func (p *pageList) UnmarshalJSON(data []byte) error {
+	var pages map[string]generatedPage
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return err
+	}
+	*p = make(pageList, 0, len(pages))
+	for _, page := range pages {
+		*p = append(*p, page)
+	}
+	sort.SliceStable(*p, func(i, j int) bool { return (*p)[i].Index < (*p)[j].Index })
+	return nil
+}
+
+// generatorResponse matches the query API's response to generator=search with prop=extracts|description.
+type generatorResponse struct {
+	Query struct {
+		Pages pageList `json:"pages"`
+	} `json:"query"`
+	Continue *struct {
+		Gsroffset int `json:"gsroffset"`
+	} `json:"continue"`
+	Error *APIError `json:"error"`
+}
+
+// maxExtracts is the most pages the TextExtracts extension returns an extract for in one
+// request; it answers exlimit=max with this and leaves any further pages without one.
+const maxExtracts = 20
+
+// snippetLimit returns the number of results to ask a snippet search for: limit, or the
+// API's default of 10 for zero, capped at maxExtracts so every result has its extract.
+func snippetLimit(limit int) int {
+	if limit <= 0 {
+		return 10
+	}
+	return min(limit, maxExtracts)
+}
+
+// searchWithSnippets runs a search whose results carry a short description and extract,
+// fetched in the same request as the titles.
+func searchWithSnippets(ctx context.Context, term string, wikiType string, opts SearchOptions) SearchMsg {
+	params := url.Values{}
+	params.Add("action", "query")
+	params.Add("format", "json")
+	params.Add("generator", "search")
+	search, what := searchQuery(term, opts)
+	params.Add("gsrsearch", search)
+	if opts.Sort != "" {
+		params.Add("gsrsort", opts.Sort)
+	}
+	if what != "" {
+		params.Add("gsrwhat", what)
+	}
+	if opts.Namespace != 0 {
+		params.Add("gsrnamespace", strconv.Itoa(opts.Namespace))
+	}
+	params.Add("gsrlimit", strconv.Itoa(snippetLimit(opts.Limit)))
+	if opts.Offset > 0 {
+		params.Add("gsroffset", strconv.Itoa(opts.Offset))
+	}
+	params.Add("prop", "extracts|description|info")
+	params.Add("exintro", "1")
+	params.Add("explaintext", "1")
+	params.Add("exsentences", "2")
+	params.Add("exlimit", "max")
+	fullURL := apiRequest(wikiType, params)
+
+	var data generatorResponse
+	if err := fetchJSON(ctx, "search", SearchTimeout, fullURL, &data); err != nil {
+		return SearchMsg{Err: err}
+	}
+	if data.Error != nil {
+		return SearchMsg{Err: data.Error}
+	}
+	msg := SearchMsg{Offset: opts.Offset}
+	for _, page := range data.Query.Pages {
+		msg.Results = append(msg.Results, SearchResult{Title: page.Title, Description: page.Description, Extract: page.Extract, Size: page.Length})
+	}
+	msg.Results = normalizeResults(msg.Results)
+	if data.Continue != nil {
+		msg.NextOffset = data.Continue.Gsroffset
+	}
+	return msg
+}
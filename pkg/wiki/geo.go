@@ -0,0 +1,25 @@
+package wiki
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Coordinates locate the subject of an article, such as a place, in decimal degrees.
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// String formats the coordinates as "lat, lon", to about ten metres.
+func (c Coordinates) String() string {
+	return fmt.Sprintf("%.4f, %.4f", c.Lat, c.Lon)
+}
+
+// MapURL returns the address of the coordinates on OpenStreetMap, with a marker on them.
+func MapURL(c Coordinates) string {
+	params := url.Values{}
+	params.Set("mlat", fmt.Sprintf("%.5f", c.Lat))
+	params.Set("mlon", fmt.Sprintf("%.5f", c.Lon))
+	return fmt.Sprintf("https://www.openstreetmap.org/?%s#map=15/%.5f/%.5f", params.Encode(), c.Lat, c.Lon)
+}
@@ -0,0 +1,67 @@
+package wiki
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMapURL(t *testing.T) {
+	tests := []struct {
+		c    Coordinates
+		want string
+	}{
+		{Coordinates{Lat: 52.52, Lon: 13.405}, "https://www.openstreetmap.org/?mlat=52.52000&mlon=13.40500#map=15/52.52000/13.40500"},
+		{Coordinates{Lat: -33.856784, Lon: 151.215297}, "https://www.openstreetmap.org/?mlat=-33.85678&mlon=151.21530#map=15/-33.85678/151.21530"},
+		{Coordinates{Lat: 0, Lon: -0.1}, "https://www.openstreetmap.org/?mlat=0.00000&mlon=-0.10000#map=15/0.00000/-0.10000"},
+	}
+	for _, tt := range tests {
+		if got := MapURL(tt.c); got != tt.want {
+			t.Errorf("MapURL(%+v) = %q, want %q", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestCoordinatesString(t *testing.T) {
+	if got := (Coordinates{Lat: -33.856784, Lon: 151.215297}).String(); got != "-33.8568, 151.2153" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParsePageInfo(t *testing.T) {
+	tests := []struct {
+		name, response string
+		want           pageInfo
+	}{
+		{
+			"description and coordinates",
+			`{"query":{"pages":[{"description":" Capital of Germany ","coordinates":[{"lat":52.52,"lon":13.405,"primary":true,"globe":"earth"}]}]}}`,
+			pageInfo{description: "Capital of Germany", coordinates: &Coordinates{Lat: 52.52, Lon: 13.405}},
+		},
+		{
+			"no globe given",
+			`{"query":{"pages":[{"coordinates":[{"lat":-33.8568,"lon":151.2153}]}]}}`,
+			pageInfo{coordinates: &Coordinates{Lat: -33.8568, Lon: 151.2153}},
+		},
+		{
+			"on the Moon",
+			`{"query":{"pages":[{"description":"Lunar crater","coordinates":[{"lat":-43.3,"lon":-11.2,"globe":"moon"}]}]}}`,
+			pageInfo{description: "Lunar crater"},
+		},
+		{
+			"not a place",
+			`{"query":{"pages":[{"description":"Programming language"}]}}`,
+			pageInfo{description: "Programming language"},
+		},
+		{"missing page", `{"query":{"pages":[{"missing":true}]}}`, pageInfo{}},
+	}
+	for _, tt := range tests {
+		var data pageInfoResponse
+		if err := json.Unmarshal([]byte(tt.response), &data); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := parsePageInfo(data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parsePageInfo() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package wiki
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Link is a link in an article to another article of the same wiki. Readable text keeps a
+// link's text but not where it leads, so the links are collected from the HTML beforehand.
+type Link struct {
+	// Text is the link's text as it reads in the article, and Title the article it leads to.
+	Text  string
+	Title string
+}
+
+// TitleFromURL extracts the article title from a link under a wiki's article path, e.g.
+// "Go (programming language)" from ".../wiki/Go_(programming_language)#History" and
+// "https://en.wikipedia.org/wiki/". Links with a query, such as those to pages that don't
+// exist yet, are not article links.
+func TitleFromURL(link, articles string) (string, bool) {
+	path, ok := strings.CutPrefix(link, articles)
+	if !ok {
+		return "", false
+	}
+	path, _, _ = strings.Cut(path, "#")
+	title, err := url.PathUnescape(path)
+	if err != nil || title == "" || strings.ContainsAny(title, "?") {
+		return "", false
+	}
+	return strings.ReplaceAll(title, "_", " "), true
+}
+
+// articleLinks collects the links to other articles in the HTML of an article, whose
+// relative addresses are resolved against the wiki's article path articles. Links without
+// text, such as images, are left out, and of links with the same text only the first is kept.
+func articleLinks(htmlContent, articles string) []Link {
+	base, err := url.Parse(articles)
+	if err != nil {
+		return nil
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(htmlContent), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return nil
+	}
+	var links []Link
+	seen := map[string]bool{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			if link, ok := articleLink(n, base, articles); ok && !seen[link.Text] {
+				seen[link.Text] = true
+				links = append(links, link)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return links
+}
+
+// articleLink returns the link an anchor element makes, if it leads to an article.
+func articleLink(a *html.Node, base *url.URL, articles string) (Link, bool) {
+	for _, attr := range a.Attr {
+		if attr.Key != "href" {
+			continue
+		}
+		target, err := base.Parse(attr.Val)
+		if err != nil {
+			return Link{}, false
+		}
+		title, ok := TitleFromURL(target.String(), articles)
+		text := strings.Join(strings.Fields(nodeText(a)), " ")
+		return Link{Text: text, Title: title}, ok && text != ""
+	}
+	return Link{}, false
+}
+
+// nodeText returns the text in the tree below n.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package wiki
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArticleLinks(t *testing.T) {
+	const articles = "https://en.wikipedia.org/wiki/"
+	html := `<p><a href="/wiki/Google">Google</a> designed <a href="/wiki/Go_(programming_language)#History" title="Go">the
+		Go language</a>, see <a href="#History">below</a>, <a href="/w/index.php?title=Gopher_lore&amp;action=edit&amp;redlink=1">gopher lore</a>,
+		<a href="https://example.com/">elsewhere</a> and <a href="/wiki/File:Gopher.png"><img src="gopher.png"></a>.
+		<a href="/wiki/Alphabet_Inc.">Google</a> again and <a href="/wiki/Stra%C3%9Fe">Straße</a>.</p>`
+	want := []Link{
+		{Text: "Google", Title: "Google"},
+		{Text: "the Go language", Title: "Go (programming language)"},
+		{Text: "Straße", Title: "Straße"},
+	}
+	if got := articleLinks(html, articles); !reflect.DeepEqual(got, want) {
+		t.Errorf("articleLinks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTitleFromURL(t *testing.T) {
+	const articles = "https://wiki.archlinux.org/title/"
+	tests := []struct {
+		link  string
+		title string
+		ok    bool
+	}{
+		{"https://wiki.archlinux.org/title/Installation_guide", "Installation guide", true},
+		{"https://wiki.archlinux.org/title/Pacman#Usage", "Pacman", true},
+		{"https://wiki.archlinux.org/title/", "", false},
+		{"https://wiki.archlinux.org/index.php?title=Foo", "", false},
+		{"https://example.com/title/Foo", "", false},
+	}
+	for _, tt := range tests {
+		if title, ok := TitleFromURL(tt.link, articles); title != tt.title || ok != tt.ok {
+			t.Errorf("TitleFromURL(%q) = %q, %v; want %q, %v", tt.link, title, ok, tt.title, tt.ok)
+		}
+	}
+}
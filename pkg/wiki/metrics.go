@@ -0,0 +1,109 @@
+package wiki
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics summarizes the requests made this session, for the summary printed on exit with
+// -verbose.
+type Metrics struct {
+	// Searches counts the searches run and ArticleFetches the articles opened or refreshed,
+	// CacheHits of which were served from the article cache.
+	Searches       int
+	ArticleFetches int
+	CacheHits      int
+	// Requests counts the API requests made, including those made in the background, and
+	// the latencies are over them, retries included.
+	Requests    int
+	MeanLatency time.Duration
+	P50Latency  time.Duration
+	P90Latency  time.Duration
+	P99Latency  time.Duration
+}
+
+// metrics collects the counters behind Metrics. Only the latencies are kept one by one, as the
+// percentiles need them; a session makes few enough requests for that.
+var metrics = struct {
+	sync.Mutex
+	searches       int
+	articleFetches int
+	cacheHits      int
+	latencies      []time.Duration
+}{}
+
+// countSearch records that a search was run.
+func countSearch() {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.searches++
+}
+
+// countArticleFetch records that an article was opened, and whether it came from the cache.
+func countArticleFetch(cached bool) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.articleFetches++
+	if cached {
+		metrics.cacheHits++
+	}
+}
+
+// recordLatency records how long an API request took.
+func recordLatency(d time.Duration) {
+	metrics.Lock()
+	defer metrics.Unlock()
+	metrics.latencies = append(metrics.latencies, d)
+}
+
+// CurrentMetrics returns the metrics of the requests made so far.
+func CurrentMetrics() Metrics {
+	metrics.Lock()
+	latencies := slices.Clone(metrics.latencies)
+	m := Metrics{
+		Searches:       metrics.searches,
+		ArticleFetches: metrics.articleFetches,
+		CacheHits:      metrics.cacheHits,
+		Requests:       len(latencies),
+	}
+	metrics.Unlock()
+	if len(latencies) == 0 {
+		return m
+	}
+	slices.Sort(latencies)
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	m.MeanLatency = total / time.Duration(len(latencies))
+	m.P50Latency = percentile(latencies, 50)
+	m.P90Latency = percentile(latencies, 90)
+	m.P99Latency = percentile(latencies, 99)
+	return m
+}
+
+// percentile returns the p-th percentile of sorted, by the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(0, rank-1)]
+}
+
+// String formats the metrics as a few lines for the terminal.
+func (m Metrics) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Searches: %d\n", m.Searches))
+	sb.WriteString(fmt.Sprintf("Article fetches: %d", m.ArticleFetches))
+	if m.ArticleFetches > 0 {
+		sb.WriteString(fmt.Sprintf(" (%d from the cache, %.0f%%)", m.CacheHits, 100*float64(m.CacheHits)/float64(m.ArticleFetches)))
+	}
+	sb.WriteString(fmt.Sprintf("\nAPI requests: %d\n", m.Requests))
+	if m.Requests > 0 {
+		round := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+		sb.WriteString(fmt.Sprintf("Latency: mean %s, p50 %s, p90 %s, p99 %s\n",
+			round(m.MeanLatency), round(m.P50Latency), round(m.P90Latency), round(m.P99Latency)))
+	}
+	return sb.String()
+}
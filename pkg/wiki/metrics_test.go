@@ -0,0 +1,140 @@
+package wiki
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// resetMetrics clears the metrics for a test, restoring them after it.
+func resetMetrics(t *testing.T) {
+	t.Helper()
+	metrics.Lock()
+	searches, fetches, hits, latencies := metrics.searches, metrics.articleFetches, metrics.cacheHits, metrics.latencies
+	metrics.searches, metrics.articleFetches, metrics.cacheHits, metrics.latencies = 0, 0, 0, nil
+	metrics.Unlock()
+	t.Cleanup(func() {
+		metrics.Lock()
+		metrics.searches, metrics.articleFetches, metrics.cacheHits, metrics.latencies = searches, fetches, hits, latencies
+		metrics.Unlock()
+	})
+}
+
+func TestCurrentMetrics(t *testing.T) {
+	resetMetrics(t)
+	if got := CurrentMetrics(); got != (Metrics{}) {
+		t.Errorf("CurrentMetrics() = %+v before any request, want none", got)
+	}
+
+	// Requests are recorded from many goroutines at once, in no particular order.
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recordLatency(time.Duration(100-i) * time.Millisecond)
+			if i%10 == 0 {
+				countSearch()
+			}
+			if i%4 == 0 {
+				countArticleFetch(i%8 == 0)
+			}
+		}()
+	}
+	wg.Wait()
+	want := Metrics{
+		Searches:       10,
+		ArticleFetches: 25,
+		CacheHits:      13,
+		Requests:       100,
+		MeanLatency:    50500 * time.Microsecond,
+		P50Latency:     50 * time.Millisecond,
+		P90Latency:     90 * time.Millisecond,
+		P99Latency:     99 * time.Millisecond,
+	}
+	if got := CurrentMetrics(); got != want {
+		t.Errorf("CurrentMetrics() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{10, 20, 30}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{1, 10}, {33, 10}, {34, 20}, {50, 20}, {90, 30}, {99, 30}, {100, 30},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %v, want %v", sorted, tt.p, got, tt.want)
+		}
+	}
+	if got := percentile([]time.Duration{7}, 99); got != 7 {
+		t.Errorf("percentile of one latency = %v, want it", got)
+	}
+}
+
+func TestMetricsString(t *testing.T) {
+	tests := []struct {
+		m    Metrics
+		want string
+	}{
+		{Metrics{}, "Searches: 0\nArticle fetches: 0\nAPI requests: 0\n"},
+		{
+			Metrics{Searches: 3, ArticleFetches: 4, CacheHits: 1, Requests: 9, MeanLatency: 123456 * time.Microsecond,
+				P50Latency: 100 * time.Millisecond, P90Latency: 250400 * time.Microsecond, P99Latency: 2 * time.Second},
+			"Searches: 3\nArticle fetches: 4 (1 from the cache, 25%)\nAPI requests: 9\nLatency: mean 123ms, p50 100ms, p90 250ms, p99 2s\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRequestsCounted(t *testing.T) {
+	resetMetrics(t)
+	useCache(t, time.Hour)
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.FormValue("list") == "search":
+			w.Write([]byte(`{"query":{"searchinfo":{"totalhits":1},"search":[{"ns":0,"title":"Berlin"}]}}`))
+		case r.FormValue("action") == "parse":
+			w.Write([]byte(`{"parse":{"title":"Berlin","revid":1,"text":{"*":"<p>Berlin is the capital of Germany.</p>"}}}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+
+	ctx := context.Background()
+	if msg := PerformSearch("berlin", name, SearchOptions{})(ctx).(SearchMsg); msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	before := CurrentMetrics().Requests
+	for range 2 {
+		if msg := FetchArticle("Berlin", name)(ctx).(ArticleMsg); msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+	}
+	if msg := RefreshArticle("Berlin", name)(ctx).(ArticleMsg); msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+
+	got := CurrentMetrics()
+	if got.Searches != 1 || got.ArticleFetches != 3 || got.CacheHits != 1 {
+		t.Errorf("%d searches, %d article fetches, %d from the cache; want 1, 3 and 1", got.Searches, got.ArticleFetches, got.CacheHits)
+	}
+	if before == 0 || got.Requests <= before {
+		t.Errorf("%d requests after the search and %d in all, want both counted", before, got.Requests)
+	}
+	if got.P99Latency <= 0 || got.P99Latency < got.P50Latency {
+		t.Errorf("latencies %+v", got)
+	}
+}
@@ -0,0 +1,135 @@
+package wiki
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Operator is a search keyword MediaWiki's search understands.
+type Operator struct {
+	// Name is the keyword including its colon, e.g. "intitle:".
+	Name    string
+	Example string
+	Help    string
+}
+
+// Operators lists the search operators shown in the search help. Add to it to document more.
+var Operators = []Operator{
+	{Name: "intitle:", Example: `intitle:"linux kernel"`, Help: "the words must appear in the title"},
+	{Name: "insource:", Example: `insource:/[0-9]{4}/`, Help: "search the page source, optionally with a /regular expression/"},
+	{Name: "incategory:", Example: `incategory:"Networking"`, Help: "only pages in the category"},
+	{Name: "hastemplate:", Example: `hastemplate:"Infobox person"`, Help: "only pages using the template"},
+	{Name: "linksto:", Example: `linksto:"Go (programming language)"`, Help: "only pages linking to the page"},
+	{Name: "prefix:", Example: `prefix:Linux`, Help: "only titles starting with the rest of the query; put it last"},
+	{Name: "morelike:", Example: `morelike:Vim`, Help: "pages with text similar to the page"},
+	{Name: "-", Example: `-python`, Help: "leave out pages with the word"},
+	{Name: `"…"`, Example: `"free software"`, Help: "the words must appear together as a phrase"},
+}
+
+// CheckQuery reports syntax problems in a search query, such as an unclosed quote or an
+// operator without a value. The wiki still accepts such queries, so these are only warnings.
+func CheckQuery(term string) []string {
+	var warnings []string
+	if strings.Count(term, `"`)%2 == 1 {
+		warnings = append(warnings, "unclosed quote")
+	}
+	for _, word := range strings.Fields(term) {
+		for _, op := range Operators {
+			if strings.HasSuffix(op.Name, ":") && strings.EqualFold(word, op.Name) {
+				warnings = append(warnings, fmt.Sprintf("%s needs a value right after the colon, e.g. %s", op.Name, op.Example))
+			}
+		}
+	}
+	// A regular expression may contain spaces, so look for its closing slash in the whole query.
+	lower := strings.ToLower(term)
+	if i := strings.Index(lower, "insource:/"); i >= 0 && !strings.Contains(term[i+len("insource:/"):], "/") {
+		warnings = append(warnings, "insource:/…/ is missing its closing slash")
+	}
+	return warnings
+}
+
+// Namespaces maps the lower-case names of common namespaces to their numbers.
+var Namespaces = map[string]int{
+	"main":      0,
+	"talk":      1,
+	"user":      2,
+	"user talk": 3,
+	"project":   4,
+	"file":      6,
+	"template":  10,
+	"help":      12,
+	"category":  14,
+}
+
+// ParseNamespace returns the number of a namespace given by name, such as "Help", or by
+// number. Empty means the main namespace, 0.
+func ParseNamespace(s string) (int, error) {
+	s = strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(s, "_", " ")), " "))
+	if s == "" {
+		return 0, nil
+	}
+	if ns, ok := Namespaces[s]; ok {
+		return ns, nil
+	}
+	if ns, err := strconv.Atoi(s); err == nil && ns >= 0 {
+		return ns, nil
+	}
+	return 0, fmt.Errorf("unknown namespace %q; use a name such as Talk or Help, or a number", s)
+}
+
+// NamespaceName returns the name of a namespace, or its number if it has no known name.
+func NamespaceName(ns int) string {
+	for name, n := range Namespaces {
+		if n == ns {
+			return strings.ToUpper(name[:1]) + name[1:]
+		}
+	}
+	return strconv.Itoa(ns)
+}
+
+// Refinement is a search given as separate fields instead of raw operators.
+type Refinement struct {
+	// Text is searched as typed, so it may still contain operators of its own.
+	Text string
+	// Title must appear in the titles of the results.
+	Title     string
+	Category  string
+	Namespace string
+}
+
+// Compose checks the fields and turns them into a search: the query, with the title as an
+// intitle: operator, the category to scope it to and the namespace to search in. The category
+// is returned separately because searches apply it as their scope.
+func (r Refinement) Compose() (term, category string, namespace int, err error) {
+	namespace, err = ParseNamespace(r.Namespace)
+	if err != nil {
+		return "", "", 0, err
+	}
+	title := cleanValue(r.Title)
+	if title == "" && strings.TrimSpace(r.Title) != "" {
+		return "", "", 0, errors.New("the title has nothing to search for once quotes are removed")
+	}
+	category = CleanCategory(r.Category)
+	term = strings.Join(strings.Fields(r.Text), " ")
+	if title != "" {
+		term = strings.TrimSpace(`intitle:"` + title + `" ` + term)
+	}
+	if term == "" && category == "" {
+		return "", "", 0, errors.New("enter some text, a title or a category to search for")
+	}
+	return term, category, namespace, nil
+}
+
+// cleanValue makes s usable as a quoted operator value by dropping double quotes and control
+// characters, which would otherwise end the value early, and trimming spaces.
+func cleanValue(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '"' || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
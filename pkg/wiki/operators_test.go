@@ -0,0 +1,63 @@
+package wiki
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRefinementCompose(t *testing.T) {
+	tests := []struct {
+		name      string
+		r         Refinement
+		term      string
+		category  string
+		namespace int
+		invalid   bool
+	}{
+		{"text only", Refinement{Text: "  wireless   network "}, "wireless network", "", 0, false},
+		{"title", Refinement{Text: "setup", Title: "Wi-Fi"}, `intitle:"Wi-Fi" setup`, "", 0, false},
+		{"title alone", Refinement{Title: "Wi-Fi"}, `intitle:"Wi-Fi"`, "", 0, false},
+		{"quotes and control characters dropped from the title", Refinement{Title: "\"Wi\nFi\""}, `intitle:"WiFi"`, "", 0, false},
+		{"category prefix dropped", Refinement{Text: "setup", Category: "Category:Networking"}, "setup", "Networking", 0, false},
+		{"category alone", Refinement{Category: "Networking"}, "", "Networking", 0, false},
+		{"namespace by name", Refinement{Text: "pacman", Namespace: "Help"}, "pacman", "", 12, false},
+		{"namespace by number", Refinement{Text: "pacman", Namespace: "1"}, "pacman", "", 1, false},
+		{"namespace with underscores", Refinement{Text: "pacman", Namespace: "user_talk"}, "pacman", "", 3, false},
+		{"unknown namespace", Refinement{Text: "pacman", Namespace: "Gadgets"}, "", "", 0, true},
+		{"negative namespace", Refinement{Text: "pacman", Namespace: "-1"}, "", "", 0, true},
+		{"title of only quotes", Refinement{Text: "pacman", Title: `""`}, "", "", 0, true},
+		{"nothing to search for", Refinement{Text: " ", Namespace: "Help"}, "", "", 0, true},
+	}
+	for _, tt := range tests {
+		term, category, namespace, err := tt.r.Compose()
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("%s: Compose() = %q, %q, %d; want an error", tt.name, term, category, namespace)
+			}
+			continue
+		}
+		if err != nil || term != tt.term || category != tt.category || namespace != tt.namespace {
+			t.Errorf("%s: Compose() = %q, %q, %d, %v; want %q, %q, %d", tt.name, term, category, namespace, err, tt.term, tt.category, tt.namespace)
+		}
+	}
+}
+
+func TestRefinedSearch(t *testing.T) {
+	var srsearch, srnamespace string
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		srsearch, srnamespace = r.FormValue("srsearch"), r.FormValue("srnamespace")
+		w.Write([]byte(`{"query":{"searchinfo":{"totalhits":0},"search":[]}}`))
+	})
+	term, category, namespace, err := Refinement{Text: "setup", Title: "Wi-Fi", Category: "Networking", Namespace: "Help"}.Compose()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := PerformSearch(term, name, SearchOptions{Category: category, Namespace: namespace})(context.Background()).(SearchMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	if want := `incategory:"Networking" intitle:"Wi-Fi" setup`; srsearch != want || srnamespace != "12" {
+		t.Errorf("srsearch %q, srnamespace %q; want %q and 12", srsearch, srnamespace, want)
+	}
+}
@@ -0,0 +1,112 @@
+package wiki
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxRedirects is how many redirects a request follows unless its source sets
+// MaxRedirects, about as many as Go's own HTTP client follows.
+const DefaultMaxRedirects = 10
+
+// RedirectError reports a redirect that was not followed because the source disallows it.
+type RedirectError struct {
+	From, To string
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("%s redirects to %s, and following redirects is disabled for this wiki", e.From, e.To)
+}
+
+// movedHosts maps an API host to the scheme and host its requests were last redirected to,
+// e.g. a wiki enforcing its canonical domain.
+var movedHosts = struct {
+	sync.Mutex
+	hosts map[string]url.URL
+}{hosts: map[string]url.URL{}}
+
+// checkRedirect applies the MaxRedirects of the source a request was first sent to.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	limit := DefaultMaxRedirects
+	if source, ok := sourceForHost(via[0].URL.Host); ok && source.MaxRedirects != 0 {
+		limit = source.MaxRedirects
+	}
+	if limit < 0 {
+		return http.ErrUseLastResponse
+	}
+	// via holds the requests made so far, the first of which wasn't a redirect.
+	if len(via) > limit {
+		return fmt.Errorf("stopped after %d redirects", limit)
+	}
+	return nil
+}
+
+// sourceForHost returns the source whose API is served from host.
+func sourceForHost(host string) (Source, bool) {
+	for _, source := range Sources {
+		if api, err := url.Parse(source.API); err == nil && api.Host == host {
+			return source, true
+		}
+	}
+	return Source{}, false
+}
+
+// SetMaxRedirects sets how many redirects requests to the named source follow; 0 disables
+// following them.
+func SetMaxRedirects(name string, n int) error {
+	if n < 0 {
+		return fmt.Errorf("%s: the number of redirects can't be negative", name)
+	}
+	for i := range Sources {
+		if Sources[i].Name == name {
+			Sources[i].MaxRedirects = n
+			if n == 0 {
+				Sources[i].MaxRedirects = -1
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown wiki %q", name)
+}
+
+// noteRedirect remembers where a request to an API ended up, if that is another host.
+func noteRedirect(requested, final *url.URL) {
+	movedHosts.Lock()
+	defer movedHosts.Unlock()
+	if final.Host == requested.Host {
+		delete(movedHosts.hosts, requested.Host)
+		return
+	}
+	movedHosts.hosts[requested.Host] = url.URL{Scheme: final.Scheme, Host: final.Host}
+}
+
+// siteURL moves an address on a wiki's website to the host the wiki's API was redirected to,
+// if the website is served from the API's original host.
+func siteURL(wikiType, address string) string {
+	api, err := url.Parse(apiURL(wikiType))
+	if err != nil {
+		return address
+	}
+	movedHosts.Lock()
+	moved, ok := movedHosts.hosts[api.Host]
+	movedHosts.Unlock()
+	prefix := api.Scheme + "://" + api.Host
+	if !ok || !strings.HasPrefix(address, prefix) {
+		return address
+	}
+	return moved.Scheme + "://" + moved.Host + strings.TrimPrefix(address, prefix)
+}
+
+// ArticleBase returns the address articles of a wiki are linked under, e.g.
+// "https://en.wikipedia.org/wiki/", following a redirect of the wiki's API to another host.
+func ArticleBase(wikiType string) string {
+	return siteURL(wikiType, LookupSource(wikiType).Articles)
+}
+
+// indexURL returns the wiki's index.php, following a redirect of its API to another host.
+func indexURL(wikiType string) string {
+	return siteURL(wikiType, LookupSource(wikiType).Index)
+}
@@ -0,0 +1,108 @@
+package wiki
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// archPages are two pages of search results as ArchWiki's API returns them: titles may carry
+// search match markup, and every page but the last has a continue offset.
+var archPages = map[string]string{
+	"": `{"batchcomplete":"","continue":{"sroffset":2,"continue":"-||"},"query":{"searchinfo":{"totalhits":3},` +
+		`"search":[{"ns":0,"title":"<span class=\"searchmatch\">Pacman</span>","pageid":3393,"size":60000,"wordcount":7000,"snippet":"the <span class=\"searchmatch\">pacman</span> package manager","timestamp":"2024-05-01T10:00:00Z"},` +
+		`{"ns":0,"title":"Pacman/Tips and tricks","pageid":4422,"size":30000,"wordcount":3500,"snippet":"","timestamp":"2024-04-01T10:00:00Z"}]}}`,
+	"2": `{"batchcomplete":"","query":{"searchinfo":{"totalhits":3},` +
+		`"search":[{"ns":0,"title":"Pacman/Package signing","pageid":5511,"size":20000,"wordcount":2000,"snippet":"","timestamp":"2024-03-01T10:00:00Z"}]}}`,
+}
+
+func TestPerformSearchPages(t *testing.T) {
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("srlimit") != "2" || r.FormValue("srinfo") != "totalhits|suggestion" {
+			t.Errorf("search parameters %v, want srlimit 2 and the total hits", r.Form)
+		}
+		w.Write([]byte(archPages[r.FormValue("sroffset")]))
+	})
+
+	first := PerformSearch("pacman", name, SearchOptions{Limit: 2})(context.Background()).(SearchMsg)
+	if first.Err != nil {
+		t.Fatal(first.Err)
+	}
+	if got, want := titles(first.Results), []string{"Pacman", "Pacman/Tips and tricks"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first page %v, want %v", got, want)
+	}
+	if first.TotalHits != 3 || first.Offset != 0 || first.NextOffset != 2 {
+		t.Errorf("first page: %d hits, offset %d, next %d; want 3, 0, 2", first.TotalHits, first.Offset, first.NextOffset)
+	}
+
+	last := PerformSearch("pacman", name, SearchOptions{Limit: 2, Offset: first.NextOffset})(context.Background()).(SearchMsg)
+	if last.Err != nil {
+		t.Fatal(last.Err)
+	}
+	if got, want := titles(last.Results), []string{"Pacman/Package signing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("last page %v, want %v", got, want)
+	}
+	if last.TotalHits != 3 || last.Offset != 2 || last.NextOffset != 0 {
+		t.Errorf("last page: %d hits, offset %d, next %d; want 3, 2, 0", last.TotalHits, last.Offset, last.NextOffset)
+	}
+}
+
+// archResponse is a search response as ArchWiki's MediaWiki returns it, with the fields the
+// search doesn't use, match markup and escaped entities in titles, and a hit in another
+// namespace.
+const archResponse = `{"batchcomplete":"","continue":{"sroffset":3,"continue":"-||"},` +
+	`"query":{"searchinfo":{"totalhits":412,"suggestion":"systemd timers","suggestionsnippet":"systemd <em>timers</em>"},"search":[` +
+	`{"ns":0,"title":"<span class=\"searchmatch\">Systemd</span>/Timers","pageid":14587,"size":25343,"wordcount":2843,` +
+	`"snippet":"<span class=\"searchmatch\">Timers</span> are <span class=\"searchmatch\">systemd</span> unit files","timestamp":"2024-06-02T08:11:41Z",` +
+	`"titlesnippet":"<span class=\"searchmatch\">Systemd</span>/<span class=\"searchmatch\">Timers</span>","sectiontitle":"Transient timer units","sectionsnippet":""},` +
+	`{"ns":0,"title":"Cron  &amp;  systemd\u00a0timers","pageid":1944,"size":19102,"wordcount":2210,"snippet":"","timestamp":"2024-05-11T17:03:09Z",` +
+	`"redirecttitle":"Crontab","redirectsnippet":"Crontab"},` +
+	`{"ns":12,"title":"Help:Reading","pageid":2907,"size":16911,"wordcount":2612,"snippet":"","timestamp":"2023-12-29T10:21:17Z","categorysnippet":""}]}}`
+
+func TestPerformSearchArchWiki(t *testing.T) {
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(archResponse))
+	})
+	msg := PerformSearch("systemd timers", name, SearchOptions{Limit: 3})(context.Background()).(SearchMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	want := []SearchResult{
+		{Title: "Systemd/Timers", Size: 25343},
+		{Title: "Cron & systemd timers", Size: 19102},
+		{Title: "Help:Reading", Size: 16911},
+	}
+	if !reflect.DeepEqual(msg.Results, want) {
+		t.Errorf("results %+v, want %+v", msg.Results, want)
+	}
+	if msg.TotalHits != 412 || msg.NextOffset != 3 || msg.Suggestion != "systemd timers" {
+		t.Errorf("%d hits, next offset %d, suggestion %q; want 412, 3 and %q", msg.TotalHits, msg.NextOffset, msg.Suggestion, "systemd timers")
+	}
+	if got, want := ArticleURL(msg.Results[0].Title, name), LookupSource(name).Articles+"Systemd/Timers"; got != want {
+		t.Errorf("article URL %q, want %q", got, want)
+	}
+}
+
+func TestPrefixSearch(t *testing.T) {
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("list") != "prefixsearch" || r.FormValue("pslimit") != "10" {
+			t.Errorf("prefix search parameters %v, want a prefixsearch of 10 titles", r.Form)
+		}
+		if r.FormValue("pssearch") != "pac" {
+			w.Write([]byte(`{"error":{"code":"badvalue","info":"Unrecognized value for parameter \"pssearch\"."}}`))
+			return
+		}
+		w.Write([]byte(`{"batchcomplete":"","query":{"prefixsearch":[{"ns":0,"title":"Pacman","pageid":3393},{"ns":0,"title":"Pacman/Tips and tricks","pageid":4422}]}}`))
+	})
+	msg := PrefixSearch("pac", name)().(PrefixSearchMsg)
+	if msg.Err != nil {
+		t.Fatal(msg.Err)
+	}
+	if want := []string{"Pacman", "Pacman/Tips and tricks"}; msg.Term != "pac" || !reflect.DeepEqual(msg.Titles, want) {
+		t.Errorf("PrefixSearch() = %q %v, want %q %v", msg.Term, msg.Titles, "pac", want)
+	}
+	if msg := PrefixSearch("x", name)().(PrefixSearchMsg); msg.Err == nil || msg.Titles != nil {
+		t.Errorf("PrefixSearch() on an API error = %v, %v; want the error and no titles", msg.Titles, msg.Err)
+	}
+}
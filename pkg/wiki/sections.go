@@ -0,0 +1,113 @@
+package wiki
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var tagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// Title returns the heading text with any markup removed.
+func (s Section) Title() string {
+	return stripMarkup(s.Line)
+}
+
+// stripMarkup turns a snippet of HTML, such as a heading or display title, into plain text.
+func stripMarkup(snippet string) string {
+	return strings.TrimSpace(html.UnescapeString(tagRegex.ReplaceAllString(snippet, "")))
+}
+
+// Depth returns the heading level as a number, or 0 when it is unknown.
+func (s Section) Depth() int {
+	level, err := strconv.Atoi(s.Level)
+	if err != nil {
+		return 0
+	}
+	return level
+}
+
+// SectionOffsets locates each section heading in the readable article text.
+// Headings are searched for in order, one per line; the offset of a heading that
+// cannot be found (e.g. because readability dropped it) is -1.
+func SectionOffsets(content string, sections []Section) []int {
+	offsets := make([]int, len(sections))
+	start := 0
+	for i, section := range sections {
+		offsets[i] = -1
+		title := section.Title()
+		if title == "" {
+			continue
+		}
+		lineStart := start
+		for lineStart < len(content) {
+			lineEnd := strings.IndexByte(content[lineStart:], '\n')
+			if lineEnd == -1 {
+				lineEnd = len(content)
+			} else {
+				lineEnd += lineStart
+			}
+			line := strings.TrimSpace(content[lineStart:lineEnd])
+			line = strings.TrimSpace(strings.TrimSuffix(line, "[edit]"))
+			if line == title {
+				offsets[i] = lineStart
+				start = lineEnd
+				break
+			}
+			lineStart = lineEnd + 1
+		}
+	}
+	return offsets
+}
+
+// FindFragment returns the index of the section a URL fragment such as "Early_life" refers
+// to, matching its anchor or its title, or -1 if no section matches.
+func FindFragment(sections []Section, fragment string) int {
+	fragment = strings.ReplaceAll(strings.TrimPrefix(fragment, "#"), "_", " ")
+	if fragment == "" {
+		return -1
+	}
+	for i, section := range sections {
+		if strings.ReplaceAll(section.Anchor, "_", " ") == fragment || section.Title() == fragment {
+			return i
+		}
+	}
+	return -1
+}
+
+// NumberSections returns a copy of sections with Number set to the hierarchical section
+// number computed from the heading levels, e.g. "1", "1.1", "2". A heading that skips levels
+// is numbered one level below its parent, and sections without a known level get no number.
+func NumberSections(sections []Section) []Section {
+	type counter struct {
+		depth, count int
+	}
+	numbered := make([]Section, len(sections))
+	var stack []counter
+	for i, section := range sections {
+		numbered[i] = section
+		numbered[i].Number = ""
+		depth := section.Depth()
+		if depth == 0 {
+			continue
+		}
+		// A deeper heading popped here held this position, so its sibling continues its count.
+		popped := 0
+		for len(stack) > 0 && stack[len(stack)-1].depth > depth {
+			popped = stack[len(stack)-1].count
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 && stack[len(stack)-1].depth == depth {
+			stack[len(stack)-1].count++
+		} else {
+			stack = append(stack, counter{depth, popped + 1})
+		}
+		parts := make([]string, len(stack))
+		for j, c := range stack {
+			parts[j] = strconv.Itoa(c.count)
+		}
+		numbered[i].Number = strings.Join(parts, ".")
+	}
+	return numbered
+}
@@ -0,0 +1,60 @@
+package wiki
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSectionOffsets(t *testing.T) {
+	content := "Intro.\nHistory [edit]\nText.\n  Design  \nMore.\nHistory\nAgain."
+	sections := []Section{
+		{Line: "History", Level: "2"},
+		// Readability dropped this heading, so it is not found; the search for the next one
+		// still starts after the previous heading.
+		{Line: "Reception", Level: "2"},
+		{Line: "<i>Design</i>", Level: "3"},
+		{Line: "", Level: "2"},
+		// The second "History" line is found, not the first again.
+		{Line: "History", Level: "2"},
+	}
+	want := []int{7, -1, 28, -1, 45}
+	if got := SectionOffsets(content, sections); !reflect.DeepEqual(got, want) {
+		t.Errorf("SectionOffsets() = %v, want %v", got, want)
+	}
+}
+
+func TestNumberSections(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []string
+		want   []string
+	}{
+		{"flat", []string{"2", "2", "2"}, []string{"1", "2", "3"}},
+		{"nested", []string{"2", "3", "3", "4", "2", "3"}, []string{"1", "1.1", "1.2", "1.2.1", "2", "2.1"}},
+		// A heading that skips a level is numbered one below its parent.
+		{"skipped level", []string{"2", "4", "3"}, []string{"1", "1.1", "1.2"}},
+		// An article starting below the top level: the first top-level heading continues
+		// the count of those before it.
+		{"deeper first", []string{"3", "3", "2"}, []string{"1", "2", "3"}},
+		{"unknown level", []string{"2", "", "x", "2"}, []string{"1", "", "", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := make([]Section, len(tt.levels))
+			for i, level := range tt.levels {
+				sections[i] = Section{Level: level, Number: "9"}
+			}
+			numbered := NumberSections(sections)
+			var got []string
+			for _, section := range numbered {
+				got = append(got, section.Number)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NumberSections(%v) numbers = %v, want %v", tt.levels, got, tt.want)
+			}
+			if sections[0].Number != "9" {
+				t.Error("NumberSections changed the sections it was given")
+			}
+		})
+	}
+}
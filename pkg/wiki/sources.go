@@ -0,0 +1,175 @@
+package wiki
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// Source describes a MediaWiki site that can be searched.
+type Source struct {
+	// Name identifies the source, e.g. in snapshots and status messages.
+	Name string
+	// Label is shown in the wiki selection menu.
+	Label string
+	// Site is the name of the wiki without its language, e.g. "Wikipedia", which labels its
+	// editions in other languages. Empty means the Label says no language.
+	Site string
+	// Group is the menu heading the source is listed under.
+	Group string
+	// API is the endpoint of the MediaWiki action API.
+	API string
+	// Articles is prefixed to an article title, with spaces as underscores, to link to it.
+	Articles string
+	// Index is the site's index.php, used for searches and links to revisions.
+	Index string
+	// Language is the code of the wiki's content language, e.g. "en", which text the API
+	// generates, such as error messages, is localized to unless UseLang overrides it.
+	Language string
+	// Headers are sent with every request to the source's host, e.g. an Authorization header
+	// for a private wiki. A User-Agent set here replaces the default one.
+	Headers map[string]string
+	// MaxRedirects is how many redirects requests to the API follow: 0 means
+	// DefaultMaxRedirects and a negative number disables following them.
+	MaxRedirects int
+	// Fallback names the source searched when a search of this one finds nothing, and
+	// FallbackFor the source this one is the fallback of; see SetFallbackLanguage.
+	Fallback    string
+	FallbackFor string
+}
+
+// Sources lists the wikis that can be searched, in menu order.
+var Sources = []Source{
+	{
+		Name:     "wikipedia",
+		Label:    "Wikipedia (English)",
+		Site:     "Wikipedia",
+		Group:    "Wikipedia",
+		API:      "https://en.wikipedia.org/w/api.php",
+		Articles: "https://en.wikipedia.org/wiki/",
+		Index:    "https://en.wikipedia.org/w/index.php",
+		Language: "en",
+	},
+	{
+		Name:     "simple",
+		Label:    "Simple English Wikipedia",
+		Group:    "Wikipedia",
+		API:      "https://simple.wikipedia.org/w/api.php",
+		Articles: "https://simple.wikipedia.org/wiki/",
+		Index:    "https://simple.wikipedia.org/w/index.php",
+		Language: "en",
+	},
+	{
+		Name:     "wikiquote",
+		Label:    "Wikiquote",
+		Group:    "Sister projects",
+		API:      "https://en.wikiquote.org/w/api.php",
+		Articles: "https://en.wikiquote.org/wiki/",
+		Index:    "https://en.wikiquote.org/w/index.php",
+		Language: "en",
+	},
+	{
+		Name:     "wikisource",
+		Label:    "Wikisource",
+		Group:    "Sister projects",
+		API:      "https://en.wikisource.org/w/api.php",
+		Articles: "https://en.wikisource.org/wiki/",
+		Index:    "https://en.wikisource.org/w/index.php",
+		Language: "en",
+	},
+	{
+		Name:     "wikivoyage",
+		Label:    "Wikivoyage",
+		Group:    "Sister projects",
+		API:      "https://en.wikivoyage.org/w/api.php",
+		Articles: "https://en.wikivoyage.org/wiki/",
+		Index:    "https://en.wikivoyage.org/w/index.php",
+		Language: "en",
+	},
+	{
+		Name:     "wiktionary",
+		Label:    "Wiktionary",
+		Group:    "Sister projects",
+		API:      "https://en.wiktionary.org/w/api.php",
+		Articles: "https://en.wiktionary.org/wiki/",
+		Index:    "https://en.wiktionary.org/w/index.php",
+		Language: "en",
+	},
+	{
+		Name:     "arch",
+		Label:    "ArchWiki",
+		Group:    "Other wikis",
+		API:      "https://wiki.archlinux.org/api.php",
+		Articles: "https://wiki.archlinux.org/index.php/",
+		Index:    "https://wiki.archlinux.org/index.php",
+		Language: "en",
+	},
+}
+
+// SourceNames returns the names of the sources listed in the menu, in menu order. The
+// fallback editions added by SetFallbackLanguage are left out.
+func SourceNames() []string {
+	var names []string
+	for _, source := range Sources {
+		if source.FallbackFor == "" {
+			names = append(names, source.Name)
+		}
+	}
+	return names
+}
+
+// LookupSource returns the source called name. Unknown names get the first source, English
+// Wikipedia, which is what wikis were searched on before sources could be chosen.
+func LookupSource(name string) Source {
+	for _, source := range Sources {
+		if source.Name == name {
+			return source
+		}
+	}
+	return Sources[0]
+}
+
+// ValidateHeaders reports the first header with an invalid name or value.
+func ValidateHeaders(headers map[string]string) error {
+	for key, value := range headers {
+		if !httpguts.ValidHeaderFieldName(key) {
+			return fmt.Errorf("invalid header name %q", key)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value of header %q", key)
+		}
+	}
+	return nil
+}
+
+// SetHeaders sets the extra HTTP headers sent to the named source.
+func SetHeaders(name string, headers map[string]string) error {
+	if err := ValidateHeaders(headers); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	for i := range Sources {
+		if Sources[i].Name == name {
+			Sources[i].Headers = headers
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown wiki %q", name)
+}
+
+// setHeaders sets the User-Agent of a request and the extra headers of the source it goes to.
+func setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", userAgent)
+	for _, source := range Sources {
+		if len(source.Headers) == 0 {
+			continue
+		}
+		if api, err := url.Parse(source.API); err != nil || api.Host != req.URL.Host {
+			continue
+		}
+		for key, value := range source.Headers {
+			req.Header.Set(key, value)
+		}
+	}
+}
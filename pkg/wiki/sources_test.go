@@ -0,0 +1,179 @@
+package wiki
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSourceURLs(t *testing.T) {
+	tests := []struct {
+		name      string
+		article   string
+		search    string
+		permalink string
+		api       string
+	}{
+		{
+			"wikipedia",
+			"https://en.wikipedia.org/wiki/New_York",
+			"https://en.wikipedia.org/w/index.php?search=New+York",
+			"https://en.wikipedia.org/w/index.php?oldid=42&title=New_York",
+			"https://en.wikipedia.org/w/api.php",
+		},
+		{
+			"simple",
+			"https://simple.wikipedia.org/wiki/New_York",
+			"https://simple.wikipedia.org/w/index.php?search=New+York",
+			"https://simple.wikipedia.org/w/index.php?oldid=42&title=New_York",
+			"https://simple.wikipedia.org/w/api.php",
+		},
+		{
+			"wikiquote",
+			"https://en.wikiquote.org/wiki/New_York",
+			"https://en.wikiquote.org/w/index.php?search=New+York",
+			"https://en.wikiquote.org/w/index.php?oldid=42&title=New_York",
+			"https://en.wikiquote.org/w/api.php",
+		},
+		{
+			"wikisource",
+			"https://en.wikisource.org/wiki/New_York",
+			"https://en.wikisource.org/w/index.php?search=New+York",
+			"https://en.wikisource.org/w/index.php?oldid=42&title=New_York",
+			"https://en.wikisource.org/w/api.php",
+		},
+		{
+			"wikivoyage",
+			"https://en.wikivoyage.org/wiki/New_York",
+			"https://en.wikivoyage.org/w/index.php?search=New+York",
+			"https://en.wikivoyage.org/w/index.php?oldid=42&title=New_York",
+			"https://en.wikivoyage.org/w/api.php",
+		},
+		{
+			"wiktionary",
+			"https://en.wiktionary.org/wiki/New_York",
+			"https://en.wiktionary.org/w/index.php?search=New+York",
+			"https://en.wiktionary.org/w/index.php?oldid=42&title=New_York",
+			"https://en.wiktionary.org/w/api.php",
+		},
+		{
+			// ArchWiki serves its API and articles without the /w/ and /wiki/ paths.
+			"arch",
+			"https://wiki.archlinux.org/index.php/New_York",
+			"https://wiki.archlinux.org/index.php?search=New+York",
+			"https://wiki.archlinux.org/index.php?oldid=42&title=New_York",
+			"https://wiki.archlinux.org/api.php",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ArticleURL("New York", tt.name); got != tt.article {
+				t.Errorf("ArticleURL() = %q, want %q", got, tt.article)
+			}
+			if got := SearchURL("New York", tt.name); got != tt.search {
+				t.Errorf("SearchURL() = %q, want %q", got, tt.search)
+			}
+			if got := PermalinkURL("New York", tt.name, 42); got != tt.permalink {
+				t.Errorf("PermalinkURL() = %q, want %q", got, tt.permalink)
+			}
+			request, err := url.Parse(apiRequest(tt.name, url.Values{"action": {"query"}}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := request.Scheme + "://" + request.Host + request.Path; got != tt.api {
+				t.Errorf("API request to %q, want %q", got, tt.api)
+			}
+			if got := request.Query().Get("action"); got != "query" {
+				t.Errorf("API request parameters %v lost the action", request.Query())
+			}
+		})
+	}
+}
+
+func TestSourceNamesInMenuOrder(t *testing.T) {
+	want := []string{"wikipedia", "simple", "wikiquote", "wikisource", "wikivoyage", "wiktionary", "arch"}
+	got := SourceNames()
+	if len(got) != len(want) {
+		t.Fatalf("SourceNames() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SourceNames()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	// Sources of a group are listed together, so the menu shows each heading once.
+	seen := map[string]bool{}
+	group := ""
+	for _, name := range got {
+		source := LookupSource(name)
+		if source.Group != group {
+			if seen[source.Group] {
+				t.Errorf("group %q is split up in the menu", source.Group)
+			}
+			group = source.Group
+			seen[group] = true
+		}
+	}
+	if LookupSource("unknown").Name != "wikipedia" {
+		t.Error("an unknown source is not looked up as English Wikipedia")
+	}
+}
+
+func TestSourceHeaders(t *testing.T) {
+	headers := func(got *http.Header) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*got = r.Header.Clone()
+			w.Write([]byte(`{"batchcomplete":"","query":{"searchinfo":{"totalhits":0},"search":[]}}`))
+		}
+	}
+	var private, public http.Header
+	name := testWiki(t, headers(&private))
+	testWiki(t, headers(&public))
+	Sources[len(Sources)-1].Name = "public"
+
+	if err := SetHeaders(name, map[string]string{"Authorization": "Bearer secret", "User-Agent": "intranet-client/1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, wikiType := range []string{name, "public"} {
+		if msg := PerformSearch("go", wikiType, SearchOptions{})(context.Background()).(SearchMsg); msg.Err != nil {
+			t.Fatalf("%s: %v", wikiType, msg.Err)
+		}
+	}
+	if got := private.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization sent to the wiki it is set for = %q, want %q", got, "Bearer secret")
+	}
+	if got := private.Get("User-Agent"); got != "intranet-client/1.0" {
+		t.Errorf("User-Agent sent to the wiki it is set for = %q, want the configured one", got)
+	}
+	if got := public.Get("Authorization"); got != "" {
+		t.Errorf("Authorization %q sent to another wiki", got)
+	}
+	if got := public.Get("User-Agent"); got != userAgent {
+		t.Errorf("User-Agent sent to another wiki = %q, want the default %q", got, userAgent)
+	}
+}
+
+func TestSetHeadersValidates(t *testing.T) {
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name    string
+		wiki    string
+		headers map[string]string
+		valid   bool
+	}{
+		{"valid", name, map[string]string{"X-Api-Key": "abc123"}, true},
+		{"space in name", name, map[string]string{"X Api Key": "abc123"}, false},
+		{"colon in name", name, map[string]string{"Authorization:": "Bearer secret"}, false},
+		{"newline in value", name, map[string]string{"X-Api-Key": "abc\r\nX-Injected: 1"}, false},
+		{"unknown wiki", "nowhere", map[string]string{"X-Api-Key": "abc123"}, false},
+	}
+	for _, tt := range tests {
+		if err := SetHeaders(tt.wiki, tt.headers); (err == nil) != tt.valid {
+			t.Errorf("%s: SetHeaders() error = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+	if got := LookupSource(name).Headers["X-Api-Key"]; got != "abc123" {
+		t.Errorf("header after invalid ones were refused = %q, want the valid one kept", got)
+	}
+}
@@ -0,0 +1,70 @@
+package wiki
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// summaryChars bounds the length of the introduction fetched for a summary; the first
+// sentence is all that is shown of it.
+const summaryChars = 600
+
+// SummaryMsg carries the start of the introduction of an article, for a quick look at what
+// a search result is about.
+type SummaryMsg struct {
+	Title    string
+	WikiType string
+	Text     string
+	Err      error
+}
+
+// summaryCache keeps fetched summaries for the session.
+var summaryCache = struct {
+	sync.Mutex
+	entries map[cacheKey]string
+}{entries: map[cacheKey]string{}}
+
+// FetchSummary fetches the start of an article's introduction as plain text, serving it from
+// the session cache when possible. It is fetched in the background, giving way to requests
+// the user is waiting for.
+func FetchSummary(title string, wikiType string) tea.Cmd {
+	return func() tea.Msg {
+		key := cacheKey{wikiType, title}
+		summaryCache.Lock()
+		text, ok := summaryCache.entries[key]
+		summaryCache.Unlock()
+		if ok {
+			return SummaryMsg{Title: title, WikiType: wikiType, Text: text}
+		}
+		params := url.Values{}
+		params.Set("action", "query")
+		params.Set("format", "json")
+		params.Set("formatversion", "2")
+		params.Set("prop", "extracts")
+		params.Set("exintro", "1")
+		params.Set("explaintext", "1")
+		params.Set("exchars", strconv.Itoa(summaryChars))
+		params.Set("redirects", "1")
+		params.Set("titles", title)
+		var data extractResponse
+		if err := getBackgroundJSON(context.Background(), "summary fetch", SearchTimeout, apiRequest(wikiType, params), &data); err != nil {
+			return SummaryMsg{Title: title, WikiType: wikiType, Err: err}
+		}
+		if data.Error != nil {
+			return SummaryMsg{Title: title, WikiType: wikiType, Err: data.Error}
+		}
+		for _, page := range data.Query.Pages {
+			text += page.Extract
+		}
+		text = strings.TrimSpace(text)
+		summaryCache.Lock()
+		summaryCache.entries[key] = text
+		summaryCache.Unlock()
+		return SummaryMsg{Title: title, WikiType: wikiType, Text: text}
+	}
+}
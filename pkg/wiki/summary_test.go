@@ -0,0 +1,36 @@
+package wiki
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchSummaryCached(t *testing.T) {
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	var requests atomic.Int32
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.FormValue("prop") != "extracts" || r.FormValue("exintro") != "1" || r.FormValue("explaintext") != "1" {
+			t.Errorf("unexpected request %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"query":{"pages":[{"title":"Go (game)","extract":" Go is an abstract strategy board game for two players. "}]}}`))
+	})
+	t.Cleanup(func() {
+		summaryCache.Lock()
+		delete(summaryCache.entries, cacheKey{name, "Go (game)"})
+		summaryCache.Unlock()
+	})
+
+	want := SummaryMsg{Title: "Go (game)", WikiType: name, Text: "Go is an abstract strategy board game for two players."}
+	for range 2 {
+		if got := FetchSummary("Go (game)", name)().(SummaryMsg); got != want {
+			t.Errorf("FetchSummary() = %+v, want %+v", got, want)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests, want the second summary from the cache", n)
+	}
+}
@@ -0,0 +1,47 @@
+package wiki
+
+import "strings"
+
+// TalkTitle returns the title of the Talk page of a page, e.g. "Talk:Go" for "Go" and
+// "Help talk:Editing" for "Help:Editing". For a Talk page it returns the title of the page
+// discussed instead, so it toggles between the two. Special pages have no Talk page, so ok is
+// false for them.
+func TalkTitle(title string) (talk string, ok bool) {
+	prefix, rest, found := strings.Cut(title, ":")
+	if !found {
+		return "Talk:" + title, true
+	}
+	name := strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(prefix, "_", " ")), " "))
+	switch name {
+	case "special", "media":
+		return "", false
+	case "talk":
+		return rest, true
+	}
+	if subject, isTalk := strings.CutSuffix(name, " talk"); isTalk {
+		if isNamespace(subject) {
+			return strings.TrimSpace(prefix[:len(prefix)-len(" talk")]) + ":" + rest, true
+		}
+	}
+	if isNamespace(name) {
+		return strings.TrimSpace(prefix) + " talk:" + rest, true
+	}
+	// An unknown prefix is part of an article title, as in "Star Wars: A New Hope".
+	return "Talk:" + title, true
+}
+
+// isNamespace reports whether a title prefix in lower case names a namespace other than the
+// main one. Articles have no prefix, so "Main: ..." is just an article title.
+func isNamespace(name string) bool {
+	ns, known := Namespaces[name]
+	return known && ns != 0
+}
+
+// TalkURL returns the address of the Talk page of a page, for reading it in the browser.
+func TalkURL(title string, wikiType string) (string, bool) {
+	talk, ok := TalkTitle(title)
+	if !ok {
+		return "", false
+	}
+	return ArticleURL(talk, wikiType), true
+}
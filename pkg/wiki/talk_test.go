@@ -0,0 +1,25 @@
+package wiki
+
+import "testing"
+
+func TestTalkTitle(t *testing.T) {
+	tests := []struct {
+		title, talk string
+		ok          bool
+	}{
+		{"Go", "Talk:Go", true},
+		{"Talk:Go", "Go", true},
+		{"Help:Editing", "Help talk:Editing", true},
+		{"Help talk:Editing", "Help:Editing", true},
+		{"user_talk:Example", "user:Example", true},
+		{"Star Wars: A New Hope", "Talk:Star Wars: A New Hope", true},
+		{"Main: The Story", "Talk:Main: The Story", true},
+		{"Main talk:Page", "Talk:Main talk:Page", true},
+		{"Special:Random", "", false},
+	}
+	for _, tt := range tests {
+		if talk, ok := TalkTitle(tt.title); talk != tt.talk || ok != tt.ok {
+			t.Errorf("TalkTitle(%q) = %q, %v; want %q, %v", tt.title, talk, ok, tt.talk, tt.ok)
+		}
+	}
+}
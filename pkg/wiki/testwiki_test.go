@@ -0,0 +1,42 @@
+package wiki
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// testWiki adds a wiki named "test" whose API is served by handler, for the duration of the
+// test, and returns its name.
+func testWiki(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	sources := slices.Clone(Sources)
+	Sources = append(Sources, Source{
+		Name:     "test",
+		Label:    "Test wiki",
+		API:      server.URL + "/w/api.php",
+		Articles: server.URL + "/wiki/",
+		Index:    server.URL + "/w/index.php",
+		Language: "en",
+	})
+	t.Cleanup(func() {
+		Sources = sources
+		server.Close()
+	})
+	return "test"
+}
+
+// writeChunks writes body in pieces of n bytes, flushing each, so the response is sent with
+// chunked transfer encoding and arrives bit by bit.
+func writeChunks(w http.ResponseWriter, body string, n int) {
+	w.Header().Set("Content-Type", "application/json")
+	flusher := w.(http.Flusher)
+	for len(body) > 0 {
+		chunk := body[:min(n, len(body))]
+		body = body[len(chunk):]
+		w.Write([]byte(chunk))
+		flusher.Flush()
+	}
+}
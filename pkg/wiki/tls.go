@@ -0,0 +1,66 @@
+package wiki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// TLSError reports that a wiki's certificate couldn't be verified, which usually means a
+// proxy on the network intercepts HTTPS.
+type TLSError struct {
+	Host string
+	Err  error
+}
+
+func (e *TLSError) Error() string {
+	return fmt.Sprintf("TLS certificate verification failed for %s — check your network/proxy (%v)", e.Host, e.Err)
+}
+
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// TLSProtocolError reports that what answered at a wiki's address didn't speak TLS, such as a
+// server or proxy answering in plain text. Skipping certificate verification doesn't help.
+type TLSProtocolError struct {
+	Host string
+	Err  error
+}
+
+func (e *TLSProtocolError) Error() string {
+	return fmt.Sprintf("%s did not answer with TLS — check the wiki's address and your network/proxy (%v)", e.Host, e.Err)
+}
+
+func (e *TLSProtocolError) Unwrap() error {
+	return e.Err
+}
+
+// tlsError wraps err in a *TLSError if it is a failure to verify the certificate of host, or
+// in a *TLSProtocolError if host didn't answer with TLS, and returns it unchanged otherwise.
+func tlsError(host string, err error) error {
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var invalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	switch {
+	case errors.As(err, &verifyErr) || errors.As(err, &authorityErr) || errors.As(err, &invalidErr) ||
+		errors.As(err, &hostnameErr):
+		return &TLSError{Host: host, Err: err}
+	case errors.As(err, &recordErr):
+		return &TLSProtocolError{Host: host, Err: err}
+	}
+	return err
+}
+
+// SkipTLSVerify turns off verification of the wikis' certificates, for networks whose proxy
+// intercepts HTTPS. Anyone on the network can then read and change the traffic, so it is
+// only for when there is no other way.
+func SkipTLSVerify() {
+	if transport, ok := Client.Transport.(*http.Transport); ok {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+}
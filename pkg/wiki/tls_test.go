@@ -0,0 +1,34 @@
+package wiki
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTLSError(t *testing.T) {
+	request := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://en.wikipedia.org/w/api.php", Err: err}
+	}
+	var tlsErr *TLSError
+	if err := tlsError("en.wikipedia.org", request(x509.UnknownAuthorityError{})); !errors.As(err, &tlsErr) {
+		t.Errorf("unknown authority: %v, want a *TLSError", err)
+	}
+
+	err := tlsError("en.wikipedia.org", request(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}))
+	var protocolErr *TLSProtocolError
+	if !errors.As(err, &protocolErr) || errors.As(err, &tlsErr) {
+		t.Errorf("record header error: %v, want a *TLSProtocolError only", err)
+	}
+	if strings.Contains(err.Error(), "certificate") {
+		t.Errorf("record header error blames the certificate: %v", err)
+	}
+
+	plain := errors.New("connection refused")
+	if err := tlsError("en.wikipedia.org", plain); err != plain {
+		t.Errorf("other error: %v, want it unchanged", err)
+	}
+}
@@ -2,136 +2,618 @@ package wiki
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/go-shiori/go-readability"
+
+	"wiki-search/pkg/utils"
 )
 
 // SearchResult matches the JSON response from the MediaWiki search API.
 type SearchResult struct {
 	Title string `json:"title"`
+	// Description and Extract are only filled in by searches with SearchOptions.Snippets.
+	Description string `json:"description,omitempty"`
+	Extract     string `json:"extract,omitempty"`
+	// Size is the length of the page's wikitext in bytes, or 0 if the search didn't say.
+	Size int `json:"size,omitempty"`
+	// Related marks a title suggested by PrefixSearch rather than found by the search itself.
+	Related bool `json:"-"`
+}
+
+// Section is an article heading as reported by the MediaWiki parse API.
+type Section struct {
+	Index  string `json:"index"`
+	Level  string `json:"level"`
+	Line   string `json:"line"`
+	Number string `json:"number"`
+	Anchor string `json:"anchor"`
+}
+
+// APIError is the error object MediaWiki returns instead of a result.
+type APIError struct {
+	Code string `json:"code"`
+	Info string `json:"info"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wiki API error (%s): %s", e.Code, e.Info)
 }
 
 // ArticleResponse matches the JSON response from the MediaWiki parse API.
+// Parse and Text are pointers so a response lacking them can be told apart from an empty page.
 type ArticleResponse struct {
-	Parse struct {
-		Text struct {
+	Parse *struct {
+		Text *struct {
 			Content string `json:"*"`
 		} `json:"text"`
+		// Title is the page's title, after following redirects.
+		Title    string    `json:"title"`
+		Sections []Section `json:"sections"`
+		// DisplayTitle is the title as shown on the page, as HTML, e.g. "<i>Nineteen Eighty-Four</i>".
+		DisplayTitle string     `json:"displaytitle"`
+		RevID        int        `json:"revid"`
+		Redirects    []Redirect `json:"redirects"`
+		Categories   []struct {
+			Name string `json:"*"`
+			// Hidden is present, as an empty string, for maintenance categories.
+			Hidden *string `json:"hidden"`
+		} `json:"categories"`
 	} `json:"parse"`
+	Error *APIError `json:"error"`
+}
+
+// Redirect is a redirect that was followed to reach a page.
+type Redirect struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// ToFragment is the section the redirect points at, if any.
+	ToFragment string `json:"tofragment"`
+}
+
+// SearchInfo carries the metadata requested with srinfo.
+type SearchInfo struct {
+	TotalHits int `json:"totalhits"`
+	// Suggestion is the wiki's spelling correction of the query, if it has one.
+	Suggestion string `json:"suggestion"`
 }
 
 // Query is for the search API.
 type Query struct {
-	Search []SearchResult `json:"search"`
+	Search     []SearchResult `json:"search"`
+	SearchInfo SearchInfo     `json:"searchinfo"`
+}
+
+// Continue holds the offset of the next page of search results.
+type Continue struct {
+	Sroffset int `json:"sroffset"`
 }
 
 // Response is for the search API.
 type Response struct {
-	Query Query `json:"query"`
+	Query    Query     `json:"query"`
+	Continue *Continue `json:"continue"`
+	Error    *APIError `json:"error"`
+}
+
+// ListIndent is the number of spaces each nested list level is indented by.
+var ListIndent = 2
+
+// SearchTimeout bounds how long a search request may take.
+var SearchTimeout = 5 * time.Second
+
+// ArticleTimeout bounds how long each request for an article may take. Parsing a long
+// article takes the wiki far longer than a search.
+var ArticleTimeout = 15 * time.Second
+
+// Sort orders understood by the MediaWiki search API's srsort parameter.
+const (
+	SortRelevance = "relevance"
+	SortLastEdit  = "last_edit_desc"
+)
+
+// Search modes: full text, titles only, or a near match of the title. CirrusSearch, the
+// search engine of Wikimedia wikis, rejects srwhat=title, so a title search is sent as a
+// full-text search restricted with the intitle: operator; see searchQuery.
+const (
+	SearchText      = "text"
+	SearchTitle     = "title"
+	SearchNearMatch = "nearmatch"
+)
+
+// SearchOptions tweaks how PerformSearch queries the API.
+type SearchOptions struct {
+	// Sort is passed as srsort; empty leaves the wiki's default order.
+	Sort string
+	// What searches full text, titles only, or for a near match.
+	What string
+	// Category restricts results to pages in this category.
+	Category string
+	// Namespace is passed as srnamespace; 0, the main namespace, is the wiki's default.
+	Namespace int
+	// Limit is the number of results per page; zero uses the API default.
+	Limit int
+	// Offset is the index of the first result to return, for loading further pages.
+	Offset int
+	// Snippets fetches a short description and extract of each result in the same request.
+	// The total number of hits is not known for such searches.
+	Snippets bool
 }
 
 // Custom messages to pass data between functions.
 type SearchMsg struct {
 	Results []SearchResult
-	Err     error
+	// TotalHits is the total number of matches reported by the wiki, or 0 if unknown.
+	TotalHits int
+	// Offset echoes SearchOptions.Offset, so callers can tell a first page from a further one.
+	Offset int
+	// NextOffset is the offset of the next page of results, or 0 when there are no more.
+	NextOffset int
+	// Suggestion is the wiki's spelling correction of the query, if it has one.
+	Suggestion string
+	Err        error
+}
+
+// Article is a fetched article: its text and what is known about it.
+type Article struct {
+	// Title is the title the article was found under, after following redirects, or empty if
+	// unknown.
+	Title string
+	// DisplayTitle is the title as the wiki displays it, in plain text, or empty if unknown.
+	DisplayTitle string
+	Content      string
+	Sections     []Section
+	// RevID is the revision the content was rendered from, or 0 if unknown.
+	RevID int
+	// Fragment is the section a redirect pointed at, e.g. "History" for a redirect to
+	// "Go (programming language)#History".
+	Fragment string
+	// Categories lists the article's visible categories, without the "Category:" prefix.
+	Categories []string
+	// Description is the article's one-line short description, e.g. "Programming language",
+	// or empty on wikis that don't have them.
+	Description string
+	// Coordinates locate the article's subject, for articles about places; nil otherwise.
+	Coordinates *Coordinates
+	// Mode is the content mode the article was fetched in, one of ContentModes. An article
+	// that could only be had as an extract keeps the mode it was asked for.
+	Mode string
+	// Links lists the links to other articles in the text, which plain-text extracts don't have.
+	Links []Link
 }
+
+// ArticleMsg carries a fetched article, or the error that kept it from being fetched.
 type ArticleMsg struct {
+	Article
+	Err error
+}
+
+// ArticleLeadMsg carries the introduction of an article, shown while the full article loads.
+// Content is empty when there is nothing to show early, e.g. because the article is cached.
+type ArticleLeadMsg struct {
 	Content string
 	Err     error
 }
 
+// apiURL returns the endpoint of the MediaWiki API for a wiki.
+func apiURL(wikiType string) string {
+	return LookupSource(wikiType).API
+}
+
+// UseLang is the language text generated by the API is localized to, e.g. "de"; empty uses
+// each wiki's own language.
+var UseLang string
+
+// apiRequest returns the address of an API request to a wiki, with the uselang parameter that
+// keeps the text the API generates in one language.
+func apiRequest(wikiType string, params url.Values) string {
+	lang := UseLang
+	if lang == "" {
+		lang = LookupSource(wikiType).Language
+	}
+	if lang != "" {
+		params.Set("uselang", lang)
+	}
+	return apiURL(wikiType) + "?" + params.Encode()
+}
+
+// ArticleURL returns the address of an article on its wiki's website.
+func ArticleURL(title string, wikiType string) string {
+	return ArticleBase(wikiType) + strings.ReplaceAll(title, " ", "_")
+}
+
+// normalizeResults cleans up search results as different MediaWiki versions return them:
+// some, like ArchWiki's, may include markup such as <span class="searchmatch"> in titles.
+func normalizeResults(results []SearchResult) []SearchResult {
+	for i := range results {
+		results[i].Title = strings.Join(strings.Fields(stripMarkup(results[i].Title)), " ")
+		results[i].Description = stripMarkup(results[i].Description)
+	}
+	return results
+}
+
+// SearchURL returns the address of the wiki's own search page for term.
+func SearchURL(term string, wikiType string) string {
+	return indexURL(wikiType) + "?" + url.Values{"search": {term}}.Encode()
+}
+
+// PermalinkURL returns a permanent link to a revision of an article. When the revision is
+// unknown it falls back to the article's current URL.
+func PermalinkURL(title string, wikiType string, revID int) string {
+	if revID <= 0 {
+		return ArticleURL(title, wikiType)
+	}
+	params := url.Values{}
+	params.Set("title", strings.ReplaceAll(title, " ", "_"))
+	params.Set("oldid", strconv.Itoa(revID))
+	return indexURL(wikiType) + "?" + params.Encode()
+}
+
+// Request is a command whose requests are made with ctx, so that cancelling ctx abandons them.
+// The requests the user waits for are Requests; those made in the background are tea.Cmds.
+type Request func(ctx context.Context) tea.Msg
+
 // PerformSearch is a command that makes the API call.
-func PerformSearch(term string, wikiType string) tea.Cmd {
-	return func() tea.Msg {
-		urlStr := "https://en.wikipedia.org/w/api.php"
-		if wikiType == "arch" {
-			urlStr = "https://wiki.archlinux.org/api.php"
+func PerformSearch(term string, wikiType string, opts SearchOptions) Request {
+	return func(ctx context.Context) tea.Msg {
+		countSearch()
+		if opts.Snippets {
+			return searchWithSnippets(ctx, term, wikiType, opts)
 		}
 		params := url.Values{}
 		params.Add("action", "query")
 		params.Add("format", "json")
 		params.Add("list", "search")
-		params.Add("srsearch", term)
-		fullURL := urlStr + "?" + params.Encode()
-
-		req, err := http.NewRequest("GET", fullURL, nil)
-		if err != nil {
-			return SearchMsg{Err: err}
+		search, what := searchQuery(term, opts)
+		params.Add("srsearch", search)
+		params.Add("srinfo", "totalhits|suggestion")
+		if opts.Sort != "" {
+			params.Add("srsort", opts.Sort)
+		}
+		if what != "" {
+			params.Add("srwhat", what)
+		}
+		if opts.Namespace != 0 {
+			params.Add("srnamespace", strconv.Itoa(opts.Namespace))
 		}
-		req.Header.Set("User-Agent", "Your-CLI-Tool-Name/1.0 (Contact: your-email@example.com)")
+		if opts.Limit > 0 {
+			params.Add("srlimit", strconv.Itoa(opts.Limit))
+		}
+		if opts.Offset > 0 {
+			params.Add("sroffset", strconv.Itoa(opts.Offset))
+		}
+		fullURL := apiRequest(wikiType, params)
 
-		client := &http.Client{Timeout: 5 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
+		var data Response
+		if err := fetchJSON(ctx, "search", SearchTimeout, fullURL, &data); err != nil {
 			return SearchMsg{Err: err}
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return SearchMsg{Err: fmt.Errorf("API request failed with status code: %d %s", resp.StatusCode, resp.Status)}
+		if data.Error != nil {
+			return SearchMsg{Err: data.Error}
 		}
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return SearchMsg{Err: err}
+		msg := SearchMsg{
+			Results:    normalizeResults(data.Query.Search),
+			TotalHits:  data.Query.SearchInfo.TotalHits,
+			Offset:     opts.Offset,
+			Suggestion: data.Query.SearchInfo.Suggestion,
 		}
-		var data Response
-		if err := json.Unmarshal(body, &data); err != nil {
-			return SearchMsg{Err: fmt.Errorf("failed to parse API response: %w", err)}
+		if data.Continue != nil {
+			msg.NextOffset = data.Continue.Sroffset
 		}
-		return SearchMsg{Results: data.Query.Search}
+		return msg
 	}
 }
 
-// FetchArticle fetches the full article content.
-func FetchArticle(title string, wikiType string) tea.Cmd {
+// PrefixSearchMsg carries the titles that start with a search term.
+type PrefixSearchMsg struct {
+	Term   string
+	Titles []string
+	Err    error
+}
+
+// prefixSearchResponse matches the query API's list=prefixsearch response.
+type prefixSearchResponse struct {
+	Query struct {
+		PrefixSearch []struct {
+			Title string `json:"title"`
+		} `json:"prefixsearch"`
+	} `json:"query"`
+	Error *APIError `json:"error"`
+}
+
+// maxRelated is how many titles PrefixSearch suggests.
+const maxRelated = 10
+
+// PrefixSearch finds titles starting with term, to suggest when a full-text search finds little.
+func PrefixSearch(term string, wikiType string) tea.Cmd {
 	return func() tea.Msg {
-		urlStr := "https://en.wikipedia.org/w/api.php"
-		if wikiType == "arch" {
-			urlStr = "https://wiki.archlinux.org/api.php"
-		}
 		params := url.Values{}
-		params.Add("action", "parse")
+		params.Add("action", "query")
 		params.Add("format", "json")
-		params.Add("page", title)
-		fullURL := urlStr + "?" + params.Encode()
-		req, err := http.NewRequest("GET", fullURL, nil)
-		if err != nil {
-			return ArticleMsg{Err: err}
+		params.Add("list", "prefixsearch")
+		params.Add("pssearch", term)
+		params.Add("pslimit", strconv.Itoa(maxRelated))
+		var data prefixSearchResponse
+		if err := getBackgroundJSON(context.Background(), "search", SearchTimeout, apiRequest(wikiType, params), &data); err != nil {
+			return PrefixSearchMsg{Term: term, Err: err}
 		}
-		req.Header.Set("User-Agent", "Your-CLI-Tool-Name/1.0 (Contact: your-email@example.com)")
-		client := &http.Client{Timeout: 5 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			return ArticleMsg{Err: err}
+		if data.Error != nil {
+			return PrefixSearchMsg{Term: term, Err: data.Error}
+		}
+		msg := PrefixSearchMsg{Term: term}
+		for _, page := range data.Query.PrefixSearch {
+			msg.Titles = append(msg.Titles, page.Title)
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			return ArticleMsg{Err: fmt.Errorf("API request failed with status code: %d %s", resp.StatusCode, resp.Status)}
+		return msg
+	}
+}
+
+// FetchArticle fetches the full article content in the current content mode, serving it from
+// the session cache when possible.
+func FetchArticle(title string, wikiType string) Request {
+	return func(ctx context.Context) tea.Msg {
+		mode := ContentMode()
+		article, ok := cachedArticle(wikiType, title)
+		ok = ok && article.fetchedAs(mode)
+		countArticleFetch(ok)
+		if ok {
+			return ArticleMsg{Article: article}
+		}
+		msg := fetchArticle(ctx, title, wikiType, mode)
+		msg.Content = utils.BreakLongLines(msg.Content)
+		cacheArticle(wikiType, title, msg)
+		return msg
+	}
+}
+
+// FetchArticleLead fetches the plain-text introduction of an article, which is much quicker to
+// get than the full article, so it can be read while FetchArticle is still busy. Nothing is
+// fetched for cached articles, which FetchArticle returns right away.
+func FetchArticleLead(title string, wikiType string) Request {
+	return func(ctx context.Context) tea.Msg {
+		if IsCached(title, wikiType) {
+			return ArticleLeadMsg{}
 		}
-		body, err := io.ReadAll(resp.Body)
+		lead, err := fetchExtract(ctx, title, wikiType, true)
+		return ArticleLeadMsg{Content: utils.BreakLongLines(lead.Content), Err: err}
+	}
+}
+
+// IsCached reports whether FetchArticle would return the article from the cache, having
+// fetched it in the current content mode.
+func IsCached(title string, wikiType string) bool {
+	article, ok := cachedArticle(wikiType, title)
+	return ok && article.fetchedAs(ContentMode())
+}
+
+// RefreshArticle fetches the latest version of an article in the current content mode,
+// bypassing and updating the cache.
+func RefreshArticle(title string, wikiType string) Request {
+	return func(ctx context.Context) tea.Msg {
+		countArticleFetch(false)
+		msg := fetchArticle(ctx, title, wikiType, ContentMode())
+		msg.Content = utils.BreakLongLines(msg.Content)
+		cacheArticle(wikiType, title, msg)
+		return msg
+	}
+}
+
+// fetchArticle requests an article in the given content mode: from the parse API, made
+// readable or rendered as it is, or as a plain-text extract. The short description and
+// coordinates, which neither API provides, are fetched at the same time.
+func fetchArticle(ctx context.Context, title string, wikiType string, mode string) ArticleMsg {
+	infoCtx, cancelInfo := context.WithCancel(ctx)
+	defer cancelInfo()
+	info := make(chan pageInfo, 1)
+	go func() {
+		info <- fetchPageInfo(infoCtx, title, wikiType)
+	}()
+	if mode == ContentExtract {
+		text, err := fetchExtract(ctx, title, wikiType, false)
 		if err != nil {
 			return ArticleMsg{Err: err}
 		}
-		var data ArticleResponse
-		if err := json.Unmarshal(body, &data); err != nil {
-			return ArticleMsg{Err: fmt.Errorf("failed to parse article response: %w", err)}
+		if strings.TrimSpace(text.Content) == "" {
+			return ArticleMsg{Err: errors.New("no extract for this page")}
 		}
-		parsedURL, err := url.Parse(fullURL)
-		if err != nil {
-			return ArticleMsg{Err: fmt.Errorf("failed to parse URL: %w", err)}
+		page := awaitPageInfo(info)
+		article := text.article(mode)
+		article.Description, article.Coordinates = page.description, page.coordinates
+		return ArticleMsg{Article: article}
+	}
+	params := url.Values{}
+	params.Add("action", "parse")
+	params.Add("format", "json")
+	params.Add("page", title)
+	params.Add("prop", "text|sections|displaytitle|categories")
+	params.Add("redirects", "1")
+	// The table of contents and the [edit] links would only end up as stray text; the
+	// sections are listed by prop=sections anyway.
+	params.Add("disabletoc", "1")
+	params.Add("disableeditsection", "1")
+	fullURL := apiRequest(wikiType, params)
+	var data ArticleResponse
+	if err := fetchJSON(ctx, "article fetch", ArticleTimeout, fullURL, &data); err != nil {
+		return ArticleMsg{Err: err}
+	}
+	if data.Error != nil {
+		return ArticleMsg{Err: data.Error}
+	}
+	if data.Parse == nil || data.Parse.Text == nil || strings.TrimSpace(data.Parse.Text.Content) == "" {
+		if text, err := fetchExtract(ctx, title, wikiType, false); err == nil && strings.TrimSpace(text.Content) != "" {
+			return ArticleMsg{Article: text.article(mode)}
+		}
+		return ArticleMsg{Err: errors.New("no parseable content for this page")}
+	}
+	parsedURL, err := url.Parse(fullURL)
+	if err != nil {
+		return ArticleMsg{Err: fmt.Errorf("failed to parse URL: %w", err)}
+	}
+	htmlContent := data.Parse.Text.Content
+	links := articleLinks(htmlContent, ArticleBase(wikiType))
+	if marked, err := utils.MarkListItems(htmlContent, ListIndent); err == nil {
+		htmlContent = marked
+	}
+	if marked, err := utils.MarkQuotes(htmlContent); err == nil {
+		htmlContent = marked
+	}
+	if marked, err := utils.MarkCodeBlocks(htmlContent); err == nil {
+		htmlContent = marked
+	}
+	if marked, err := utils.MarkTables(htmlContent); err == nil {
+		htmlContent = marked
+	}
+	var content string
+	if mode == ContentHTML {
+		if content, err = utils.HTMLText(htmlContent); err != nil {
+			return ArticleMsg{Err: fmt.Errorf("failed to render content: %w", err)}
 		}
-		contentReader := bytes.NewReader([]byte(data.Parse.Text.Content))
-		article, err := readability.FromReader(contentReader, parsedURL)
+	} else {
+		article, err := readability.FromReader(bytes.NewReader([]byte(htmlContent)), parsedURL)
 		if err != nil {
+			if text, extractErr := fetchExtract(ctx, title, wikiType, false); extractErr == nil && strings.TrimSpace(text.Content) != "" {
+				return ArticleMsg{Article: text.article(mode)}
+			}
 			return ArticleMsg{Err: fmt.Errorf("failed to make content readable: %w", err)}
 		}
-		return ArticleMsg{Content: article.TextContent}
+		content = article.TextContent
+	}
+	page := awaitPageInfo(info)
+	article := Article{
+		Title:        data.Parse.Title,
+		Content:      content,
+		Sections:     data.Parse.Sections,
+		RevID:        data.Parse.RevID,
+		DisplayTitle: stripMarkup(data.Parse.DisplayTitle),
+		Description:  page.description,
+		Coordinates:  page.coordinates,
+		Mode:         mode,
+		Links:        links,
+	}
+	for _, redirect := range data.Parse.Redirects {
+		article.Fragment = redirect.ToFragment
+	}
+	for _, category := range data.Parse.Categories {
+		if category.Hidden == nil {
+			article.Categories = append(article.Categories, strings.ReplaceAll(category.Name, "_", " "))
+		}
+	}
+	return ArticleMsg{Article: article}
+}
+
+// pageInfoWait is how long an article that is ready waits for its short description and
+// coordinates before it is shown without them.
+var pageInfoWait = 500 * time.Millisecond
+
+// awaitPageInfo returns the page info fetched alongside an article, or none if it doesn't
+// arrive within pageInfoWait.
+func awaitPageInfo(info <-chan pageInfo) pageInfo {
+	timer := time.NewTimer(pageInfoWait)
+	defer timer.Stop()
+	select {
+	case page := <-info:
+		return page
+	case <-timer.C:
+		return pageInfo{}
+	}
+}
+
+// pageInfoResponse matches the query API's prop=description|coordinates response in format
+// version 2. Wikis without the extension behind one of them just leave it out.
+type pageInfoResponse struct {
+	Query struct {
+		Pages []struct {
+			Description string `json:"description"`
+			Coordinates []struct {
+				Coordinates
+				Globe string `json:"globe"`
+			} `json:"coordinates"`
+		} `json:"pages"`
+	} `json:"query"`
+}
+
+// pageInfo is what fetchPageInfo found out about an article.
+type pageInfo struct {
+	description string
+	coordinates *Coordinates
+}
+
+// fetchPageInfo returns the short description and the coordinates of an article. They are a
+// nicety, so they are missing when the wiki has none or the request fails, and fetching them
+// takes at most SearchTimeout.
+func fetchPageInfo(ctx context.Context, title string, wikiType string) pageInfo {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	params.Set("prop", "description|coordinates")
+	// Only the coordinates of the article's subject, not of the places it mentions.
+	params.Set("coprimary", "primary")
+	params.Set("redirects", "1")
+	params.Set("titles", title)
+	var data pageInfoResponse
+	if err := getBackgroundJSON(ctx, "description fetch", SearchTimeout, apiRequest(wikiType, params), &data); err != nil {
+		return pageInfo{}
+	}
+	return parsePageInfo(data)
+}
+
+// parsePageInfo picks the description and coordinates out of a page info response.
+func parsePageInfo(data pageInfoResponse) pageInfo {
+	var info pageInfo
+	for _, page := range data.Query.Pages {
+		if info.description == "" && page.Description != "" {
+			info.description = strings.TrimSpace(page.Description)
+		}
+		for _, c := range page.Coordinates {
+			// Craters on the Moon have coordinates too, which no map of the Earth shows.
+			if info.coordinates == nil && (c.Globe == "" || c.Globe == "earth") {
+				coordinates := c.Coordinates
+				info.coordinates = &coordinates
+			}
+		}
+	}
+	return info
+}
+
+// CleanCategory normalizes a category name for use in an incategory: operator. It drops a
+// leading "Category:" namespace, double quotes and control characters, which would otherwise
+// break out of the quoted operator value.
+func CleanCategory(category string) string {
+	category = cleanValue(category)
+	if len(category) >= len("category:") && strings.EqualFold(category[:len("category:")], "category:") {
+		category = strings.TrimSpace(category[len("category:"):])
+	}
+	return category
+}
+
+// searchQuery returns the search string and srwhat value for a search. A title search
+// prefixes every word with intitle:, which every search engine behind the API understands,
+// and leaves srwhat empty.
+func searchQuery(term string, opts SearchOptions) (search, what string) {
+	if opts.What != SearchTitle {
+		return ComposeSearch(term, opts.Category), opts.What
+	}
+	words := strings.Fields(term)
+	for i, word := range words {
+		words[i] = "intitle:" + word
+	}
+	return ComposeSearch(strings.Join(words, " "), opts.Category), ""
+}
+
+// ComposeSearch restricts term to a category with the incategory: operator.
+// An empty category leaves term unchanged.
+func ComposeSearch(term, category string) string {
+	category = CleanCategory(category)
+	if category == "" {
+		return term
 	}
+	return strings.TrimSpace(`incategory:"` + category + `" ` + term)
 }
@@ -0,0 +1,93 @@
+package wiki
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		term   string
+		opts   SearchOptions
+		search string
+		what   string
+	}{
+		{"text", "go language", SearchOptions{What: SearchText}, "go language", SearchText},
+		{"default", "go", SearchOptions{}, "go", ""},
+		{"near match", "go", SearchOptions{What: SearchNearMatch}, "go", SearchNearMatch},
+		{"title", "go  language", SearchOptions{What: SearchTitle}, "intitle:go intitle:language", ""},
+		{"title in category", "go", SearchOptions{What: SearchTitle, Category: "Category:Languages"}, `incategory:"Languages" intitle:go`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search, what := searchQuery(tt.term, tt.opts)
+			if search != tt.search || what != tt.what {
+				t.Errorf("searchQuery(%q) = %q, %q; want %q, %q", tt.term, search, what, tt.search, tt.what)
+			}
+		})
+	}
+}
+
+func TestSnippetLimit(t *testing.T) {
+	for limit, want := range map[int]int{0: 10, 5: 5, 20: 20, 50: maxExtracts, 500: maxExtracts} {
+		if got := snippetLimit(limit); got != want {
+			t.Errorf("snippetLimit(%d) = %d, want %d", limit, got, want)
+		}
+	}
+}
+
+func TestUseLang(t *testing.T) {
+	interval := MinRequestInterval
+	MinRequestInterval = 0
+	defer func() { MinRequestInterval = interval }()
+	var mu sync.Mutex
+	langs := map[string]string{}
+	name := testWiki(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		langs[r.FormValue("action")+" "+r.FormValue("prop")+r.FormValue("list")] = r.FormValue("uselang")
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	})
+	// requestLangs makes a search and fetches an article, returning the uselang of each request.
+	requestLangs := func() map[string]string {
+		mu.Lock()
+		clear(langs)
+		mu.Unlock()
+		PerformSearch("Berlin", name, SearchOptions{})(context.Background())
+		fetchArticle(context.Background(), "Berlin", name, ContentReadable)
+		mu.Lock()
+		defer mu.Unlock()
+		return maps.Clone(langs)
+	}
+
+	for request, lang := range requestLangs() {
+		if lang != "en" {
+			t.Errorf("%s: uselang %q, want the wiki's language en", request, lang)
+		}
+	}
+
+	defer func(lang string) { UseLang = lang }(UseLang)
+	UseLang = "de"
+	got := requestLangs()
+	if got["query search"] != "de" || got["parse text|sections|displaytitle|categories"] != "de" {
+		t.Errorf("uselang per request %v, want de on the search and the parse", got)
+	}
+	for request, lang := range got {
+		if lang != "de" {
+			t.Errorf("%s: uselang %q, want the configured de", request, lang)
+		}
+	}
+
+	// A wiki of unknown language is left to its default.
+	UseLang = ""
+	Sources[len(Sources)-1].Language = ""
+	for request, lang := range requestLangs() {
+		if lang != "" {
+			t.Errorf("%s: uselang %q on a wiki without a language, want none", request, lang)
+		}
+	}
+}